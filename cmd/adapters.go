@@ -13,4 +13,21 @@ var adapters = []walder.Dimensioner{
 	lib.CfgDim{},
 	lib.String{},
 	lib.Sway{},
+	lib.MermaidDim{},
+	lib.GraphMLDim{},
+	lib.GEXFDim{},
+	lib.CSVDim{},
+	lib.JSONDim{},
+	lib.YAMLDim{},
+	lib.OpenAPIDim{},
+	lib.NotesDim{},
+	lib.OutlineDim{},
+	lib.MakeDim{},
+	lib.ProcDim{},
+	lib.SystemdDim{},
+	lib.ManifestDim{},
+	lib.TerraformDim{},
+	lib.LockDim{},
+	lib.DpkgDim{},
+	lib.SQLiteDim{},
 }
@@ -0,0 +1,565 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// CSVDim is a generator for graphs stored as csv edge lists or adjacency matrices.
+// Source and Target name the header columns which hold the ends of a edge,
+// if empty the first column which is not taken by the other one is used. Comma defaults to ','.
+// Once opened the columns can also be changed through the dimensions of the graph.
+type CSVDim struct {
+	Source string
+	Target string
+	Comma  rune
+}
+
+var _ walder.Dimensioner = CSVDim{}
+
+func (d CSVDim) String() string {
+	return "csv"
+}
+
+// New returns a empty edge list with a source and target column
+func (d CSVDim) New() (walder.Graph, error) {
+	source, target := d.Source, d.Target
+	if source == "" {
+		source = "source"
+	}
+	if target == "" {
+		target = "target"
+	}
+	return &csvGraph{
+		dim:    dimEdgeList,
+		comma:  d.comma(),
+		header: []string{source, target},
+		source: 0,
+		target: 1,
+	}, nil
+}
+
+func (d CSVDim) comma() rune {
+	if d.Comma == 0 {
+		return ','
+	}
+	return d.Comma
+}
+
+var _ walder.OpenReader = CSVDim{}
+
+// Open reads a edge list or, if the header and the first column name the same nodes, a adjacency matrix
+func (d CSVDim) Open(from io.Reader) (walder.Graph, error) {
+	r := csv.NewReader(from)
+	r.Comma = d.comma()
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	if len(records) == 0 {
+		return d.New()
+	}
+	if isAdjacencyMatrix(records) {
+		return d.openMatrix(records)
+	}
+	return d.openEdgeList(records)
+}
+
+func isAdjacencyMatrix(records [][]string) bool {
+	header := records[0]
+	if len(header) < 2 || header[0] != "" || len(records) != len(header) {
+		return false
+	}
+	for i, row := range records[1:] {
+		if len(row) != len(header) || row[0] != header[i+1] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d CSVDim) openEdgeList(records [][]string) (walder.Graph, error) {
+	g := &csvGraph{
+		dim:    dimEdgeList,
+		comma:  d.comma(),
+		header: records[0],
+		source: -1,
+		target: -1,
+	}
+	if len(g.header) < 2 {
+		return nil, fmt.Errorf("a edge list needs at least two columns, but got %d", len(g.header))
+	}
+	for i, name := range g.header {
+		if d.Source != "" && name == d.Source {
+			g.source = i
+		}
+		if d.Target != "" && name == d.Target {
+			g.target = i
+		}
+	}
+	if d.Source != "" && g.source < 0 {
+		return nil, fmt.Errorf("source column '%s' not found in header", d.Source)
+	}
+	if d.Target != "" && g.target < 0 {
+		return nil, fmt.Errorf("target column '%s' not found in header", d.Target)
+	}
+	// a column which is not named is the first one which is not taken by the other
+	if g.source < 0 {
+		g.source = 0
+		if g.target == 0 {
+			g.source = 1
+		}
+	}
+	if g.target < 0 {
+		g.target = 0
+		if g.source == 0 {
+			g.target = 1
+		}
+	}
+	if g.source == g.target {
+		return nil, fmt.Errorf("source and target can not be the same column")
+	}
+	for _, record := range records[1:] {
+		g.addRow(record)
+	}
+	return g, nil
+}
+
+func (d CSVDim) openMatrix(records [][]string) (walder.Graph, error) {
+	g := &csvGraph{
+		dim:    dimMatrix,
+		comma:  d.comma(),
+		header: []string{"source", "target", csvWeight},
+		source: 0,
+		target: 1,
+	}
+	nodes := records[0][1:]
+	g.nodes = append(g.nodes, nodes...)
+	for i, record := range records[1:] {
+		for k, cell := range record[1:] {
+			if cell == "" || cell == "0" {
+				continue
+			}
+			g.rows = append(g.rows, []string{nodes[i], nodes[k], cell})
+		}
+	}
+	return g, nil
+}
+
+const (
+	dimEdgeList dimension = "edge list"
+	dimMatrix   dimension = "adjacency matrix"
+)
+
+// the prefixes of the dimensions which choose the columns of the edge ends
+const (
+	csvSourceColumn = "source column: "
+	csvTargetColumn = "target column: "
+)
+
+// csvWeight is the column used for the cells of a adjacency matrix
+const csvWeight = "weight"
+
+type csvNode string
+
+func (n csvNode) String() string {
+	return string(n)
+}
+
+// csvGraph holds every edge as a row in the layout of the header,
+// which dimension is set only decides how the graph is written.
+type csvGraph struct {
+	dim    dimension
+	comma  rune
+	header []string
+	source int
+	target int
+	nodes  []string
+	rows   [][]string
+}
+
+// addRow adds a row and the nodes it names, a empty source or target is a node without edge.
+func (g *csvGraph) addRow(row []string) {
+	for _, n := range []string{row[g.source], row[g.target]} {
+		if n != "" && !g.has(n) {
+			g.nodes = append(g.nodes, n)
+		}
+	}
+	g.rows = append(g.rows, row)
+}
+
+func (g *csvGraph) has(node string) bool {
+	for _, n := range g.nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *csvGraph) lookup(str fmt.Stringer) (csvNode, error) {
+	if str == nil {
+		return "", fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(csvNode)
+	if !ok {
+		return n, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	if !g.has(string(n)) {
+		return n, fmt.Errorf("'%s' is not part of this graph", n)
+	}
+	return n, nil
+}
+
+// isEdge reports if the row connects the given nodes
+func (g *csvGraph) isEdge(row []string, from, to csvNode) bool {
+	return row[g.source] == string(from) && row[g.target] == string(to)
+}
+
+var _ walder.Graph = &csvGraph{}
+
+func (g *csvGraph) String() string {
+	return fmt.Sprintf("csv %s", g.dim)
+}
+
+func (g *csvGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return g.NodeAll()
+}
+
+var _ walder.NodeAller = &csvGraph{}
+
+func (g *csvGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		all = append(all, csvNode(n))
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &csvGraph{}
+
+func (g *csvGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	for _, row := range g.rows {
+		if row[g.source] == string(n) && row[g.target] != "" {
+			out = append(out, csvNode(row[g.target]))
+		}
+	}
+	return out, nil
+}
+
+func (g *csvGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, row := range g.rows {
+		if row[g.target] == string(n) && row[g.source] != "" {
+			in = append(in, csvNode(row[g.source]))
+		}
+	}
+	return in, nil
+}
+
+var _ walder.DimensionChanger = &csvGraph{}
+
+// DimensionGetAll returns the layouts and for every other column the choice to use it as source or target
+func (g *csvGraph) DimensionGetAll() ([]fmt.Stringer, error) {
+	dims := []fmt.Stringer{
+		stringer(dimEdgeList),
+		stringer(dimMatrix),
+	}
+	for i, name := range g.header {
+		if i != g.source {
+			dims = append(dims, stringer(csvSourceColumn+name))
+		}
+	}
+	for i, name := range g.header {
+		if i != g.target {
+			dims = append(dims, stringer(csvTargetColumn+name))
+		}
+	}
+	return dims, nil
+}
+
+// DimensionSet sets the layout which is written or the column used as source or target,
+// choosing the column of the other end swaps source and target.
+func (g *csvGraph) DimensionSet(dim fmt.Stringer) error {
+	if dim == nil {
+		return fmt.Errorf("recieved nil value")
+	}
+	switch dim := dim.String(); {
+	case dim == string(dimEdgeList), dim == string(dimMatrix):
+		g.dim = dimension(dim)
+		return nil
+	case strings.HasPrefix(dim, csvSourceColumn):
+		return g.setColumns(strings.TrimPrefix(dim, csvSourceColumn), &g.source, &g.target)
+	case strings.HasPrefix(dim, csvTargetColumn):
+		return g.setColumns(strings.TrimPrefix(dim, csvTargetColumn), &g.target, &g.source)
+	}
+	return fmt.Errorf("dimension '%s' not known to this graph", dim)
+}
+
+// setColumns sets the end to the named column and reads the nodes again from the rows
+func (g *csvGraph) setColumns(name string, end, other *int) error {
+	column := -1
+	for i, n := range g.header {
+		if n == name {
+			column = i
+			break
+		}
+	}
+	if column < 0 {
+		return fmt.Errorf("column '%s' not found in header", name)
+	}
+	// nodes without a edge only exist in the current columns, so they are kept as rows of there own
+	records := g.edgeList()
+	if column == *other {
+		*other = *end
+	}
+	*end = column
+	g.nodes, g.rows = nil, nil
+	for _, row := range records[1:] {
+		g.addRow(row)
+	}
+	return nil
+}
+
+var _ walder.GraphCreater = &csvGraph{}
+
+// NodeCreate adds a node without any edge, in a edge list it is written as row with only the source set
+func (g *csvGraph) NodeCreate(input fmt.Stringer) (fmt.Stringer, error) {
+	if input == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	name := input.String()
+	if name == "" {
+		return nil, fmt.Errorf("node name can not be empty")
+	}
+	if g.has(name) {
+		return nil, fmt.Errorf("'%s' is already part of this graph", name)
+	}
+	g.nodes = append(g.nodes, name)
+	return csvNode(name), nil
+}
+
+func (g *csvGraph) NodeUpdate(toUpdate fmt.Stringer) (fmt.Stringer, error) {
+	return g.lookup(toUpdate)
+}
+
+// EdgeCreate adds a new row with all other columns left empty
+func (g *csvGraph) EdgeCreate(from, to fmt.Stringer) error {
+	f, err := g.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return err
+	}
+	row := make([]string, len(g.header))
+	row[g.source], row[g.target] = string(f), string(t)
+	g.rows = append(g.rows, row)
+	return nil
+}
+
+var _ walder.NodeDeleter = &csvGraph{}
+
+func (g *csvGraph) NodeDelete(toDelete fmt.Stringer) error {
+	n, err := g.lookup(toDelete)
+	if err != nil {
+		return err
+	}
+	g.nodes = removeString(g.nodes, string(n))
+	rows := make([][]string, 0, len(g.rows))
+	for _, row := range g.rows {
+		if row[g.source] == string(n) || row[g.target] == string(n) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	g.rows = rows
+	return nil
+}
+
+var _ walder.EdgeDeleter = &csvGraph{}
+
+func (g *csvGraph) EdgeDelete(from, to fmt.Stringer) error {
+	f, err := g.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(g.rows))
+	for _, row := range g.rows {
+		if g.isEdge(row, f, t) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	g.rows = rows
+	return nil
+}
+
+var _ walder.EdgeLabelAdder = &csvGraph{}
+
+func (g *csvGraph) edge(from, to fmt.Stringer) ([]string, error) {
+	f, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range g.rows {
+		if g.isEdge(row, f, t) {
+			return row, nil
+		}
+	}
+	return nil, fmt.Errorf("no edge from '%s' to '%s'", f, t)
+}
+
+// EdgeLabels returns all columns besides source and target of the first row of the edge
+func (g *csvGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	row, err := g.edge(from, to)
+	if err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	for i, name := range g.header {
+		if i == g.source || i == g.target {
+			continue
+		}
+		labels = append(labels, [2]string{name, row[i]})
+	}
+	return labels, nil
+}
+
+// EdgeLabelAdd sets the column of the first row of the edge and adds the column if its not known
+func (g *csvGraph) EdgeLabelAdd(from, to fmt.Stringer, key, value string) error {
+	row, err := g.edge(from, to)
+	if err != nil {
+		return err
+	}
+	column := -1
+	for i, name := range g.header {
+		if name == key {
+			column = i
+		}
+	}
+	if column == g.source || column == g.target {
+		return fmt.Errorf("'%s' is the column of a node and can not be set as label", key)
+	}
+	if column < 0 {
+		g.header = append(g.header, key)
+		for i := range g.rows {
+			g.rows[i] = append(g.rows[i], "")
+		}
+		column = len(g.header) - 1
+		// the rows where reallocated
+		row, err = g.edge(from, to)
+		if err != nil {
+			return err
+		}
+	}
+	row[column] = value
+	return nil
+}
+
+var _ walder.GetReader = &csvGraph{}
+
+func (g *csvGraph) GetReader() (io.Reader, error) {
+	b := &bytes.Buffer{}
+	w := csv.NewWriter(b)
+	w.Comma = g.comma
+	var err error
+	switch g.dim {
+	case dimMatrix:
+		err = w.WriteAll(g.matrix())
+	case dimEdgeList:
+		err = w.WriteAll(g.edgeList())
+	default:
+		return nil, fmt.Errorf("unhandled dimensions: %s", g.dim)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// edgeList returns the header and rows, nodes without any row get one of there own
+func (g *csvGraph) edgeList() [][]string {
+	records := [][]string{g.header}
+	used := make(map[string]bool)
+	for _, row := range g.rows {
+		used[row[g.source]] = true
+		used[row[g.target]] = true
+		records = append(records, row)
+	}
+	for _, n := range g.nodes {
+		if used[n] {
+			continue
+		}
+		row := make([]string, len(g.header))
+		row[g.source] = n
+		records = append(records, row)
+	}
+	return records
+}
+
+// matrix returns the adjacency matrix with the weight column as cell value or 1 if its missing or empty
+func (g *csvGraph) matrix() [][]string {
+	index := make(map[string]int, len(g.nodes))
+	header := make([]string, 0, len(g.nodes)+1)
+	header = append(header, "")
+	for i, n := range g.nodes {
+		index[n] = i + 1
+		header = append(header, n)
+	}
+	weight := -1
+	for i, name := range g.header {
+		if name == csvWeight && i != g.source && i != g.target {
+			weight = i
+		}
+	}
+
+	records := [][]string{header}
+	for _, n := range g.nodes {
+		row := make([]string, len(header))
+		row[0] = n
+		for i := range row[1:] {
+			row[i+1] = "0"
+		}
+		records = append(records, row)
+	}
+	for _, row := range g.rows {
+		from, ok := index[row[g.source]]
+		if !ok {
+			continue
+		}
+		to, ok := index[row[g.target]]
+		if !ok {
+			continue
+		}
+		value := "1"
+		if weight >= 0 && row[weight] != "" {
+			value = row[weight]
+		}
+		records[from][to] = value
+	}
+	return records
+}
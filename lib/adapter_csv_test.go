@@ -0,0 +1,177 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/treilik/walder"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		dim    CSVDim
+		source string
+		want   string
+	}{
+		{"edge list", CSVDim{}, "from,to,weight\na,b,2\nb,c,\n", "from,to,weight\na,b,2\nb,c,\n"},
+		{"named columns", CSVDim{Source: "to", Target: "from"}, "from,to\na,b\n", "from,to\na,b\n"},
+		{"semicolon", CSVDim{Comma: ';'}, "s;t\na;b\n", "s;t\na;b\n"},
+		{"node without edge", CSVDim{}, "s,t\na,\n", "s,t\na,\n"},
+		{"matrix", CSVDim{}, ",a,b\na,0,3\nb,1,0\n", ",a,b\na,0,3\nb,1,0\n"},
+		{"empty", CSVDim{}, "", "source,target\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := tt.dim.Open(strings.NewReader(tt.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readAll(t, g); got != tt.want {
+				t.Errorf("want:\n%s\nbut got:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		dim    CSVDim
+		source string
+	}{
+		{"one column", CSVDim{}, "a\nb\n"},
+		{"missing source", CSVDim{Source: "x"}, "s,t\na,b\n"},
+		{"missing target", CSVDim{Target: "x"}, "s,t\na,b\n"},
+		{"same column", CSVDim{Source: "s", Target: "s"}, "s,t\na,b\n"},
+		{"uneven rows", CSVDim{}, "s,t\na\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.dim.Open(strings.NewReader(tt.source)); err == nil {
+				t.Errorf("want error, but got none")
+			}
+		})
+	}
+}
+
+func TestCSVOpenColumns(t *testing.T) {
+	const source = "s,t,via\na,b,x\n"
+	tests := []struct {
+		name   string
+		dim    CSVDim
+		source string
+		target string
+	}{
+		{"default", CSVDim{}, "s", "t"},
+		{"source second", CSVDim{Source: "t"}, "t", "s"},
+		{"source third", CSVDim{Source: "via"}, "via", "s"},
+		{"target first", CSVDim{Target: "s"}, "t", "s"},
+		{"target third", CSVDim{Target: "via"}, "s", "via"},
+		{"both", CSVDim{Source: "via", Target: "t"}, "via", "t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := tt.dim.Open(strings.NewReader(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := g.(*csvGraph)
+			if got := c.header[c.source]; got != tt.source {
+				t.Errorf("want source column '%s', but got '%s'", tt.source, got)
+			}
+			if got := c.header[c.target]; got != tt.target {
+				t.Errorf("want target column '%s', but got '%s'", tt.target, got)
+			}
+		})
+	}
+}
+
+func TestCSVColumns(t *testing.T) {
+	const source = "s,t,via\na,b,x\nb,c,y\n"
+	tests := []struct {
+		name    string
+		dims    []string
+		nodes   string
+		from    csvNode
+		out     string
+		wantErr bool
+	}{
+		{name: "default", nodes: "a b c", from: "a", out: "b"},
+		{name: "target", dims: []string{csvTargetColumn + "via"}, nodes: "a b x y", from: "a", out: "x"},
+		{name: "swap", dims: []string{csvSourceColumn + "t"}, nodes: "a b c", from: "b", out: "a"},
+		{name: "both", dims: []string{csvSourceColumn + "via", csvTargetColumn + "s"}, nodes: "a b x y", from: "x", out: "a"},
+		{name: "unknown column", dims: []string{csvSourceColumn + "z"}, wantErr: true},
+		{name: "unknown dimension", dims: []string{"z"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := CSVDim{}.Open(strings.NewReader(source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := g.(walder.DimensionChanger)
+			for _, dim := range tt.dims {
+				err = d.DimensionSet(stringer(dim))
+				if err != nil {
+					break
+				}
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			all, err := g.(walder.NodeAller).NodeAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(all); got != tt.nodes {
+				t.Errorf("want nodes '%s', but got '%s'", tt.nodes, got)
+			}
+			out, err := g.(walder.GraphDirected).Outgoing(tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(out); got != tt.out {
+				t.Errorf("want outgoing '%s', but got '%s'", tt.out, got)
+			}
+			// the layout of the rows is kept
+			if got := readAll(t, g); got != source {
+				t.Errorf("want:\n%s\nbut got:\n%s", source, got)
+			}
+		})
+	}
+}
+
+func TestCSVColumnsKeepNodes(t *testing.T) {
+	g, err := CSVDim{}.Open(strings.NewReader("s,t\na,b\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := g.(*csvGraph)
+	if _, err := c.NodeCreate(stringer("lonely")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DimensionSet(stringer(csvSourceColumn + "t")); err != nil {
+		t.Fatal(err)
+	}
+	all, err := c.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sortedNames(all), "a b lonely"; got != want {
+		t.Errorf("want nodes '%s', but got '%s'", want, got)
+	}
+	in, err := c.Incoming(csvNode("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(in), "b"; got != want {
+		t.Errorf("want incoming '%s', but got '%s'", want, got)
+	}
+}
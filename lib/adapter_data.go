@@ -0,0 +1,664 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/treilik/walder"
+	"gopkg.in/yaml.v3"
+)
+
+// JSONDim is a generator for JSON documents shown as trees
+type JSONDim struct{}
+
+var _ walder.Dimensioner = JSONDim{}
+
+func (d JSONDim) String() string {
+	return "json"
+}
+
+// New returns a document holding a empty object
+func (d JSONDim) New() (walder.Graph, error) {
+	return newDataGraph(dataJSON), nil
+}
+
+var _ walder.OpenReader = JSONDim{}
+
+func (d JSONDim) Open(from io.Reader) (walder.Graph, error) {
+	return openDataGraph(dataJSON, from)
+}
+
+// YAMLDim is a generator for YAML documents shown as trees
+type YAMLDim struct{}
+
+var _ walder.Dimensioner = YAMLDim{}
+
+func (d YAMLDim) String() string {
+	return "yaml"
+}
+
+// New returns a document holding a empty mapping
+func (d YAMLDim) New() (walder.Graph, error) {
+	return newDataGraph(dataYAML), nil
+}
+
+var _ walder.OpenReader = YAMLDim{}
+
+func (d YAMLDim) Open(from io.Reader) (walder.Graph, error) {
+	return openDataGraph(dataYAML, from)
+}
+
+type dataFormat string
+
+const (
+	dataJSON dataFormat = "json"
+	dataYAML dataFormat = "yaml"
+)
+
+func newDataGraph(format dataFormat) *dataGraph {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	g := &dataGraph{
+		format: format,
+		docs:   []*yaml.Node{{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}},
+	}
+	g.index()
+	return g
+}
+
+// openDataGraph parses JSON and YAML alike, since JSON is a subset of YAML.
+// YAML streams with several documents keep all of them, each with its own root.
+func openDataGraph(format dataFormat, from io.Reader) (walder.Graph, error) {
+	all, err := io.ReadAll(from)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	if len(bytes.TrimSpace(all)) == 0 {
+		return newDataGraph(format), nil
+	}
+	g := &dataGraph{format: format}
+	dec := yaml.NewDecoder(bytes.NewReader(all))
+	for {
+		doc := &yaml.Node{}
+		err := dec.Decode(doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error while opening from Reader: %w", err)
+		}
+		if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 {
+			return nil, fmt.Errorf("want a document with one root, but got %d", len(doc.Content))
+		}
+		g.docs = append(g.docs, doc)
+	}
+	if len(g.docs) == 0 {
+		return newDataGraph(format), nil
+	}
+	if format == dataJSON && len(g.docs) > 1 {
+		return nil, fmt.Errorf("want a single json document, but got %d", len(g.docs))
+	}
+	g.index()
+	return g, nil
+}
+
+// dataNode is a object, array or scalar of the document together with the key under which it was found
+type dataNode struct {
+	key  string
+	node *yaml.Node
+}
+
+func (n dataNode) String() string {
+	var value string
+	switch n.node.Kind {
+	case yaml.MappingNode:
+		value = fmt.Sprintf("{%d}", len(n.node.Content)/2)
+	case yaml.SequenceNode:
+		value = fmt.Sprintf("[%d]", len(n.node.Content))
+	case yaml.AliasNode:
+		value = "*" + n.node.Value
+	default:
+		value = n.node.Value
+	}
+	if n.key == "" {
+		return value
+	}
+	return fmt.Sprintf("%s: %s", n.key, value)
+}
+
+type dataGraph struct {
+	format dataFormat
+	// docs holds the documents in the order of the stream
+	docs    []*yaml.Node
+	parents map[*yaml.Node]*yaml.Node
+}
+
+// index remembers the parent of every value, keys of mappings are not part of the graph
+func (g *dataGraph) index() {
+	g.parents = make(map[*yaml.Node]*yaml.Node)
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		for i, c := range n.Content {
+			if n.Kind == yaml.MappingNode && i%2 == 0 {
+				continue
+			}
+			g.parents[c] = n
+			walk(c)
+		}
+	}
+	for _, r := range g.roots() {
+		walk(r)
+	}
+}
+
+// roots returns the top value of every document
+func (g *dataGraph) roots() []*yaml.Node {
+	roots := make([]*yaml.Node, 0, len(g.docs))
+	for _, doc := range g.docs {
+		roots = append(roots, doc.Content[0])
+	}
+	return roots
+}
+
+func (g *dataGraph) isRoot(n *yaml.Node) bool {
+	for _, r := range g.roots() {
+		if r == n {
+			return true
+		}
+	}
+	return false
+}
+
+// position returns the index of the value within the content of its parent
+func (g *dataGraph) position(n *yaml.Node) (*yaml.Node, int, error) {
+	parent, ok := g.parents[n]
+	if !ok {
+		return nil, 0, fmt.Errorf("the root has no parent")
+	}
+	for i, c := range parent.Content {
+		if c == n && (parent.Kind != yaml.MappingNode || i%2 == 1) {
+			return parent, i, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("node not found in its parent")
+}
+
+// key returns the key of a mapping value or the index of a sequence element
+func (g *dataGraph) key(n *yaml.Node) string {
+	parent, i, err := g.position(n)
+	if err != nil {
+		return ""
+	}
+	if parent.Kind == yaml.MappingNode {
+		return parent.Content[i-1].Value
+	}
+	return strconv.Itoa(i)
+}
+
+func (g *dataGraph) node(n *yaml.Node) dataNode {
+	return dataNode{key: g.key(n), node: n}
+}
+
+func (g *dataGraph) lookup(str fmt.Stringer) (dataNode, error) {
+	if str == nil {
+		return dataNode{}, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(dataNode)
+	if !ok {
+		return n, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	if _, ok := g.parents[n.node]; !ok && !g.isRoot(n.node) {
+		return n, fmt.Errorf("'%s' is not part of this document", n.String())
+	}
+	return g.node(n.node), nil
+}
+
+// children returns the values of a mapping or the elements of a sequence
+func (g *dataGraph) children(n *yaml.Node) []fmt.Stringer {
+	var children []fmt.Stringer
+	for i, c := range n.Content {
+		if n.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		children = append(children, g.node(c))
+	}
+	return children
+}
+
+var _ walder.Graph = &dataGraph{}
+
+func (g *dataGraph) String() string {
+	return string(g.format)
+}
+
+func (g *dataGraph) HomeNodes() ([]fmt.Stringer, error) {
+	var home []fmt.Stringer
+	for _, r := range g.roots() {
+		home = append(home, g.node(r))
+	}
+	return home, nil
+}
+
+var _ walder.NodeAller = &dataGraph{}
+
+func (g *dataGraph) NodeAll() ([]fmt.Stringer, error) {
+	all, err := g.HomeNodes()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(all); i++ {
+		all = append(all, g.children(all[i].(dataNode).node)...)
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirectedTree = &dataGraph{}
+
+func (g *dataGraph) Parent(str fmt.Stringer) (fmt.Stringer, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	parent, ok := g.parents[n.node]
+	if !ok {
+		return nil, fmt.Errorf("the root has no parent")
+	}
+	return g.node(parent), nil
+}
+
+func (g *dataGraph) Children(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	return g.children(n.node), nil
+}
+
+var _ walder.GraphDirected = &dataGraph{}
+
+func (g *dataGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	return g.Children(str)
+}
+
+func (g *dataGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	parent, ok := g.parents[n.node]
+	if !ok {
+		return nil, nil
+	}
+	return []fmt.Stringer{g.node(parent)}, nil
+}
+
+var _ walder.EdgeLabeler = &dataGraph{}
+
+// EdgeLabels returns the key of a object member or the index of a array element
+func (g *dataGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	if g.parents[t.node] != f.node {
+		return nil, fmt.Errorf("'%s' is no child of '%s'", t.String(), f.String())
+	}
+	if f.node.Kind == yaml.MappingNode {
+		return [][2]string{{"key", t.key}}, nil
+	}
+	return [][2]string{{"index", t.key}}, nil
+}
+
+var _ walder.NodeLabeler = &dataGraph{}
+
+func (g *dataGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{
+		{"tag", n.node.ShortTag()},
+		{"line", strconv.Itoa(n.node.Line)},
+	}
+	if n.node.Anchor != "" {
+		labels = append(labels, [2]string{"anchor", n.node.Anchor})
+	}
+	for _, comment := range []string{n.node.HeadComment, n.node.LineComment, n.node.FootComment} {
+		if comment != "" {
+			labels = append(labels, [2]string{"comment", comment})
+		}
+	}
+	return labels, nil
+}
+
+var _ walder.NodeReader = &dataGraph{}
+
+// NodeRead returns the value of a scalar or the encoded object or array
+func (g *dataGraph) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if n.node.Kind == yaml.ScalarNode {
+		return strings.NewReader(n.node.Value), nil
+	}
+	b := &bytes.Buffer{}
+	if err := g.encode(b, n.node); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+var _ walder.NodeWriter = &dataGraph{}
+
+func (g *dataGraph) NodeUpdate(toUpdate fmt.Stringer) (fmt.Stringer, error) {
+	return g.lookup(toUpdate)
+}
+
+// NodeWrite replaces the value of a scalar, the type of the value is resolved anew unless it was a string
+func (g *dataGraph) NodeWrite(str fmt.Stringer) (io.WriteCloser, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if n.node.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("only scalars can be written, but '%s' is not one", n.String())
+	}
+	tag := n.node.ShortTag()
+	return &applyBuffer{apply: func(content []byte) error {
+		value := strings.TrimSuffix(string(content), "\n")
+		n.node.Value = value
+		if tag == "!!str" {
+			return nil
+		}
+		n.node.Tag = resolveScalar(value)
+		n.node.Style = 0
+		if n.node.Tag == "!!str" {
+			n.node.Style = yaml.DoubleQuotedStyle
+		}
+		return nil
+	}}, nil
+}
+
+// resolveScalar returns the tag yaml would give the plain value
+func resolveScalar(value string) string {
+	var n yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &n); err != nil || len(n.Content) != 1 || n.Content[0].Kind != yaml.ScalarNode {
+		return "!!str"
+	}
+	return n.Content[0].ShortTag()
+}
+
+var _ walder.EdgeMover = &dataGraph{}
+
+// EdgeMove moves a value from its parent to a sibling position or, if to is a object or array, into it
+func (g *dataGraph) EdgeMove(toMove, from, to fmt.Stringer) error {
+	m, err := g.lookup(toMove)
+	if err != nil {
+		return err
+	}
+	f, err := g.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return err
+	}
+	if g.parents[m.node] != f.node {
+		return fmt.Errorf("'%s' is no child of '%s'", m.String(), f.String())
+	}
+	if m.node == t.node {
+		return nil
+	}
+	for p := t.node; p != nil; p = g.parents[p] {
+		if p == m.node {
+			return fmt.Errorf("cant move subtree into it self")
+		}
+	}
+
+	// remove the value and for mappings its key
+	entry := g.entry(f.node, m.node)
+	if t.node.Kind == yaml.MappingNode && g.parents[t.node] != f.node {
+		key := m.key
+		if len(entry) == 2 {
+			key = entry[0].Value
+		}
+		for i := 0; i+1 < len(t.node.Content); i += 2 {
+			if t.node.Content[i].Value == key {
+				return fmt.Errorf("'%s' has a key '%s' already", t.String(), key)
+			}
+		}
+	}
+	f.node.Content = removeNodes(f.node.Content, entry...)
+
+	if g.parents[t.node] == f.node {
+		// reorder within the parent by moving in front of the sibling
+		for i, c := range f.node.Content {
+			if c == t.node {
+				at := i - len(entry) + 1
+				f.node.Content = insertNodes(f.node.Content, at, entry...)
+				break
+			}
+		}
+		g.index()
+		return nil
+	}
+
+	switch t.node.Kind {
+	case yaml.SequenceNode:
+		t.node.Content = append(t.node.Content, m.node)
+	case yaml.MappingNode:
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: m.key}
+		if len(entry) == 2 {
+			key = entry[0]
+		}
+		t.node.Content = append(t.node.Content, key, m.node)
+	default:
+		f.node.Content = insertNodes(f.node.Content, len(f.node.Content), entry...)
+		return fmt.Errorf("'%s' is neither a object nor a array", t.String())
+	}
+	g.index()
+	return nil
+}
+
+// entry returns the value and if the parent is a mapping also its key
+func (g *dataGraph) entry(parent, value *yaml.Node) []*yaml.Node {
+	for i, c := range parent.Content {
+		if c != value {
+			continue
+		}
+		if parent.Kind == yaml.MappingNode {
+			return parent.Content[i-1 : i+1 : i+1]
+		}
+		return []*yaml.Node{c}
+	}
+	return nil
+}
+
+func removeNodes(list []*yaml.Node, toRemove ...*yaml.Node) []*yaml.Node {
+	remove := make(map[*yaml.Node]bool, len(toRemove))
+	for _, n := range toRemove {
+		remove[n] = true
+	}
+	kept := make([]*yaml.Node, 0, len(list))
+	for _, n := range list {
+		if !remove[n] {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+func insertNodes(list []*yaml.Node, at int, toInsert ...*yaml.Node) []*yaml.Node {
+	inserted := make([]*yaml.Node, 0, len(list)+len(toInsert))
+	inserted = append(inserted, list[:at]...)
+	inserted = append(inserted, toInsert...)
+	return append(inserted, list[at:]...)
+}
+
+var _ walder.NodeSwaper = &dataGraph{}
+
+// NodeSwap swaps the positions of two values, members of the same object keep there keys
+func (g *dataGraph) NodeSwap(first, second fmt.Stringer) error {
+	a, err := g.lookup(first)
+	if err != nil {
+		return err
+	}
+	b, err := g.lookup(second)
+	if err != nil {
+		return err
+	}
+	for _, pair := range [][2]*yaml.Node{{a.node, b.node}, {b.node, a.node}} {
+		for p := g.parents[pair[0]]; p != nil; p = g.parents[p] {
+			if p == pair[1] {
+				return fmt.Errorf("cant swap a node with one of its ancestors")
+			}
+		}
+	}
+	aParent, aIndex, err := g.position(a.node)
+	if err != nil {
+		return err
+	}
+	bParent, bIndex, err := g.position(b.node)
+	if err != nil {
+		return err
+	}
+	aParent.Content[aIndex], bParent.Content[bIndex] = b.node, a.node
+	if aParent == bParent && aParent.Kind == yaml.MappingNode {
+		aParent.Content[aIndex-1], bParent.Content[bIndex-1] = bParent.Content[bIndex-1], aParent.Content[aIndex-1]
+	}
+	g.index()
+	return nil
+}
+
+var _ walder.GetReader = &dataGraph{}
+
+func (g *dataGraph) GetReader() (io.Reader, error) {
+	b := &bytes.Buffer{}
+	if err := encodeData(g.format, b, g.docs...); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (g *dataGraph) encode(b *bytes.Buffer, n *yaml.Node) error {
+	return encodeData(g.format, b, n)
+}
+
+// encodeData writes the nodes in the given format, several yaml documents are separated by '---'
+func encodeData(format dataFormat, b *bytes.Buffer, nodes ...*yaml.Node) error {
+	switch format {
+	case dataJSON:
+		if len(nodes) != 1 {
+			return fmt.Errorf("want a single json document, but got %d", len(nodes))
+		}
+		if err := encodeJSON(b, nodes[0], ""); err != nil {
+			return err
+		}
+		b.WriteString("\n")
+		return nil
+	case dataYAML:
+		enc := yaml.NewEncoder(b)
+		enc.SetIndent(2)
+		for _, n := range nodes {
+			if err := enc.Encode(n); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+// encodeJSON writes the node indented by two spaces, members are written in document order
+func encodeJSON(b *bytes.Buffer, n *yaml.Node, indent string) error {
+	inner := indent + "  "
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) != 1 {
+			return fmt.Errorf("want a single document, but got %d", len(n.Content))
+		}
+		return encodeJSON(b, n.Content[0], indent)
+	case yaml.MappingNode:
+		if len(n.Content) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		b.WriteString("{\n")
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, err := json.Marshal(n.Content[i].Value)
+			if err != nil {
+				return err
+			}
+			b.WriteString(inner)
+			b.Write(key)
+			b.WriteString(": ")
+			if err := encodeJSON(b, n.Content[i+1], inner); err != nil {
+				return err
+			}
+			if i+2 < len(n.Content) {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[\n")
+		for i, c := range n.Content {
+			b.WriteString(inner)
+			if err := encodeJSON(b, c, inner); err != nil {
+				return err
+			}
+			if i+1 < len(n.Content) {
+				b.WriteString(",")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "]")
+	case yaml.AliasNode:
+		if n.Alias == nil {
+			return fmt.Errorf("alias '%s' without target", n.Value)
+		}
+		return encodeJSON(b, n.Alias, indent)
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!null":
+			b.WriteString("null")
+		case "!!bool", "!!int", "!!float":
+			if json.Valid([]byte(n.Value)) {
+				b.WriteString(n.Value)
+				return nil
+			}
+			var v interface{}
+			if err := n.Decode(&v); err != nil {
+				return err
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("'%s' can not be written as json: %w", n.Value, err)
+			}
+			b.Write(value)
+		default:
+			value, err := json.Marshal(n.Value)
+			if err != nil {
+				return err
+			}
+			b.Write(value)
+		}
+	default:
+		return fmt.Errorf("unknown yaml kind: %d", n.Kind)
+	}
+	return nil
+}
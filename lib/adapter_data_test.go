@@ -0,0 +1,112 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/treilik/walder"
+)
+
+func TestDataRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		dim    walder.OpenReader
+		source string
+		want   string
+		homes  int
+	}{
+		{"yaml", YAMLDim{}, "a: 1\nb:\n  - x\n  - y\n", "a: 1\nb:\n  - x\n  - y\n", 1},
+		{"yaml documents", YAMLDim{}, "a: 1\n---\nb: 2\n", "a: 1\n---\nb: 2\n", 2},
+		{"yaml comment", YAMLDim{}, "# head\na: 1 # line\n", "# head\na: 1 # line\n", 1},
+		{"json", JSONDim{}, `{"a": [1, true, null], "b": "c"}`, "{\n  \"a\": [\n    1,\n    true,\n    null\n  ],\n  \"b\": \"c\"\n}\n", 1},
+		{"empty", YAMLDim{}, "", "{}\n", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := test.dim.Open(strings.NewReader(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			home, err := g.HomeNodes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(home) != test.homes {
+				t.Errorf("want %d home nodes, but got %d", test.homes, len(home))
+			}
+			r, err := g.(walder.GetReader).GetReader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("want:\n%s\nbut got:\n%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestDataErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		dim    walder.OpenReader
+		source string
+	}{
+		{"json documents", JSONDim{}, "{}\n---\n{}\n"},
+		{"invalid", YAMLDim{}, "a: [1\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.dim.Open(strings.NewReader(test.source)); err == nil {
+				t.Error("want an error, but got none")
+			}
+		})
+	}
+}
+
+func TestDataMoveKey(t *testing.T) {
+	const source = "a:\n  x: 1\nb:\n  x: 2\n  y: 3\n"
+	g, err := YAMLDim{}.Open(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := g.(*dataGraph)
+	all, err := d.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// find returns the node with the key and the scalar value, mappings have a empty value
+	find := func(key, value string) fmt.Stringer {
+		t.Helper()
+		for _, n := range all {
+			if dn, ok := n.(dataNode); ok && dn.key == key && dn.node.Value == value {
+				return dn
+			}
+		}
+		t.Fatalf("no node '%s: %s'", key, value)
+		return nil
+	}
+	a, b := find("a", ""), find("b", "")
+
+	// a has a key x already, so the move is rejected and nothing changes
+	if err := d.EdgeMove(find("x", "2"), b, a); err == nil {
+		t.Error("want error, but got nil")
+	}
+	if got := readAll(t, d); got != source {
+		t.Errorf("want:\n%s\nbut got:\n%s", source, got)
+	}
+
+	err = d.EdgeMove(find("y", "3"), b, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a:\n  x: 1\n  y: 3\nb:\n  x: 2\n"
+	if got := readAll(t, d); got != want {
+		t.Errorf("want:\n%s\nbut got:\n%s", want, got)
+	}
+}
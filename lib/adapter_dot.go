@@ -320,13 +320,17 @@ func (d *DotGraph) GetTypes() ([]fmt.Stringer, error) {
 
 func (d *DotGraph) NodeTypedCreate(Type fmt.Stringer, input fmt.Stringer) (fmt.Stringer, error) {
 	i := input.String()
+	parent := d.graph.Name
+	if d.aktiveSubgraph != "" {
+		parent = d.aktiveSubgraph
+	}
 	if Type.String() == "node" {
-		d.graph.AddNode(d.aktiveSubgraph, i, nil)
+		d.graph.AddNode(parent, i, nil)
 		n := d.graph.Nodes.Lookup[i]
 		return node(*n), nil
 	}
 	if Type.String() == "subgraph" {
-		d.graph.AddSubGraph(d.aktiveSubgraph, i, nil)
+		d.graph.AddSubGraph(parent, i, nil)
 		s := d.graph.SubGraphs.SubGraphs[i]
 		return subgraph{*s}, nil
 	}
@@ -0,0 +1,478 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// DpkgDim is a generator for the dependency graph of the installed Debian packages.
+// Path is the dpkg status file and defaults to /var/lib/dpkg/status.
+type DpkgDim struct {
+	Path string
+}
+
+var _ walder.Dimensioner = DpkgDim{}
+
+func (d DpkgDim) String() string {
+	return "dpkg packages"
+}
+
+// New reads the status file of the system
+func (d DpkgDim) New() (walder.Graph, error) {
+	path := d.Path
+	if path == "" {
+		path = "/var/lib/dpkg/status"
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return d.Open(f)
+}
+
+var _ walder.OpenReader = DpkgDim{}
+
+// Open reads a file in the format of the dpkg status file
+func (d DpkgDim) Open(from io.Reader) (walder.Graph, error) {
+	g := &dpkgGraph{
+		view:     dimDependency,
+		pkgs:     make(map[string]*dpkgPkg),
+		arches:   make(map[string][]string),
+		virtuals: make(map[string][]string),
+	}
+	pkgs, err := parseDpkg(from)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	g.add(pkgs)
+	g.link()
+	return g, nil
+}
+
+const (
+	dpkgPackage = "package"
+	dpkgVirtual = "virtual"
+)
+
+// dpkgRelations are the fields turned into edges
+var dpkgRelations = []string{"Pre-Depends", "Depends", "Recommends", "Suggests", "Conflicts", "Breaks", "Provides"}
+
+const (
+	dimDependency dimension = "dependency"
+	dimSuggestion dimension = "suggestion"
+	dimConflict   dimension = "conflict"
+)
+
+// dpkgRelationViews maps the relations to the view they belong to,
+// so packages which only conflict are not mistaken for dependencies
+var dpkgRelationViews = map[string]dimension{
+	"Pre-Depends": dimDependency,
+	"Depends":     dimDependency,
+	"Recommends":  dimDependency,
+	"Provides":    dimDependency,
+	"Suggests":    dimSuggestion,
+	"Conflicts":   dimConflict,
+	"Breaks":      dimConflict,
+}
+
+type dpkgNode string
+
+func (d dpkgNode) String() string {
+	return string(d)
+}
+
+type dpkgPkg struct {
+	name   string
+	fields [][2]string
+	// raw is the paragraph as written in the status file
+	raw string
+}
+
+func (p *dpkgPkg) field(key string) string {
+	for _, f := range p.fields {
+		if f[0] == key {
+			return f[1]
+		}
+	}
+	return ""
+}
+
+type dpkgEdge struct {
+	from       string
+	to         string
+	relation   string
+	constraint string
+}
+
+type dpkgGraph struct {
+	view  dimension
+	order []string
+	pkgs  map[string]*dpkgPkg
+	// arches maps the packages installed for several architectures to there names qualified by the architecture
+	arches map[string][]string
+	// virtuals maps virtual packages to the installed packages providing them
+	virtuals     map[string][]string
+	virtualOrder []string
+	edges        []dpkgEdge
+}
+
+// parseDpkg reads the paragraphs of installed packages, continuation lines are joined to there field
+func parseDpkg(from io.Reader) ([]*dpkgPkg, error) {
+	scanner := bufio.NewScanner(from)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var pkgs []*dpkgPkg
+	p := &dpkgPkg{}
+	var raw strings.Builder
+	flush := func() {
+		p.raw = raw.String()
+		raw.Reset()
+		status := strings.Fields(p.field("Status"))
+		installed := len(status) == 0 || (status[len(status)-1] != "not-installed" && status[len(status)-1] != "config-files")
+		if p.field("Package") != "" && installed {
+			pkgs = append(pkgs, p)
+		}
+		p = &dpkgPkg{}
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		raw.WriteString(line)
+		raw.WriteString("\n")
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(p.fields) > 0 {
+				last := &p.fields[len(p.fields)-1]
+				last[1] += "\n" + strings.TrimSpace(line)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed line '%s'", line)
+		}
+		p.fields = append(p.fields, [2]string{key, strings.TrimSpace(value)})
+	}
+	flush()
+	return pkgs, scanner.Err()
+}
+
+// add names all packages which are installed for several architectures by there architecture like libc6:i386,
+// so none of them is mistaken for the package of an other architecture
+func (g *dpkgGraph) add(pkgs []*dpkgPkg) {
+	count := make(map[string]int)
+	for _, p := range pkgs {
+		count[p.field("Package")]++
+	}
+	for _, p := range pkgs {
+		p.name = p.field("Package")
+		if count[p.name] > 1 {
+			qualified := p.name + ":" + p.field("Architecture")
+			g.arches[p.name] = append(g.arches[p.name], qualified)
+			p.name = qualified
+		}
+		g.pkgs[p.name] = p
+		g.order = append(g.order, p.name)
+	}
+}
+
+// satisfies tells if the package can be used by a package of the architecture,
+// which is the case for the same architecture or if the architecture does not matter
+func (p *dpkgPkg) satisfies(arch string, anyArch bool) bool {
+	own := p.field("Architecture")
+	switch {
+	case anyArch, own == arch, own == "", arch == "":
+		return true
+	case own == "all", arch == "all":
+		return true
+	}
+	return p.field("Multi-Arch") == "foreign"
+}
+
+// dpkgDep is one package of a relation field like "libc6 (>= 2.34)"
+type dpkgDep struct {
+	name       string
+	constraint string
+}
+
+// parseRelation splits a relation field, alternatives separated by | are returned together
+func parseRelation(field string) [][]dpkgDep {
+	var relation [][]dpkgDep
+	for _, group := range strings.Split(field, ",") {
+		var alternatives []dpkgDep
+		for _, alt := range strings.Split(group, "|") {
+			alt = strings.TrimSpace(alt)
+			if alt == "" {
+				continue
+			}
+			name, constraint, _ := strings.Cut(alt, "(")
+			name = strings.TrimSpace(name)
+			// architecture restrictions like [amd64] are ignored
+			if i := strings.IndexByte(name, '['); i >= 0 {
+				name = strings.TrimSpace(name[:i])
+			}
+			constraint = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(constraint), ")"))
+			alternatives = append(alternatives, dpkgDep{name: name, constraint: constraint})
+		}
+		if alternatives != nil {
+			relation = append(relation, alternatives)
+		}
+	}
+	return relation
+}
+
+// link adds the provided virtual packages and then the edges to installed or virtual packages,
+// packages which are neither installed nor provided are left out
+func (g *dpkgGraph) link() {
+	for _, name := range g.order {
+		for _, group := range parseRelation(g.pkgs[name].field("Provides")) {
+			for _, d := range group {
+				if _, ok := g.virtuals[d.name]; !ok {
+					g.virtualOrder = append(g.virtualOrder, d.name)
+				}
+				g.virtuals[d.name] = append(g.virtuals[d.name], name)
+				// the edge points from the virtual package to its provider, so the provider is reached by its dependents
+				g.edges = append(g.edges, dpkgEdge{from: dpkgVirtualName(d.name), to: name, relation: "Provides", constraint: d.constraint})
+			}
+		}
+	}
+	for _, name := range g.order {
+		p := g.pkgs[name]
+		for _, relation := range dpkgRelations {
+			if relation == "Provides" {
+				continue
+			}
+			for _, group := range parseRelation(p.field(relation)) {
+				for _, d := range group {
+					to := g.resolve(d.name, p.field("Architecture"))
+					if to == "" || to == name {
+						continue
+					}
+					g.edges = append(g.edges, dpkgEdge{from: name, to: to, relation: relation, constraint: d.constraint})
+				}
+			}
+		}
+	}
+}
+
+const dpkgVirtualSuffix = " (virtual)"
+
+// dpkgVirtualName keeps virtual packages apart from real packages of the same name
+func dpkgVirtualName(name string) string {
+	return name + dpkgVirtualSuffix
+}
+
+// providers returns the packages providing the node if it is a virtual package
+func (g *dpkgGraph) providers(node string) ([]string, bool) {
+	if !strings.HasSuffix(node, dpkgVirtualSuffix) {
+		return nil, false
+	}
+	providers, ok := g.virtuals[strings.TrimSuffix(node, dpkgVirtualSuffix)]
+	return providers, ok
+}
+
+// resolve returns the installed package of the name which fits the architecture, or else the virtual package
+func (g *dpkgGraph) resolve(name, arch string) string {
+	name, qualifier, _ := strings.Cut(name, ":")
+	if qualifier != "" && qualifier != "any" {
+		arch = qualifier
+	}
+	if p, ok := g.pkgs[name+":"+arch]; ok {
+		return p.name
+	}
+	candidates := g.arches[name]
+	if _, ok := g.pkgs[name]; ok {
+		candidates = []string{name}
+	}
+	for _, c := range candidates {
+		if g.pkgs[c].satisfies(arch, qualifier == "any") {
+			return c
+		}
+	}
+	if _, ok := g.virtuals[name]; ok {
+		return dpkgVirtualName(name)
+	}
+	return ""
+}
+
+func (g *dpkgGraph) lookup(str fmt.Stringer) (string, error) {
+	if str == nil {
+		return "", fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(dpkgNode)
+	if !ok {
+		return "", fmt.Errorf("want %T, but got %T", n, str)
+	}
+	if _, ok := g.pkgs[string(n)]; ok {
+		return string(n), nil
+	}
+	if _, ok := g.providers(string(n)); ok {
+		return string(n), nil
+	}
+	return "", fmt.Errorf("'%s' is not installed", n)
+}
+
+var _ walder.Graph = &dpkgGraph{}
+
+func (g *dpkgGraph) String() string {
+	return "dpkg packages"
+}
+
+func (g *dpkgGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return g.NodeAll()
+}
+
+var _ walder.NodeAller = &dpkgGraph{}
+
+// NodeAll returns the installed packages followed by the virtual packages
+func (g *dpkgGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(g.order)+len(g.virtualOrder))
+	for _, name := range g.order {
+		all = append(all, dpkgNode(name))
+	}
+	for _, name := range g.virtualOrder {
+		all = append(all, dpkgNode(dpkgVirtualName(name)))
+	}
+	return all, nil
+}
+
+var _ walder.DimensionChanger = &dpkgGraph{}
+
+func (g *dpkgGraph) DimensionGetAll() ([]fmt.Stringer, error) {
+	return []fmt.Stringer{
+		stringer(dimDependency),
+		stringer(dimSuggestion),
+		stringer(dimConflict),
+	}, nil
+}
+
+// DimensionSet switches between the dependencies, the suggestions and the conflicts of the packages
+func (g *dpkgGraph) DimensionSet(dim fmt.Stringer) error {
+	switch dim := dim.String(); dim {
+	case string(dimDependency), string(dimSuggestion), string(dimConflict):
+		g.view = dimension(dim)
+		return nil
+	}
+	return fmt.Errorf("dimension '%s' not known to this graph", dim)
+}
+
+func (g *dpkgGraph) visible(e dpkgEdge) bool {
+	return dpkgRelationViews[e.relation] == g.view
+}
+
+var _ walder.GraphDirected = &dpkgGraph{}
+
+// Outgoing returns the dependencies of the package, or in the other views the suggested or conflicting packages
+func (g *dpkgGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	name, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.from == name && g.visible(e) && !seen[e.to] {
+			seen[e.to] = true
+			out = append(out, dpkgNode(e.to))
+		}
+	}
+	return out, nil
+}
+
+// Incoming returns the reverse dependencies of the package
+func (g *dpkgGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	name, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.to == name && g.visible(e) && !seen[e.from] {
+			seen[e.from] = true
+			in = append(in, dpkgNode(e.from))
+		}
+	}
+	return in, nil
+}
+
+var _ walder.EdgeLabeler = &dpkgGraph{}
+
+// EdgeLabels returns the relation fields with there version constraints of all views
+func (g *dpkgGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	for _, e := range g.edges {
+		if e.from == f && e.to == t {
+			labels = append(labels, [2]string{e.relation, e.constraint})
+		}
+	}
+	if labels == nil {
+		return nil, fmt.Errorf("'%s' has no relation to '%s'", f, t)
+	}
+	return labels, nil
+}
+
+var _ walder.Typer = &dpkgGraph{}
+
+func (g *dpkgGraph) GetType(str fmt.Stringer) (string, error) {
+	name, err := g.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := g.providers(name); ok {
+		return dpkgVirtual, nil
+	}
+	return dpkgPackage, nil
+}
+
+var _ walder.NodeLabeler = &dpkgGraph{}
+
+func (g *dpkgGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	name, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if providers, ok := g.providers(name); ok {
+		return [][2]string{{"providers", strings.Join(providers, ", ")}}, nil
+	}
+	p := g.pkgs[name]
+	var labels [][2]string
+	for _, key := range []string{"Version", "Architecture", "Section", "Priority", "Installed-Size", "Essential", "Source"} {
+		if value := p.field(key); value != "" {
+			labels = append(labels, [2]string{key, value})
+		}
+	}
+	if description, _, _ := strings.Cut(p.field("Description"), "\n"); description != "" {
+		labels = append(labels, [2]string{"Description", description})
+	}
+	return labels, nil
+}
+
+var _ walder.NodeReader = &dpkgGraph{}
+
+// NodeRead returns the paragraph of the package from the status file
+func (g *dpkgGraph) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	name, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := g.providers(name); ok {
+		return nil, fmt.Errorf("'%s' is a virtual package", name)
+	}
+	return strings.NewReader(g.pkgs[name].raw), nil
+}
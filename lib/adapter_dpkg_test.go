@@ -0,0 +1,133 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+const dpkgSample = `Package: app
+Status: install ok installed
+Architecture: amd64
+Depends: libfoo (>= 1.0), mail-transport-agent
+Suggests: docs
+Conflicts: oldapp
+Breaks: libfoo (<< 0.5)
+
+Package: libfoo
+Status: install ok installed
+Architecture: i386
+Multi-Arch: same
+
+Package: libfoo
+Status: install ok installed
+Architecture: amd64
+Multi-Arch: same
+
+Package: docs
+Status: install ok installed
+Architecture: all
+
+Package: oldapp
+Status: install ok installed
+Architecture: amd64
+
+Package: postfix
+Status: install ok installed
+Architecture: amd64
+Provides: mail-transport-agent
+
+Package: gone
+Status: deinstall ok config-files
+Architecture: amd64
+`
+
+func TestDpkgViews(t *testing.T) {
+	g, err := DpkgDim{}.Open(strings.NewReader(dpkgSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := g.(*dpkgGraph)
+	all, err := d.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "app libfoo:i386 libfoo:amd64 docs oldapp postfix mail-transport-agent (virtual)"
+	if got := names(all); got != want {
+		t.Errorf("want nodes '%s', but got '%s'", want, got)
+	}
+	tests := []struct {
+		view     dimension
+		node     string
+		outgoing string
+		incoming string
+	}{
+		{dimDependency, "app", "libfoo:amd64 mail-transport-agent (virtual)", ""},
+		{dimDependency, "mail-transport-agent (virtual)", "postfix", "app"},
+		{dimDependency, "libfoo:i386", "", ""},
+		{dimSuggestion, "app", "docs", ""},
+		{dimConflict, "app", "libfoo:amd64 oldapp", ""},
+		{dimConflict, "oldapp", "", "app"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.view)+" "+test.node, func(t *testing.T) {
+			err := d.DimensionSet(stringer(test.view))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := d.Outgoing(dpkgNode(test.node))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(out); got != test.outgoing {
+				t.Errorf("want outgoing '%s', but got '%s'", test.outgoing, got)
+			}
+			in, err := d.Incoming(dpkgNode(test.node))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(in); got != test.incoming {
+				t.Errorf("want incoming '%s', but got '%s'", test.incoming, got)
+			}
+		})
+	}
+
+	// the labels hold the relations of all views
+	labels, err := d.EdgeLabels(dpkgNode("app"), dpkgNode("libfoo:amd64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := labelValue(labels, "Depends"); !ok || v != ">= 1.0" {
+		t.Errorf("want Depends '>= 1.0', but got '%s'", v)
+	}
+	if v, ok := labelValue(labels, "Breaks"); !ok || v != "<< 0.5" {
+		t.Errorf("want Breaks '<< 0.5', but got '%s'", v)
+	}
+}
+
+func TestDpkgResolve(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		dep    string
+		arch   string
+		want   string
+	}{
+		{"same architecture", "Package: a\nArchitecture: i386\n\nPackage: a\nArchitecture: amd64\n", "a", "amd64", "a:amd64"},
+		{"qualified", "Package: a\nArchitecture: i386\n\nPackage: a\nArchitecture: amd64\n", "a:i386", "amd64", "a:i386"},
+		{"other architecture", "Package: a\nArchitecture: i386\n", "a", "amd64", ""},
+		{"foreign", "Package: a\nArchitecture: i386\nMulti-Arch: foreign\n", "a", "amd64", "a"},
+		{"any", "Package: a\nArchitecture: i386\nMulti-Arch: allowed\n", "a:any", "amd64", "a"},
+		{"architecture all", "Package: a\nArchitecture: all\n", "a", "amd64", "a"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := DpkgDim{}.Open(strings.NewReader(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := g.(*dpkgGraph).resolve(test.dep, test.arch); got != test.want {
+				t.Errorf("want '%s', but got '%s'", test.want, got)
+			}
+		})
+	}
+}
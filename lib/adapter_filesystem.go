@@ -109,7 +109,7 @@ func (f *FS) NodeWrite(node fmt.Stringer) (io.WriteCloser, error) {
 	if !ok {
 		return nil, fmt.Errorf("got '%T' but want '%T'", node, path)
 	}
-	fd, err := os.OpenFile(string(path), os.O_WRONLY, 0644)
+	fd, err := os.OpenFile(string(path), os.O_WRONLY|os.O_TRUNC, 0644)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,395 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/treilik/walder"
+)
+
+// GEXFDim is a generator for the GEXF Dimension used by Gephi
+type GEXFDim struct{}
+
+var _ walder.Dimensioner = GEXFDim{}
+
+func (d GEXFDim) String() string {
+	return "gexf"
+}
+
+// New returns a new empty directed GEXF graph
+func (d GEXFDim) New() (walder.Graph, error) {
+	doc := newXMLDoc(gexfCodec{})
+	return doc.root, nil
+}
+
+var _ walder.OpenReader = GEXFDim{}
+
+// Open satisfies the walder.OpenReader interface
+func (d GEXFDim) Open(from io.Reader) (walder.Graph, error) {
+	return openXMLDoc(gexfCodec{}, from)
+}
+
+// gexf is the structure of a GEXF document used to (un)marshal it
+// the unknown attributes and elements, like the viz module of Gephi, are kept to round-trip them
+type gexf struct {
+	XMLName xml.Name   `xml:"gexf"`
+	Xmlns   string     `xml:"xmlns,attr,omitempty"`
+	Version string     `xml:"version,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Meta    *gexfMeta  `xml:"meta"`
+	Graph   gexfGraph  `xml:"graph"`
+}
+
+type gexfMeta struct {
+	LastModified string `xml:"lastmodifieddate,attr,omitempty"`
+	Inner        string `xml:",innerxml"`
+}
+
+type gexfGraph struct {
+	Mode            string           `xml:"mode,attr,omitempty"`
+	DefaultEdgeType string           `xml:"defaultedgetype,attr,omitempty"`
+	Attrs           []xml.Attr       `xml:",any,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           *gexfNodes       `xml:"nodes"`
+	Edges           *gexfEdges       `xml:"edges"`
+}
+
+type gexfAttributes struct {
+	Class     string          `xml:"class,attr"`
+	Mode      string          `xml:"mode,attr,omitempty"`
+	Attribute []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID      string `xml:"id,attr"`
+	Title   string `xml:"title,attr"`
+	Type    string `xml:"type,attr"`
+	Default string `xml:"default,omitempty"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr,omitempty"`
+	PID       string         `xml:"pid,attr,omitempty"`
+	Attrs     []xml.Attr     `xml:",any,attr"`
+	AttValues *gexfAttValues `xml:"attvalues"`
+	Nodes     *gexfNodes     `xml:"nodes"`
+	Edges     *gexfEdges     `xml:"edges"`
+	Elements  []xmlElement   `xml:",any"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr,omitempty"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Type      string         `xml:"type,attr,omitempty"`
+	Label     string         `xml:"label,attr,omitempty"`
+	Weight    string         `xml:"weight,attr,omitempty"`
+	Attrs     []xml.Attr     `xml:",any,attr"`
+	AttValues *gexfAttValues `xml:"attvalues"`
+	Elements  []xmlElement   `xml:",any"`
+}
+
+// the list wrappers are pointers so that empty lists are not written
+
+type gexfNodes struct {
+	Node []gexfNode `xml:"node"`
+}
+
+func (n *gexfNodes) list() []gexfNode {
+	if n == nil {
+		return nil
+	}
+	return n.Node
+}
+
+type gexfEdges struct {
+	Edge []gexfEdge `xml:"edge"`
+}
+
+func (e *gexfEdges) list() []gexfEdge {
+	if e == nil {
+		return nil
+	}
+	return e.Edge
+}
+
+type gexfAttValues struct {
+	AttValue []gexfAttValue `xml:"attvalue"`
+}
+
+func (a *gexfAttValues) list() []gexfAttValue {
+	if a == nil {
+		return nil
+	}
+	return a.AttValue
+}
+
+func (a *gexfAttValues) add(v gexfAttValue) *gexfAttValues {
+	if a == nil {
+		a = &gexfAttValues{}
+	}
+	a.AttValue = append(a.AttValue, v)
+	return a
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// the builtin gexf attributes are stored as data with these keys
+const (
+	gexfLabel  = "label"
+	gexfWeight = "weight"
+)
+
+type gexfCodec struct{}
+
+func (c gexfCodec) name() string {
+	return "gexf"
+}
+
+func (c gexfCodec) prepare(doc *xmlDoc) {
+	doc.keys = append(doc.keys,
+		xmlKey{id: gexfLabel, domain: "all", name: gexfLabel},
+		xmlKey{id: gexfWeight, domain: "edge", name: gexfWeight, attrType: "double"},
+	)
+}
+
+func (c gexfCodec) decode(content []byte) (*xmlDoc, error) {
+	var g gexf
+	err := xml.Unmarshal(content, &g)
+	if err != nil {
+		return nil, err
+	}
+	doc := newXMLDoc(c)
+	doc.extra["xmlns"] = g.Xmlns
+	doc.extra["version"] = g.Version
+	doc.extra["mode"] = g.Graph.Mode
+	prefixes := xmlPrefixes(g.Attrs)
+	doc.attrs = prefixAttrs(g.Attrs, prefixes)
+	doc.root.attrs = prefixAttrs(g.Graph.Attrs, prefixes)
+	if g.Meta != nil {
+		doc.extra["meta"] = g.Meta.Inner
+		doc.extra["lastmodifieddate"] = g.Meta.LastModified
+	}
+	for _, attrs := range g.Graph.Attributes {
+		for _, a := range attrs.Attribute {
+			doc.keys = append(doc.keys, xmlKey{id: a.ID, domain: attrs.Class, name: a.Title, attrType: a.Type, def: a.Default})
+		}
+	}
+	doc.root.directed = g.Graph.DefaultEdgeType != "undirected"
+
+	c.decodeNodes(doc, doc.root, g.Graph.Nodes.list(), prefixes)
+	c.decodeEdges(doc, doc.root, g.Graph.Edges.list(), prefixes)
+
+	// flat hierarchies reference the parent by its id
+	for _, n := range g.Graph.Nodes.list() {
+		if n.PID == "" {
+			continue
+		}
+		parent, ok := doc.nodes[n.PID]
+		if !ok {
+			return nil, fmt.Errorf("node '%s' has unknown parent '%s'", n.ID, n.PID)
+		}
+		if parent.sub == nil {
+			parent.sub = &xmlGraph{doc: doc, directed: doc.root.directed, parent: n.PID}
+		}
+		doc.root.order = removeString(doc.root.order, n.ID)
+		parent.sub.order = append(parent.sub.order, n.ID)
+		doc.nodes[n.ID].graph = parent.sub
+	}
+	for _, e := range doc.edges {
+		for _, id := range []string{e.source, e.target} {
+			if _, ok := doc.nodes[id]; !ok {
+				return nil, fmt.Errorf("edge '%s' references unknown node '%s'", e.id, id)
+			}
+		}
+	}
+	return doc, nil
+}
+
+func (c gexfCodec) decodeNodes(doc *xmlDoc, graph *xmlGraph, nodes []gexfNode, prefixes map[string]string) {
+	for _, n := range nodes {
+		data := &xmlNodeData{
+			graph:    graph,
+			attrs:    prefixAttrs(n.Attrs, prefixes),
+			elements: prefixElements(n.Elements, prefixes),
+		}
+		if n.Label != "" {
+			data.data = append(data.data, xmlData{key: doc.keyID("node", gexfLabel), value: n.Label})
+		}
+		for _, v := range n.AttValues.list() {
+			data.data = append(data.data, xmlData{key: v.For, value: v.Value})
+		}
+		doc.nodes[n.ID] = data
+		graph.order = append(graph.order, n.ID)
+		if n.Nodes != nil || n.Edges != nil {
+			data.sub = &xmlGraph{doc: doc, directed: graph.directed, parent: n.ID}
+			c.decodeNodes(doc, data.sub, n.Nodes.list(), prefixes)
+			c.decodeEdges(doc, data.sub, n.Edges.list(), prefixes)
+		}
+	}
+}
+
+func (c gexfCodec) decodeEdges(doc *xmlDoc, graph *xmlGraph, edges []gexfEdge, prefixes map[string]string) {
+	for _, e := range edges {
+		edge := &xmlEdge{
+			id:       e.ID,
+			source:   e.Source,
+			target:   e.Target,
+			graph:    graph,
+			attrs:    prefixAttrs(e.Attrs, prefixes),
+			elements: prefixElements(e.Elements, prefixes),
+		}
+		switch e.Type {
+		case "directed":
+			directed := true
+			edge.directed = &directed
+		case "undirected":
+			directed := false
+			edge.directed = &directed
+		}
+		if e.Label != "" {
+			edge.data = append(edge.data, xmlData{key: doc.keyID("edge", gexfLabel), value: e.Label})
+		}
+		if e.Weight != "" {
+			edge.data = append(edge.data, xmlData{key: doc.keyID("edge", gexfWeight), value: e.Weight})
+		}
+		for _, v := range e.AttValues.list() {
+			edge.data = append(edge.data, xmlData{key: v.For, value: v.Value})
+		}
+		doc.edges = append(doc.edges, edge)
+	}
+}
+
+func (c gexfCodec) encode(doc *xmlDoc) ([]byte, error) {
+	g := gexf{
+		Xmlns:   doc.extra["xmlns"],
+		Version: doc.extra["version"],
+		Attrs:   doc.attrs,
+	}
+	if g.Xmlns == "" {
+		g.Xmlns = "http://gexf.net/1.3"
+	}
+	if g.Version == "" {
+		g.Version = "1.3"
+	}
+	if doc.extra["meta"] != "" || doc.extra["lastmodifieddate"] != "" {
+		g.Meta = &gexfMeta{LastModified: doc.extra["lastmodifieddate"], Inner: doc.extra["meta"]}
+	}
+	g.Graph.Mode = doc.extra["mode"]
+	g.Graph.Attrs = doc.root.attrs
+	g.Graph.DefaultEdgeType = "directed"
+	if !doc.root.directed {
+		g.Graph.DefaultEdgeType = "undirected"
+	}
+
+	classes := map[string]int{}
+	for _, k := range doc.keys {
+		if c.builtin(k.id) {
+			continue
+		}
+		class := k.domain
+		if class != "edge" {
+			class = "node"
+		}
+		i, ok := classes[class]
+		if !ok {
+			i = len(g.Graph.Attributes)
+			classes[class] = i
+			g.Graph.Attributes = append(g.Graph.Attributes, gexfAttributes{Class: class})
+		}
+		attrType := k.attrType
+		if attrType == "" {
+			attrType = "string"
+		}
+		g.Graph.Attributes[i].Attribute = append(g.Graph.Attributes[i].Attribute, gexfAttribute{
+			ID:      k.id,
+			Title:   k.name,
+			Type:    attrType,
+			Default: k.def,
+		})
+	}
+
+	var err error
+	g.Graph.Nodes, g.Graph.Edges, err = c.encodeGraph(doc, doc.root)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(g); err != nil {
+		return nil, err
+	}
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
+
+func (c gexfCodec) builtin(keyID string) bool {
+	return keyID == gexfLabel || keyID == gexfWeight
+}
+
+func (c gexfCodec) encodeGraph(doc *xmlDoc, graph *xmlGraph) (*gexfNodes, *gexfEdges, error) {
+	nodes := &gexfNodes{}
+	for _, id := range graph.order {
+		data := doc.nodes[id]
+		n := gexfNode{ID: id, Attrs: data.attrs, Elements: data.elements}
+		for _, d := range data.data {
+			if d.raw {
+				return nil, nil, fmt.Errorf("gexf can not hold xml values like the one of node '%s'", id)
+			}
+			if d.key == gexfLabel {
+				n.Label = d.value
+				continue
+			}
+			n.AttValues = n.AttValues.add(gexfAttValue{For: d.key, Value: d.value})
+		}
+		if data.sub != nil {
+			var err error
+			n.Nodes, n.Edges, err = c.encodeGraph(doc, data.sub)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		nodes.Node = append(nodes.Node, n)
+	}
+	var edges *gexfEdges
+	for _, e := range doc.edges {
+		if e.graph != graph {
+			continue
+		}
+		edge := gexfEdge{ID: e.id, Source: e.source, Target: e.target, Attrs: e.attrs, Elements: e.elements}
+		if e.directed != nil {
+			edge.Type = "undirected"
+			if *e.directed {
+				edge.Type = "directed"
+			}
+		}
+		for _, d := range e.data {
+			switch d.key {
+			case gexfLabel:
+				edge.Label = d.value
+			case gexfWeight:
+				if _, err := strconv.ParseFloat(d.value, 64); err != nil {
+					return nil, nil, fmt.Errorf("weight of edge '%s' is not a number: %w", e.id, err)
+				}
+				edge.Weight = d.value
+			default:
+				edge.AttValues = edge.AttValues.add(gexfAttValue{For: d.key, Value: d.value})
+			}
+		}
+		if edges == nil {
+			edges = &gexfEdges{}
+		}
+		edges.Edge = append(edges.Edge, edge)
+	}
+	return nodes, edges, nil
+}
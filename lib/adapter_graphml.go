@@ -0,0 +1,878 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// GraphMLDim is a generator for the GraphML Dimension
+type GraphMLDim struct{}
+
+var _ walder.Dimensioner = GraphMLDim{}
+
+func (d GraphMLDim) String() string {
+	return "graphml"
+}
+
+// New returns a new empty directed GraphML graph
+func (d GraphMLDim) New() (walder.Graph, error) {
+	doc := newXMLDoc(graphmlCodec{})
+	return doc.root, nil
+}
+
+var _ walder.OpenReader = GraphMLDim{}
+
+// Open satisfies the walder.OpenReader interface
+func (d GraphMLDim) Open(from io.Reader) (walder.Graph, error) {
+	return openXMLDoc(graphmlCodec{}, from)
+}
+
+// xmlCodec translates between a xml graph exchange format and the xmlDoc model
+type xmlCodec interface {
+	decode([]byte) (*xmlDoc, error)
+	encode(*xmlDoc) ([]byte, error)
+	name() string
+	// prepare declares the keys which are builtin to the format
+	prepare(*xmlDoc)
+}
+
+func openXMLDoc(codec xmlCodec, from io.Reader) (walder.Graph, error) {
+	all, err := io.ReadAll(from)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	if len(bytes.TrimSpace(all)) == 0 {
+		return newXMLDoc(codec).root, nil
+	}
+	doc, err := codec.decode(all)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	return doc.root, nil
+}
+
+// xmlKey is the declaration of a attribute which nodes, edges or graphs can have
+type xmlKey struct {
+	id       string
+	domain   string
+	name     string
+	attrType string
+	def      string
+	// attrs are unknown attributes like yfiles.type, which are written back as they were read
+	attrs []xml.Attr
+}
+
+// xmlData is the value of a attribute, raw values contain xml and are written back verbatim
+type xmlData struct {
+	key   string
+	value string
+	raw   bool
+}
+
+type xmlNodeData struct {
+	graph *xmlGraph
+	data  []xmlData
+	sub   *xmlGraph
+	// attrs and elements are unknown to the model and written back as they were read
+	attrs    []xml.Attr
+	elements []xmlElement
+}
+
+type xmlEdge struct {
+	id       string
+	source   string
+	target   string
+	directed *bool
+	data     []xmlData
+	graph    *xmlGraph
+	attrs    []xml.Attr
+	elements []xmlElement
+}
+
+// xmlElement is a child element unknown to the model, like the viz elements of gexf
+type xmlElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// xmlPrefixes maps the namespaces declared in the attributes to there prefixes
+func xmlPrefixes(attrs []xml.Attr) map[string]string {
+	prefixes := make(map[string]string)
+	for _, a := range attrs {
+		if a.Name.Space == "xmlns" {
+			prefixes[a.Value] = a.Name.Local
+		}
+	}
+	return prefixes
+}
+
+// prefixName replaces the namespace by its prefix, since encoding/xml would declare the namespace anew on every element.
+// Names of not declared namespaces, like the default one, loose there namespace.
+func prefixName(name xml.Name, prefixes map[string]string) xml.Name {
+	switch name.Space {
+	case "":
+		return name
+	case "xmlns":
+		return xml.Name{Local: "xmlns:" + name.Local}
+	}
+	if prefix, ok := prefixes[name.Space]; ok {
+		return xml.Name{Local: prefix + ":" + name.Local}
+	}
+	return xml.Name{Local: name.Local}
+}
+
+func prefixAttrs(attrs []xml.Attr, prefixes map[string]string) []xml.Attr {
+	prefixed := make([]xml.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		prefixed = append(prefixed, xml.Attr{Name: prefixName(a.Name, prefixes), Value: a.Value})
+	}
+	return prefixed
+}
+
+// prefixElements prefixes the elements and there attributes, the inner xml keeps its prefixes anyway
+func prefixElements(elements []xmlElement, prefixes map[string]string) []xmlElement {
+	prefixed := make([]xmlElement, 0, len(elements))
+	for _, e := range elements {
+		prefixed = append(prefixed, xmlElement{
+			XMLName: prefixName(e.XMLName, prefixes),
+			Attrs:   prefixAttrs(e.Attrs, prefixes),
+			Inner:   e.Inner,
+		})
+	}
+	return prefixed
+}
+
+// xmlDoc holds everything which is shared between a graph and its nested graphs
+type xmlDoc struct {
+	codec xmlCodec
+	keys  []xmlKey
+	nodes map[string]*xmlNodeData
+	edges []*xmlEdge
+	root  *xmlGraph
+	extra map[string]string
+	// attrs are the unknown attributes of the document element, like the declarations of namespaces
+	attrs  []xml.Attr
+	lastID int
+}
+
+func newXMLDoc(codec xmlCodec) *xmlDoc {
+	doc := &xmlDoc{
+		codec: codec,
+		nodes: make(map[string]*xmlNodeData),
+		extra: make(map[string]string),
+	}
+	doc.root = &xmlGraph{doc: doc, directed: true}
+	codec.prepare(doc)
+	return doc
+}
+
+type xmlNode struct {
+	id    string
+	label string
+}
+
+func (n xmlNode) String() string {
+	if n.label != "" {
+		return n.label
+	}
+	return n.id
+}
+
+type xmlGraph struct {
+	doc      *xmlDoc
+	id       string
+	directed bool
+	data     []xmlData
+	order    []string
+	parent   string
+	attrs    []xml.Attr
+}
+
+func (g *xmlGraph) String() string {
+	name := g.doc.codec.name()
+	if g.parent != "" {
+		return fmt.Sprintf("%s: %s", name, g.node(g.parent).String())
+	}
+	if g.id != "" {
+		return fmt.Sprintf("%s: %s", name, g.id)
+	}
+	return name
+}
+
+func (g *xmlGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return g.NodeAll()
+}
+
+// keyName returns the human readable name of a key
+func (d *xmlDoc) keyName(id string) string {
+	for _, k := range d.keys {
+		if k.id == id && k.name != "" {
+			return k.name
+		}
+	}
+	return id
+}
+
+// keyID returns the id of the key with the given name for the domain and declares it if needed
+func (d *xmlDoc) keyID(domain, name string) string {
+	for _, k := range d.keys {
+		if (k.domain == domain || k.domain == "all" || k.domain == "") && (k.name == name || (k.name == "" && k.id == name)) {
+			return k.id
+		}
+	}
+	id := "d" + strconv.Itoa(len(d.keys))
+	for d.hasKey(id) {
+		id += "_"
+	}
+	d.keys = append(d.keys, xmlKey{id: id, domain: domain, name: name, attrType: "string"})
+	return id
+}
+
+func (d *xmlDoc) hasKey(id string) bool {
+	for _, k := range d.keys {
+		if k.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *xmlDoc) nextID(prefix string) string {
+	for {
+		d.lastID++
+		id := prefix + strconv.Itoa(d.lastID)
+		if _, ok := d.nodes[id]; ok {
+			continue
+		}
+		var used bool
+		for _, e := range d.edges {
+			if e.id == id {
+				used = true
+				break
+			}
+		}
+		if !used {
+			return id
+		}
+	}
+}
+
+func (d *xmlDoc) labels(data []xmlData) [][2]string {
+	labels := make([][2]string, 0, len(data))
+	for _, v := range data {
+		labels = append(labels, [2]string{d.keyName(v.key), v.value})
+	}
+	return labels
+}
+
+func setXMLData(data []xmlData, key, value string) []xmlData {
+	for i, v := range data {
+		if v.key == key {
+			data[i] = xmlData{key: key, value: value}
+			return data
+		}
+	}
+	return append(data, xmlData{key: key, value: value})
+}
+
+// label returns the value of the first data whose key is named label or name
+func (d *xmlDoc) label(data []xmlData) string {
+	for _, v := range data {
+		if v.raw {
+			continue
+		}
+		switch strings.ToLower(d.keyName(v.key)) {
+		case "label", "name":
+			return v.value
+		}
+	}
+	return ""
+}
+
+func (g *xmlGraph) node(id string) xmlNode {
+	n := xmlNode{id: id}
+	if data, ok := g.doc.nodes[id]; ok {
+		n.label = g.doc.label(data.data)
+	}
+	return n
+}
+
+func (g *xmlGraph) lookup(str fmt.Stringer) (xmlNode, *xmlNodeData, error) {
+	n, ok := str.(xmlNode)
+	if !ok {
+		return n, nil, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	data, ok := g.doc.nodes[n.id]
+	if !ok {
+		return n, nil, fmt.Errorf("'%s' is not part of this graph", n.id)
+	}
+	return g.node(n.id), data, nil
+}
+
+func (e *xmlEdge) isDirected() bool {
+	if e.directed != nil {
+		return *e.directed
+	}
+	return e.graph.directed
+}
+
+var _ walder.NodeAller = &xmlGraph{}
+
+func (g *xmlGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(g.order))
+	for _, id := range g.order {
+		all = append(all, g.node(id))
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &xmlGraph{}
+
+// Outgoing returns the targets of directed edges and both ends of undirected edges
+func (g *xmlGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	for _, e := range g.doc.edges {
+		switch {
+		case e.source == n.id:
+			out = append(out, g.node(e.target))
+		case e.target == n.id && !e.isDirected():
+			out = append(out, g.node(e.source))
+		}
+	}
+	return out, nil
+}
+
+// Incoming returns the sources of directed edges and both ends of undirected edges
+func (g *xmlGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, e := range g.doc.edges {
+		switch {
+		case e.target == n.id:
+			in = append(in, g.node(e.source))
+		case e.source == n.id && !e.isDirected():
+			in = append(in, g.node(e.target))
+		}
+	}
+	return in, nil
+}
+
+var _ walder.GraphNeighbors = &xmlGraph{}
+
+func (g *xmlGraph) Neighbors(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var neighbors []fmt.Stringer
+	for _, e := range g.doc.edges {
+		switch n.id {
+		case e.source:
+			neighbors = append(neighbors, g.node(e.target))
+		case e.target:
+			neighbors = append(neighbors, g.node(e.source))
+		}
+	}
+	return neighbors, nil
+}
+
+var _ walder.GraphCreater = &xmlGraph{}
+
+func (g *xmlGraph) NodeCreate(input fmt.Stringer) (fmt.Stringer, error) {
+	if input == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	id := g.doc.nextID("n")
+	g.doc.nodes[id] = &xmlNodeData{
+		graph: g,
+		data:  []xmlData{{key: g.doc.keyID("node", "label"), value: input.String()}},
+	}
+	g.order = append(g.order, id)
+	return g.node(id), nil
+}
+
+func (g *xmlGraph) NodeUpdate(toUpdate fmt.Stringer) (fmt.Stringer, error) {
+	n, _, err := g.lookup(toUpdate)
+	return n, err
+}
+
+func (g *xmlGraph) EdgeCreate(from, to fmt.Stringer) error {
+	f, _, err := g.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, _, err := g.lookup(to)
+	if err != nil {
+		return err
+	}
+	g.doc.edges = append(g.doc.edges, &xmlEdge{
+		id:     g.doc.nextID("e"),
+		source: f.id,
+		target: t.id,
+		graph:  g,
+	})
+	return nil
+}
+
+var _ walder.NodeFromCreater = &xmlGraph{}
+
+func (g *xmlGraph) NodeFromCreate(input fmt.Stringer, fromNodes ...fmt.Stringer) (fmt.Stringer, error) {
+	newNode, err := g.NodeCreate(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, from := range fromNodes {
+		if err := g.EdgeCreate(from, newNode); err != nil {
+			return newNode, err
+		}
+	}
+	return newNode, nil
+}
+
+var _ walder.NodeToCreater = &xmlGraph{}
+
+func (g *xmlGraph) NodeToCreate(input fmt.Stringer, toNodes ...fmt.Stringer) (fmt.Stringer, error) {
+	newNode, err := g.NodeCreate(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, to := range toNodes {
+		if err := g.EdgeCreate(newNode, to); err != nil {
+			return newNode, err
+		}
+	}
+	return newNode, nil
+}
+
+var _ walder.NodeDeleter = &xmlGraph{}
+
+func (g *xmlGraph) NodeDelete(toDelete fmt.Stringer) error {
+	n, _, err := g.lookup(toDelete)
+	if err != nil {
+		return err
+	}
+	g.doc.deleteNode(n.id)
+	return nil
+}
+
+func (d *xmlDoc) deleteNode(id string) {
+	data, ok := d.nodes[id]
+	if !ok {
+		return
+	}
+	if data.sub != nil {
+		for _, child := range data.sub.order {
+			d.deleteNode(child)
+		}
+	}
+	delete(d.nodes, id)
+	data.graph.order = removeString(data.graph.order, id)
+
+	edges := make([]*xmlEdge, 0, len(d.edges))
+	for _, e := range d.edges {
+		if e.source == id || e.target == id {
+			continue
+		}
+		edges = append(edges, e)
+	}
+	d.edges = edges
+}
+
+var _ walder.EdgeDeleter = &xmlGraph{}
+
+func (g *xmlGraph) EdgeDelete(from, to fmt.Stringer) error {
+	f, _, err := g.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, _, err := g.lookup(to)
+	if err != nil {
+		return err
+	}
+	edges := make([]*xmlEdge, 0, len(g.doc.edges))
+	for _, e := range g.doc.edges {
+		if e.source == f.id && e.target == t.id {
+			continue
+		}
+		if !e.isDirected() && e.source == t.id && e.target == f.id {
+			continue
+		}
+		edges = append(edges, e)
+	}
+	g.doc.edges = edges
+	return nil
+}
+
+var _ walder.NodeLabelAdder = &xmlGraph{}
+
+func (g *xmlGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	n, data, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	return append([][2]string{{"id", n.id}}, g.doc.labels(data.data)...), nil
+}
+
+func (g *xmlGraph) NodeLabelAdd(str fmt.Stringer, key, value string) (fmt.Stringer, error) {
+	n, data, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if key == "id" {
+		return nil, fmt.Errorf("the id of a node can not be changed")
+	}
+	data.data = setXMLData(data.data, g.doc.keyID("node", key), value)
+	return g.node(n.id), nil
+}
+
+var _ walder.EdgeLabelAdder = &xmlGraph{}
+
+func (g *xmlGraph) edge(from, to fmt.Stringer) (*xmlEdge, error) {
+	f, _, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range g.doc.edges {
+		if e.source == f.id && e.target == t.id {
+			return e, nil
+		}
+		if !e.isDirected() && e.source == t.id && e.target == f.id {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("no edge found from '%s' to '%s'", f.id, t.id)
+}
+
+func (g *xmlGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	e, err := g.edge(from, to)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{{"directed", strconv.FormatBool(e.isDirected())}}
+	if e.id != "" {
+		labels = append(labels, [2]string{"id", e.id})
+	}
+	return append(labels, g.doc.labels(e.data)...), nil
+}
+
+func (g *xmlGraph) EdgeLabelAdd(from, to fmt.Stringer, key, value string) error {
+	e, err := g.edge(from, to)
+	if err != nil {
+		return err
+	}
+	switch key {
+	case "id":
+		return fmt.Errorf("the id of a edge can not be changed")
+	case "directed":
+		directed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		e.directed = &directed
+		return nil
+	}
+	e.data = setXMLData(e.data, g.doc.keyID("edge", key), value)
+	return nil
+}
+
+var _ walder.Typer = &xmlGraph{}
+
+func (g *xmlGraph) GetType(str fmt.Stringer) (string, error) {
+	_, data, err := g.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	if data.sub != nil {
+		return "subgraph", nil
+	}
+	return "node", nil
+}
+
+var _ walder.NodeTypedCreator = &xmlGraph{}
+
+func (g *xmlGraph) GetTypes() ([]fmt.Stringer, error) {
+	return types, nil
+}
+
+// NodeTypedCreate creates a node which for the type 'subgraph' holds a empty nested graph
+func (g *xmlGraph) NodeTypedCreate(Type fmt.Stringer, input fmt.Stringer) (fmt.Stringer, error) {
+	switch Type.String() {
+	case "node":
+		return g.NodeCreate(input)
+	case "subgraph":
+		n, err := g.NodeCreate(input)
+		if err != nil {
+			return nil, err
+		}
+		node := n.(xmlNode)
+		g.doc.nodes[node.id].sub = &xmlGraph{
+			doc:      g.doc,
+			id:       node.id + ":",
+			directed: g.directed,
+			parent:   node.id,
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("no type named '%s'", Type)
+}
+
+var _ walder.Dimensions = &xmlGraph{}
+
+// Dimensions returns the graph nested in the given node
+func (g *xmlGraph) Dimensions(str fmt.Stringer) ([]walder.Graph, error) {
+	_, data, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if data.sub == nil {
+		return []walder.Graph{}, nil
+	}
+	return []walder.Graph{data.sub}, nil
+}
+
+var _ walder.GetReader = &xmlGraph{}
+
+// GetReader returns the whole document even if called on a nested graph
+func (g *xmlGraph) GetReader() (io.Reader, error) {
+	content, err := g.doc.codec.encode(g.doc)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(content), nil
+}
+
+// graphml is the structure of a GraphML document used to (un)marshal it
+// the unknown attributes and elements are kept to round-trip documents of yEd and others
+type graphml struct {
+	XMLName xml.Name       `xml:"graphml"`
+	Xmlns   string         `xml:"xmlns,attr,omitempty"`
+	Attrs   []xml.Attr     `xml:",any,attr"`
+	Keys    []graphmlKey   `xml:"key"`
+	Graphs  []graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID      string     `xml:"id,attr"`
+	For     string     `xml:"for,attr,omitempty"`
+	Name    string     `xml:"attr.name,attr,omitempty"`
+	Type    string     `xml:"attr.type,attr,omitempty"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Default string     `xml:"default,omitempty"`
+}
+
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr,omitempty"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Attrs       []xml.Attr    `xml:",any,attr"`
+	Data        []graphmlData `xml:"data"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID       string        `xml:"id,attr"`
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Data     []graphmlData `xml:"data"`
+	Graph    *graphmlGraph `xml:"graph"`
+	Elements []xmlElement  `xml:",any"`
+}
+
+type graphmlEdge struct {
+	ID       string        `xml:"id,attr,omitempty"`
+	Source   string        `xml:"source,attr"`
+	Target   string        `xml:"target,attr"`
+	Directed string        `xml:"directed,attr,omitempty"`
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Data     []graphmlData `xml:"data"`
+	Elements []xmlElement  `xml:",any"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Inner string `xml:",innerxml"`
+	Text  string `xml:",chardata"`
+}
+
+func (d graphmlData) toData() xmlData {
+	if strings.Contains(d.Inner, "<") {
+		return xmlData{key: d.Key, value: d.Inner, raw: true}
+	}
+	return xmlData{key: d.Key, value: d.Text}
+}
+
+func fromXMLData(data []xmlData) []graphmlData {
+	result := make([]graphmlData, 0, len(data))
+	for _, d := range data {
+		if d.raw {
+			result = append(result, graphmlData{Key: d.key, Inner: d.value})
+			continue
+		}
+		b := &bytes.Buffer{}
+		_ = xml.EscapeText(b, []byte(d.value))
+		result = append(result, graphmlData{Key: d.key, Inner: b.String()})
+	}
+	return result
+}
+
+type graphmlCodec struct{}
+
+func (c graphmlCodec) name() string {
+	return "graphml"
+}
+
+func (c graphmlCodec) prepare(_ *xmlDoc) {}
+
+func (c graphmlCodec) decode(content []byte) (*xmlDoc, error) {
+	var g graphml
+	err := xml.Unmarshal(content, &g)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Graphs) == 0 {
+		return nil, fmt.Errorf("no graph element found")
+	}
+	if len(g.Graphs) > 1 {
+		return nil, fmt.Errorf("want a single graph element, but got %d", len(g.Graphs))
+	}
+	doc := newXMLDoc(c)
+	doc.extra["xmlns"] = g.Xmlns
+	prefixes := xmlPrefixes(g.Attrs)
+	doc.attrs = prefixAttrs(g.Attrs, prefixes)
+	for _, k := range g.Keys {
+		doc.keys = append(doc.keys, xmlKey{id: k.ID, domain: k.For, name: k.Name, attrType: k.Type, def: k.Default, attrs: prefixAttrs(k.Attrs, prefixes)})
+	}
+	err = c.decodeGraph(doc, doc.root, g.Graphs[0], prefixes)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range doc.edges {
+		for _, id := range []string{e.source, e.target} {
+			if _, ok := doc.nodes[id]; !ok {
+				return nil, fmt.Errorf("edge '%s' references unknown node '%s'", e.id, id)
+			}
+		}
+	}
+	return doc, nil
+}
+
+func (c graphmlCodec) decodeGraph(doc *xmlDoc, graph *xmlGraph, g graphmlGraph, prefixes map[string]string) error {
+	graph.id = g.ID
+	graph.directed = g.EdgeDefault != "undirected"
+	graph.attrs = prefixAttrs(g.Attrs, prefixes)
+	for _, d := range g.Data {
+		graph.data = append(graph.data, d.toData())
+	}
+	for _, n := range g.Nodes {
+		if _, ok := doc.nodes[n.ID]; ok {
+			return fmt.Errorf("node id '%s' is not unique", n.ID)
+		}
+		data := &xmlNodeData{
+			graph:    graph,
+			attrs:    prefixAttrs(n.Attrs, prefixes),
+			elements: prefixElements(n.Elements, prefixes),
+		}
+		for _, d := range n.Data {
+			data.data = append(data.data, d.toData())
+		}
+		doc.nodes[n.ID] = data
+		graph.order = append(graph.order, n.ID)
+		if n.Graph != nil {
+			data.sub = &xmlGraph{doc: doc, parent: n.ID}
+			err := c.decodeGraph(doc, data.sub, *n.Graph, prefixes)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, e := range g.Edges {
+		edge := &xmlEdge{
+			id:       e.ID,
+			source:   e.Source,
+			target:   e.Target,
+			graph:    graph,
+			attrs:    prefixAttrs(e.Attrs, prefixes),
+			elements: prefixElements(e.Elements, prefixes),
+		}
+		if e.Directed != "" {
+			directed := e.Directed == "true"
+			edge.directed = &directed
+		}
+		for _, d := range e.Data {
+			edge.data = append(edge.data, d.toData())
+		}
+		doc.edges = append(doc.edges, edge)
+	}
+	return nil
+}
+
+func (c graphmlCodec) encode(doc *xmlDoc) ([]byte, error) {
+	xmlns := doc.extra["xmlns"]
+	if xmlns == "" {
+		xmlns = "http://graphml.graphdrawing.org/xmlns"
+	}
+	g := graphml{Xmlns: xmlns, Attrs: doc.attrs}
+	for _, k := range doc.keys {
+		g.Keys = append(g.Keys, graphmlKey{ID: k.id, For: k.domain, Name: k.name, Type: k.attrType, Default: k.def, Attrs: k.attrs})
+	}
+	g.Graphs = []graphmlGraph{c.encodeGraph(doc, doc.root)}
+
+	b := &bytes.Buffer{}
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(g); err != nil {
+		return nil, err
+	}
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
+
+func (c graphmlCodec) encodeGraph(doc *xmlDoc, graph *xmlGraph) graphmlGraph {
+	g := graphmlGraph{
+		ID:          graph.id,
+		EdgeDefault: "directed",
+		Attrs:       graph.attrs,
+		Data:        fromXMLData(graph.data),
+	}
+	if !graph.directed {
+		g.EdgeDefault = "undirected"
+	}
+	for _, id := range graph.order {
+		data := doc.nodes[id]
+		n := graphmlNode{ID: id, Attrs: data.attrs, Data: fromXMLData(data.data), Elements: data.elements}
+		if data.sub != nil {
+			sub := c.encodeGraph(doc, data.sub)
+			n.Graph = &sub
+		}
+		g.Nodes = append(g.Nodes, n)
+	}
+	for _, e := range doc.edges {
+		if e.graph != graph {
+			continue
+		}
+		edge := graphmlEdge{ID: e.id, Source: e.source, Target: e.target, Attrs: e.attrs, Data: fromXMLData(e.data), Elements: e.elements}
+		if e.directed != nil {
+			edge.Directed = strconv.FormatBool(*e.directed)
+		}
+		g.Edges = append(g.Edges, edge)
+	}
+	return g
+}
@@ -0,0 +1,106 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/treilik/walder"
+)
+
+const yedSample = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:y="http://www.yworks.com/xml/graphml">
+  <key id="d0" for="node" yfiles.type="nodegraphics"/>
+  <key id="d1" for="edge" attr.name="weight" attr.type="double"/>
+  <graph id="G" edgedefault="directed">
+    <node id="a" yfiles.foldertype="group">
+      <data key="d0"><y:ShapeNode><y:Geometry x="1" y="2"/></y:ShapeNode></data>
+      <port name="p"/>
+    </node>
+    <node id="b"/>
+    <edge id="e" source="a" target="b"><data key="d1">2</data></edge>
+  </graph>
+</graphml>`
+
+const gephiSample = `<?xml version="1.0" encoding="UTF-8"?>
+<gexf xmlns="http://gexf.net/1.3" xmlns:viz="http://gexf.net/1.3/viz" version="1.3">
+  <graph mode="static" defaultedgetype="directed">
+    <nodes>
+      <node id="a" label="a">
+        <viz:color r="255" g="0" b="0"/>
+        <viz:position x="1.5" y="2.5"/>
+        <viz:size value="3"/>
+      </node>
+      <node id="b" label="b"/>
+    </nodes>
+    <edges>
+      <edge id="e" source="a" target="b"><viz:thickness value="2"/></edge>
+    </edges>
+  </graph>
+</gexf>`
+
+func TestXMLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		dim    walder.OpenReader
+		source string
+		want   []string
+	}{
+		{"graphml namespaces", GraphMLDim{}, yedSample, []string{
+			`xmlns="http://graphml.graphdrawing.org/xmlns"`,
+			`xmlns:y="http://www.yworks.com/xml/graphml"`,
+			`yfiles.type="nodegraphics"`,
+			`yfiles.foldertype="group"`,
+			`<y:ShapeNode><y:Geometry x="1" y="2"/></y:ShapeNode>`,
+			`<port name="p"></port>`,
+		}},
+		{"gexf viz", GEXFDim{}, gephiSample, []string{
+			`xmlns:viz="http://gexf.net/1.3/viz"`,
+			`<viz:color r="255" g="0" b="0"></viz:color>`,
+			`<viz:position x="1.5" y="2.5"></viz:position>`,
+			`<viz:size value="3"></viz:size>`,
+			`<viz:thickness value="2"></viz:thickness>`,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := test.dim.Open(strings.NewReader(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := readAll(t, g)
+			for _, w := range test.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("want %s in:\n%s", w, got)
+				}
+			}
+			// the written document has to be read the same way again
+			again, err := test.dim.Open(strings.NewReader(got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if second := readAll(t, again); second != got {
+				t.Errorf("want:\n%s\nbut got:\n%s", got, second)
+			}
+		})
+	}
+}
+
+func TestXMLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		dim    walder.OpenReader
+		source string
+	}{
+		{"no graph", GraphMLDim{}, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns"></graphml>`},
+		{"two graphs", GraphMLDim{}, `<graphml><graph edgedefault="directed"/><graph edgedefault="directed"/></graphml>`},
+		{"invalid", GEXFDim{}, `<gexf><graph>`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.dim.Open(strings.NewReader(test.source))
+			if err == nil {
+				t.Error("want error, but got nil")
+			}
+		})
+	}
+}
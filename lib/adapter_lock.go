@@ -0,0 +1,873 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// LockDim is a generator for the resolved dependency graph of a lockfile.
+// It reads go.mod, go.sum, the output of "go mod graph", package-lock.json, Cargo.lock and poetry.lock.
+// A go.sum only lists the modules, the edges between them are only known from a go.mod or "go mod graph".
+type LockDim struct{}
+
+var _ walder.Dimensioner = LockDim{}
+
+func (d LockDim) String() string {
+	return "lockfile"
+}
+
+func (d LockDim) New() (walder.Graph, error) {
+	return newLockGraph(""), nil
+}
+
+var _ walder.OpenReader = LockDim{}
+
+// Open detects the format of the lockfile by its content
+func (d LockDim) Open(from io.Reader) (walder.Graph, error) {
+	content, err := io.ReadAll(from)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	format := lockFormat(content)
+	g := newLockGraph(format)
+	switch format {
+	case lockNPM:
+		err = g.parseNPM(content)
+	case lockCargo:
+		err = g.parseCargo(content)
+	case lockPoetry:
+		err = g.parsePoetry(content)
+	case lockGoMod:
+		err = g.parseGoMod(content)
+	case lockGoSum:
+		err = g.parseGoSum(content)
+	case lockGoGraph:
+		err = g.parseGoGraph(content)
+	default:
+		err = fmt.Errorf("unknown lockfile format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	return g, nil
+}
+
+const (
+	lockNPM     = "package-lock.json"
+	lockCargo   = "Cargo.lock"
+	lockPoetry  = "poetry.lock"
+	lockGoMod   = "go.mod"
+	lockGoSum   = "go.sum"
+	lockGoGraph = "go mod graph"
+)
+
+const (
+	lockRoot      = "root"
+	lockPackage   = "package"
+	lockDuplicate = "duplicate"
+)
+
+func lockFormat(content []byte) string {
+	text := string(content)
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(text), "{"):
+		return lockNPM
+	case strings.Contains(text, "[[package]]"):
+		if strings.Contains(text, "[package.dependencies]") || strings.Contains(text, "python-versions") {
+			return lockPoetry
+		}
+		return lockCargo
+	}
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "module ") {
+			return lockGoMod
+		}
+	}
+	fields := strings.Fields(lines[0])
+	switch {
+	case len(fields) == 3 && strings.HasPrefix(fields[2], "h1:"):
+		return lockGoSum
+	case len(fields) == 2 && strings.Contains(fields[1], "@"):
+		return lockGoGraph
+	}
+	return ""
+}
+
+// lockNode is a package at a resolved version
+type lockNode struct {
+	name    string
+	version string
+}
+
+func (l lockNode) String() string {
+	if l.version == "" {
+		return l.name
+	}
+	return l.name + "@" + l.version
+}
+
+type lockPkg struct {
+	lockNode
+	root   bool
+	labels [][2]string
+}
+
+type lockDep struct {
+	to         string
+	constraint string
+	kind       string
+}
+
+type lockGraph struct {
+	format string
+	order  []string
+	pkgs   map[string]*lockPkg
+	deps   map[string][]lockDep
+}
+
+func newLockGraph(format string) *lockGraph {
+	return &lockGraph{
+		format: format,
+		pkgs:   make(map[string]*lockPkg),
+		deps:   make(map[string][]lockDep),
+	}
+}
+
+// add returns the package, creating it if it is new
+func (g *lockGraph) add(name, version string) *lockPkg {
+	n := lockNode{name: name, version: version}
+	id := n.String()
+	p, ok := g.pkgs[id]
+	if !ok {
+		p = &lockPkg{lockNode: n}
+		g.pkgs[id] = p
+		g.order = append(g.order, id)
+	}
+	return p
+}
+
+func (g *lockGraph) depend(from, to *lockPkg, constraint, kind string) {
+	id := from.String()
+	for _, d := range g.deps[id] {
+		if d.to == to.String() {
+			return
+		}
+	}
+	g.deps[id] = append(g.deps[id], lockDep{to: to.String(), constraint: constraint, kind: kind})
+}
+
+// splitModule splits a go module like golang.org/x/text@v0.7.0
+func splitModule(module string) (string, string) {
+	name, version, _ := strings.Cut(module, "@")
+	return name, version
+}
+
+func (g *lockGraph) parseGoMod(content []byte) error {
+	var root *lockPkg
+	directive := func(verb string, fields []string, comment string) error {
+		switch verb {
+		case "module":
+			if len(fields) == 0 {
+				return fmt.Errorf("module directive without path")
+			}
+			root = g.add(strings.Trim(fields[0], `"`), "")
+			root.root = true
+		case "require":
+			if root == nil {
+				return fmt.Errorf("require before the module directive")
+			}
+			if len(fields) < 2 {
+				return nil
+			}
+			kind := "direct"
+			if strings.TrimSpace(comment) == "indirect" {
+				kind = "indirect"
+			}
+			g.depend(root, g.add(fields[0], fields[1]), fields[1], kind)
+		case "replace":
+			old, replacement, ok := strings.Cut(strings.Join(fields, " "), "=>")
+			if !ok {
+				return nil
+			}
+			oldFields := strings.Fields(old)
+			for _, id := range g.order {
+				p := g.pkgs[id]
+				if p.name == oldFields[0] && (len(oldFields) == 1 || p.version == oldFields[1]) {
+					p.labels = append(p.labels, [2]string{"replaced by", strings.TrimSpace(replacement)})
+				}
+			}
+		}
+		return nil
+	}
+	var block string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line, comment, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case block != "" && fields[0] == ")":
+			block = ""
+		case block != "":
+			if err := directive(block, fields, comment); err != nil {
+				return err
+			}
+		case len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+		default:
+			if err := directive(fields[0], fields[1:], comment); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// parseGoSum reads the modules of a go.sum with there hashes.
+// A go.sum does not know which module requires which, so the graph has no edges,
+// open the go.mod or the output of "go mod graph" for them.
+// The lines of the form "<module> <version>/go.mod" only hash the go.mod of modules,
+// which were needed to select the versions, so they are skipped.
+func (g *lockGraph) parseGoSum(content []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		p := g.add(fields[0], fields[1])
+		p.labels = append(p.labels, [2]string{"hash", fields[2]})
+	}
+	return scanner.Err()
+}
+
+// parseGoGraph reads the output of "go mod graph", the main module is written without version
+func (g *lockGraph) parseGoGraph(content []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		from := g.add(splitModule(fields[0]))
+		if from.version == "" {
+			from.root = true
+		}
+		to := g.add(splitModule(fields[1]))
+		g.depend(from, to, to.version, "")
+	}
+	return scanner.Err()
+}
+
+type npmPackage struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Resolved             string            `json:"resolved"`
+	Integrity            string            `json:"integrity"`
+	Link                 bool              `json:"link"`
+	Dev                  bool              `json:"dev"`
+	Optional             bool              `json:"optional"`
+	License              string            `json:"license"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+}
+
+// npmLegacy is a entry of the nested dependencies of lockfile version 1
+type npmLegacy struct {
+	Version      string               `json:"version"`
+	Resolved     string               `json:"resolved"`
+	Integrity    string               `json:"integrity"`
+	Dev          bool                 `json:"dev"`
+	Optional     bool                 `json:"optional"`
+	Requires     map[string]string    `json:"requires"`
+	Dependencies map[string]npmLegacy `json:"dependencies"`
+}
+
+func (g *lockGraph) parseNPM(content []byte) error {
+	var lock struct {
+		Name         string                `json:"name"`
+		Version      string                `json:"version"`
+		Packages     map[string]npmPackage `json:"packages"`
+		Dependencies map[string]npmLegacy  `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return err
+	}
+	packages := lock.Packages
+	if packages == nil {
+		// version 1 only nests the dependencies, they are flattened into node_modules paths
+		packages = map[string]npmPackage{"": {Name: lock.Name, Version: lock.Version}}
+		root := packages[""]
+		root.Dependencies = make(map[string]string)
+		for name, dep := range lock.Dependencies {
+			if dep.Dev {
+				continue
+			}
+			root.Dependencies[name] = dep.Version
+		}
+		packages[""] = root
+		flattenNPM(packages, "", lock.Dependencies)
+	}
+	paths := make([]string, 0, len(packages))
+	for path := range packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	nodes := make(map[string]*lockPkg)
+	for _, path := range paths {
+		p := packages[path]
+		if p.Link {
+			if target, ok := packages[p.Resolved]; ok {
+				p = target
+			}
+		}
+		name := p.Name
+		if name == "" {
+			name = npmName(path)
+		}
+		pkg := g.add(name, p.Version)
+		if path == "" {
+			pkg.root = true
+		}
+		if len(pkg.labels) == 0 {
+			for _, l := range [][2]string{{"resolved", p.Resolved}, {"integrity", p.Integrity}, {"license", p.License}} {
+				if l[1] != "" {
+					pkg.labels = append(pkg.labels, l)
+				}
+			}
+			if p.Dev {
+				pkg.labels = append(pkg.labels, [2]string{"dev", "true"})
+			}
+			if p.Optional {
+				pkg.labels = append(pkg.labels, [2]string{"optional", "true"})
+			}
+		}
+		nodes[path] = pkg
+	}
+	for _, path := range paths {
+		p := packages[path]
+		kinds := []struct {
+			kind string
+			deps map[string]string
+		}{
+			{"dependency", p.Dependencies},
+			{"optional", p.OptionalDependencies},
+			{"peer", p.PeerDependencies},
+		}
+		if path == "" {
+			kinds = append(kinds, struct {
+				kind string
+				deps map[string]string
+			}{"dev", p.DevDependencies})
+		}
+		for _, k := range kinds {
+			names := make([]string, 0, len(k.deps))
+			for name := range k.deps {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				to, ok := nodes[resolveNPM(packages, path, name)]
+				if !ok {
+					continue
+				}
+				g.depend(nodes[path], to, k.deps[name], k.kind)
+			}
+		}
+	}
+	return nil
+}
+
+func flattenNPM(packages map[string]npmPackage, parent string, deps map[string]npmLegacy) {
+	for name, dep := range deps {
+		path := strings.TrimPrefix(parent+"/node_modules/"+name, "/")
+		packages[path] = npmPackage{
+			Version:      dep.Version,
+			Resolved:     dep.Resolved,
+			Integrity:    dep.Integrity,
+			Dev:          dep.Dev,
+			Optional:     dep.Optional,
+			Dependencies: dep.Requires,
+		}
+		flattenNPM(packages, path, dep.Dependencies)
+	}
+}
+
+// npmName returns the package name of a node_modules path, which may be scoped
+func npmName(path string) string {
+	i := strings.LastIndex(path, "node_modules/")
+	if i < 0 {
+		return path
+	}
+	return path[i+len("node_modules/"):]
+}
+
+// resolveNPM searches the package like node does, in the node_modules of the package and then of its parents
+func resolveNPM(packages map[string]npmPackage, from, name string) string {
+	for dir := from; ; {
+		path := strings.TrimPrefix(dir+"/node_modules/"+name, "/")
+		if _, ok := packages[path]; ok {
+			return path
+		}
+		if dir == "" {
+			return ""
+		}
+		i := strings.LastIndex(dir, "/node_modules/")
+		if i < 0 {
+			dir = ""
+			continue
+		}
+		dir = dir[:i]
+	}
+}
+
+// tomlTable is a table of a lockfile, nested tables are stored under there last key
+type tomlTable map[string]interface{}
+
+// parseLockTOML reads the [[package]] tables of a Cargo or poetry lockfile,
+// it supports the subset of TOML those files are written in
+func parseLockTOML(content []byte) ([]tomlTable, error) {
+	var packages []tomlTable
+	var current tomlTable
+	lines := strings.Split(string(content), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case line == "[[package]]":
+			current = make(tomlTable)
+			packages = append(packages, current)
+			continue
+		case strings.HasPrefix(line, "[package.") && strings.HasSuffix(line, "]") && current != nil:
+			sub := make(tomlTable)
+			current[strings.TrimSuffix(strings.TrimPrefix(line, "[package."), "]")] = sub
+			// the keys of the sub table are read into it until the next table
+			for i+1 < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i+1]), "[") {
+				i++
+				if err := readTOMLKey(sub, lines, &i); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		case strings.HasPrefix(line, "["):
+			current = nil
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if err := readTOMLKey(current, lines, &i); err != nil {
+			return nil, err
+		}
+	}
+	return packages, nil
+}
+
+// readTOMLKey reads the key value pair starting at line i, arrays may span multiple lines
+func readTOMLKey(table tomlTable, lines []string, i *int) error {
+	line := strings.TrimSpace(lines[*i])
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return fmt.Errorf("line %d: expected key = value", *i+1)
+	}
+	value = strings.TrimSpace(value)
+	for tomlOpen(value) > 0 && *i+1 < len(lines) {
+		*i++
+		value += " " + strings.TrimSpace(lines[*i])
+	}
+	parsed, rest, err := parseTOMLValue(value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", *i+1, err)
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("line %d: unexpected '%s'", *i+1, rest)
+	}
+	table[strings.Trim(strings.TrimSpace(key), `"`)] = parsed
+	return nil
+}
+
+// tomlOpen returns how many brackets are not closed outside of strings
+func tomlOpen(value string) int {
+	open := 0
+	var quote rune
+	for _, r := range value {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[' || r == '{':
+			open++
+		case r == ']' || r == '}':
+			open--
+		}
+	}
+	return open
+}
+
+// parseTOMLValue parses a string, array, inline table or a bare value like a number
+func parseTOMLValue(value string) (interface{}, string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, "", fmt.Errorf("missing value")
+	}
+	switch value[0] {
+	case '"':
+		end := 1
+		for ; end < len(value); end++ {
+			if value[end] == '\\' {
+				end++
+				continue
+			}
+			if value[end] == '"' {
+				break
+			}
+		}
+		if end >= len(value) {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		s, err := strconv.Unquote(value[:end+1])
+		return s, value[end+1:], err
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		return value[1 : end+1], value[end+2:], nil
+	case '[':
+		var list []interface{}
+		rest := strings.TrimSpace(value[1:])
+		for !strings.HasPrefix(rest, "]") {
+			item, r, err := parseTOMLValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			list = append(list, item)
+			rest = strings.TrimPrefix(strings.TrimSpace(r), ",")
+			rest = strings.TrimSpace(rest)
+			if rest == "" {
+				return nil, "", fmt.Errorf("unterminated array")
+			}
+		}
+		return list, rest[1:], nil
+	case '{':
+		table := make(tomlTable)
+		rest := strings.TrimSpace(value[1:])
+		for !strings.HasPrefix(rest, "}") {
+			key, r, ok := strings.Cut(rest, "=")
+			if !ok {
+				return nil, "", fmt.Errorf("unterminated inline table")
+			}
+			item, r, err := parseTOMLValue(r)
+			if err != nil {
+				return nil, "", err
+			}
+			table[strings.Trim(strings.TrimSpace(key), `"`)] = item
+			rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(r), ","))
+			if rest == "" {
+				return nil, "", fmt.Errorf("unterminated inline table")
+			}
+		}
+		return table, rest[1:], nil
+	}
+	end := strings.IndexAny(value, ",]}#")
+	if end < 0 {
+		end = len(value)
+	}
+	return strings.TrimSpace(value[:end]), value[end:], nil
+}
+
+func tomlString(table tomlTable, key string) string {
+	s, _ := table[key].(string)
+	return s
+}
+
+func (g *lockGraph) parseCargo(content []byte) error {
+	packages, err := parseLockTOML(content)
+	if err != nil {
+		return err
+	}
+	for _, p := range packages {
+		pkg := g.add(tomlString(p, "name"), tomlString(p, "version"))
+		source := tomlString(p, "source")
+		if source == "" {
+			// packages without source are the members of the workspace
+			pkg.root = true
+			continue
+		}
+		pkg.labels = append(pkg.labels, [2]string{"source", source})
+		if checksum := tomlString(p, "checksum"); checksum != "" {
+			pkg.labels = append(pkg.labels, [2]string{"checksum", checksum})
+		}
+	}
+	for _, p := range packages {
+		from := g.pkgs[lockNode{name: tomlString(p, "name"), version: tomlString(p, "version")}.String()]
+		deps, _ := p["dependencies"].([]interface{})
+		for _, d := range deps {
+			spec, _ := d.(string)
+			// a dependency is written as name, or with version and source if the name is ambiguous
+			fields := strings.Fields(spec)
+			if len(fields) == 0 {
+				continue
+			}
+			version := ""
+			if len(fields) > 1 {
+				version = fields[1]
+			}
+			to := g.find(fields[0], version)
+			if to == nil {
+				continue
+			}
+			g.depend(from, to, version, "")
+		}
+	}
+	return nil
+}
+
+func (g *lockGraph) parsePoetry(content []byte) error {
+	packages, err := parseLockTOML(content)
+	if err != nil {
+		return err
+	}
+	for _, p := range packages {
+		pkg := g.add(poetryName(tomlString(p, "name")), tomlString(p, "version"))
+		for _, key := range []string{"category", "optional", "python-versions", "description"} {
+			if value := tomlString(p, key); value != "" {
+				pkg.labels = append(pkg.labels, [2]string{key, value})
+			}
+		}
+	}
+	for _, p := range packages {
+		from := g.pkgs[lockNode{name: poetryName(tomlString(p, "name")), version: tomlString(p, "version")}.String()]
+		deps, _ := p["dependencies"].(tomlTable)
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			to := g.find(poetryName(name), "")
+			if to == nil {
+				continue
+			}
+			constraint := ""
+			switch spec := deps[name].(type) {
+			case string:
+				constraint = spec
+			case tomlTable:
+				constraint = tomlString(spec, "version")
+			case []interface{}:
+				// alternatives for different markers
+				var versions []string
+				for _, s := range spec {
+					if t, ok := s.(tomlTable); ok {
+						versions = append(versions, tomlString(t, "version"))
+					}
+				}
+				constraint = strings.Join(versions, " | ")
+			}
+			g.depend(from, to, constraint, "")
+		}
+	}
+	// poetry does not record the project itself, so packages nothing depends on are the roots
+	for _, id := range g.order {
+		if len(g.incoming(id)) == 0 {
+			g.pkgs[id].root = true
+		}
+	}
+	return nil
+}
+
+// poetryName normalizes python package names like pip does
+func poetryName(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(strings.ToLower(name), "_", "-"), ".", "-")
+}
+
+// find returns the package by name and version, without version the first package of the name
+func (g *lockGraph) find(name, version string) *lockPkg {
+	for _, id := range g.order {
+		p := g.pkgs[id]
+		if p.name == name && (version == "" || p.version == version) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (g *lockGraph) incoming(id string) []string {
+	var in []string
+	for _, from := range g.order {
+		for _, d := range g.deps[from] {
+			if d.to == id {
+				in = append(in, from)
+				break
+			}
+		}
+	}
+	return in
+}
+
+func (g *lockGraph) lookup(str fmt.Stringer) (*lockPkg, error) {
+	if str == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(lockNode)
+	if !ok {
+		return nil, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	p, ok := g.pkgs[n.String()]
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not part of the lockfile", n.String())
+	}
+	return p, nil
+}
+
+var _ walder.Graph = &lockGraph{}
+
+func (g *lockGraph) String() string {
+	if g.format == "" {
+		return "lockfile"
+	}
+	return g.format
+}
+
+// HomeNodes returns the own modules or packages, if they are unknown all packages
+func (g *lockGraph) HomeNodes() ([]fmt.Stringer, error) {
+	var home []fmt.Stringer
+	for _, id := range g.order {
+		if g.pkgs[id].root {
+			home = append(home, g.pkgs[id].lockNode)
+		}
+	}
+	if home == nil {
+		return g.NodeAll()
+	}
+	return home, nil
+}
+
+var _ walder.NodeAller = &lockGraph{}
+
+func (g *lockGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(g.order))
+	for _, id := range g.order {
+		all = append(all, g.pkgs[id].lockNode)
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &lockGraph{}
+
+// Outgoing returns the dependencies of the package
+func (g *lockGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	p, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	for _, d := range g.deps[p.String()] {
+		out = append(out, g.pkgs[d.to].lockNode)
+	}
+	return out, nil
+}
+
+// Incoming returns the packages depending on the package, which answers why it is included
+func (g *lockGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	p, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, id := range g.incoming(p.String()) {
+		in = append(in, g.pkgs[id].lockNode)
+	}
+	return in, nil
+}
+
+var _ walder.EdgeLabeler = &lockGraph{}
+
+// EdgeLabels returns the version constraint and the kind of dependency
+func (g *lockGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range g.deps[f.String()] {
+		if d.to != t.String() {
+			continue
+		}
+		labels := [][2]string{{"constraint", d.constraint}}
+		if d.kind != "" {
+			labels = append(labels, [2]string{"kind", d.kind})
+		}
+		return labels, nil
+	}
+	return nil, fmt.Errorf("'%s' does not depend on '%s'", f.String(), t.String())
+}
+
+var _ walder.Typer = &lockGraph{}
+
+// GetType flags packages which are included in more than one version
+func (g *lockGraph) GetType(str fmt.Stringer) (string, error) {
+	p, err := g.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	if p.root {
+		return lockRoot, nil
+	}
+	for _, id := range g.order {
+		other := g.pkgs[id]
+		if other.name == p.name && other.version != p.version {
+			return lockDuplicate, nil
+		}
+	}
+	return lockPackage, nil
+}
+
+var _ walder.NodeLabeler = &lockGraph{}
+
+func (g *lockGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	p, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{{"name", p.name}}
+	if p.version != "" {
+		labels = append(labels, [2]string{"version", p.version})
+	}
+	var others []string
+	for _, id := range g.order {
+		other := g.pkgs[id]
+		if other.name == p.name && other.version != p.version {
+			others = append(others, other.version)
+		}
+	}
+	if others != nil {
+		labels = append(labels, [2]string{"other versions", strings.Join(others, ", ")})
+	}
+	return append(labels, p.labels...), nil
+}
@@ -0,0 +1,52 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLockGo(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		all      string
+		node     string
+		outgoing string
+	}{
+		{"go.sum", "golang.org/x/text v0.7.0 h1:abc=\ngolang.org/x/text v0.7.0/go.mod h1:def=\ngolang.org/x/tools v0.1.0/go.mod h1:ghi=\n",
+			"golang.org/x/text@v0.7.0", "golang.org/x/text@v0.7.0", ""},
+		{"go.mod", "module example.com/m\n\ngo 1.19\n\nrequire (\n\tgolang.org/x/text v0.7.0\n\tgolang.org/x/tools v0.1.0 // indirect\n)\n",
+			"example.com/m golang.org/x/text@v0.7.0 golang.org/x/tools@v0.1.0", "example.com/m", "golang.org/x/text@v0.7.0 golang.org/x/tools@v0.1.0"},
+		{"go mod graph", "example.com/m golang.org/x/text@v0.7.0\ngolang.org/x/text@v0.7.0 golang.org/x/tools@v0.1.0\n",
+			"example.com/m golang.org/x/text@v0.7.0 golang.org/x/tools@v0.1.0", "golang.org/x/text@v0.7.0", "golang.org/x/tools@v0.1.0"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := LockDim{}.Open(strings.NewReader(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			l := g.(*lockGraph)
+			all, err := l.NodeAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(all); got != test.all {
+				t.Errorf("want nodes '%s', but got '%s'", test.all, got)
+			}
+			var node lockNode
+			for _, n := range all {
+				if n.String() == test.node {
+					node = n.(lockNode)
+				}
+			}
+			out, err := l.Outgoing(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(out); got != test.outgoing {
+				t.Errorf("want outgoing '%s', but got '%s'", test.outgoing, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,438 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// MakeDim is a generator for the target graph of a Makefile
+type MakeDim struct{}
+
+var _ walder.Dimensioner = MakeDim{}
+
+func (d MakeDim) String() string {
+	return "makefile"
+}
+
+func (d MakeDim) New() (walder.Graph, error) {
+	return nil, fmt.Errorf("a makefile needs to be opened from the filesystem")
+}
+
+var _ walder.NodeOpener = MakeDim{}
+
+// NodeOpen parses the Makefile of the node, if the node is a directory its Makefile is used
+func (d MakeDim) NodeOpen(nodes ...fmt.Stringer) (walder.Graph, error) {
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("need exactly one node, got %d", len(nodes))
+	}
+	node := nodes[0]
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	path := node.String()
+	if p, ok := node.(walder.Pather); ok {
+		var err error
+		path, err = p.Path()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		for _, name := range []string{"GNUmakefile", "makefile", "Makefile"} {
+			if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+				path = filepath.Join(path, name)
+				break
+			}
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &makeGraph{
+		path:    path,
+		targets: make(map[string]*makeRule),
+		vars:    make(map[string]string),
+		phony:   make(map[string]bool),
+	}
+	if err := m.parse(f); err != nil {
+		return nil, fmt.Errorf("error while parsing '%s': %w", path, err)
+	}
+	return m, nil
+}
+
+var (
+	makeAssign = regexp.MustCompile(`^(?:export\s+|override\s+)*([^\s:#=+?!]+)\s*(=|:=|::=|\?=|\+=|!=)\s*(.*)$`)
+	makeVar    = regexp.MustCompile(`\$[({]([^(){}:]+)[)}]`)
+)
+
+const (
+	makePhony   = "phony"
+	makeFile    = "file"
+	makePattern = "pattern"
+)
+
+type makeTarget string
+
+func (t makeTarget) String() string {
+	return string(t)
+}
+
+type makeRule struct {
+	prerequisites []string
+	orderOnly     []string
+	recipe        []string
+}
+
+type makeGraph struct {
+	path   string
+	walder *Walder
+	// order holds every target and prerequisite in the order they appear
+	order   []string
+	goal    string
+	targets map[string]*makeRule
+	vars    map[string]string
+	phony   map[string]bool
+}
+
+func (m *makeGraph) parse(from io.Reader) error {
+	scanner := bufio.NewScanner(from)
+	var rules []*makeRule
+	var define string
+	var pending string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if define != "" {
+			if strings.TrimSpace(line) == "endef" {
+				define = ""
+			}
+			continue
+		}
+		// recipe lines belong to the last rule
+		if strings.HasPrefix(line, "\t") && pending == "" {
+			for _, r := range rules {
+				r.recipe = append(r.recipe, strings.TrimPrefix(line, "\t"))
+			}
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		line, pending = pending+line, ""
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "define":
+			define = strings.Join(fields[1:], " ")
+			rules = nil
+			continue
+		case "include", "-include", "sinclude", "ifeq", "ifneq", "ifdef", "ifndef", "else", "endif", "export", "unexport", "vpath", "override":
+			if !makeAssign.MatchString(line) {
+				rules = nil
+				continue
+			}
+		}
+		if a := makeAssign.FindStringSubmatch(line); a != nil {
+			switch a[2] {
+			case "+=":
+				m.vars[a[1]] = strings.TrimSpace(m.vars[a[1]] + " " + a[3])
+			case "?=":
+				if _, ok := m.vars[a[1]]; !ok {
+					m.vars[a[1]] = a[3]
+				}
+			case "!=":
+				// the output of shell commands is not known
+			default:
+				m.vars[a[1]] = a[3]
+			}
+			rules = nil
+			continue
+		}
+		rules = m.parseRule(line)
+	}
+	return scanner.Err()
+}
+
+// parseRule adds the targets of the rule line and returns there rules to collect the recipe
+func (m *makeGraph) parseRule(line string) []*makeRule {
+	targetPart, rest, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil
+	}
+	rest = strings.TrimPrefix(rest, ":")
+	rest, recipe, hasRecipe := strings.Cut(rest, ";")
+	if strings.Contains(rest, "=") {
+		// target specific variables are no prerequisites
+		return nil
+	}
+	// static pattern rules name the target pattern before the prerequisites
+	if _, prerequisites, ok := strings.Cut(rest, ":"); ok {
+		rest = prerequisites
+	}
+	normal, orderOnly, _ := strings.Cut(rest, "|")
+
+	targets := strings.Fields(m.expand(targetPart, 0))
+	prerequisites := strings.Fields(m.expand(normal, 0))
+	if len(targets) == 1 && targets[0] == ".PHONY" {
+		for _, p := range prerequisites {
+			m.phony[p] = true
+			m.add(p)
+		}
+		return nil
+	}
+	var rules []*makeRule
+	for _, t := range targets {
+		if strings.HasPrefix(t, ".") && strings.ToUpper(t) == t {
+			// special targets like .SUFFIXES are no real targets
+			continue
+		}
+		if m.goal == "" && !strings.Contains(t, "%") {
+			m.goal = t
+		}
+		m.add(t)
+		r, ok := m.targets[t]
+		if !ok {
+			r = &makeRule{}
+			m.targets[t] = r
+		}
+		for _, p := range prerequisites {
+			m.add(p)
+			r.prerequisites = append(r.prerequisites, p)
+		}
+		for _, p := range strings.Fields(m.expand(orderOnly, 0)) {
+			m.add(p)
+			r.orderOnly = append(r.orderOnly, p)
+		}
+		if hasRecipe {
+			r.recipe = append(r.recipe, strings.TrimSpace(recipe))
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func (m *makeGraph) add(name string) {
+	for _, n := range m.order {
+		if n == name {
+			return
+		}
+	}
+	m.order = append(m.order, name)
+}
+
+// expand replaces references to variables defined in the Makefile, others are kept as they are
+func (m *makeGraph) expand(text string, depth int) string {
+	if depth > 10 {
+		return text
+	}
+	return makeVar.ReplaceAllStringFunc(text, func(ref string) string {
+		name := makeVar.FindStringSubmatch(ref)[1]
+		value, ok := m.vars[strings.TrimSpace(name)]
+		if !ok {
+			return ref
+		}
+		return m.expand(value, depth+1)
+	})
+}
+
+func (m *makeGraph) lookup(str fmt.Stringer) (string, error) {
+	if str == nil {
+		return "", fmt.Errorf("recieved nil value")
+	}
+	t, ok := str.(makeTarget)
+	if !ok {
+		return "", fmt.Errorf("want %T, but got %T", t, str)
+	}
+	for _, n := range m.order {
+		if n == string(t) {
+			return n, nil
+		}
+	}
+	return "", fmt.Errorf("'%s' is not part of this makefile", t)
+}
+
+var _ internal = &makeGraph{}
+
+func (m *makeGraph) renew(w *Walder) {
+	m.walder = w
+}
+
+var _ walder.Graph = &makeGraph{}
+
+func (m *makeGraph) String() string {
+	return fmt.Sprintf("makefile: %s", filepath.Base(m.path))
+}
+
+// HomeNodes returns all targets which have a rule, the default goal first
+func (m *makeGraph) HomeNodes() ([]fmt.Stringer, error) {
+	var home []fmt.Stringer
+	if m.goal != "" {
+		home = append(home, makeTarget(m.goal))
+	}
+	for _, n := range m.order {
+		if _, ok := m.targets[n]; ok && n != m.goal {
+			home = append(home, makeTarget(n))
+		}
+	}
+	return home, nil
+}
+
+var _ walder.NodeAller = &makeGraph{}
+
+func (m *makeGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(m.order))
+	for _, n := range m.order {
+		all = append(all, makeTarget(n))
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &makeGraph{}
+
+// Outgoing returns the prerequisites of the target
+func (m *makeGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	t, err := m.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := m.targets[t]
+	if !ok {
+		return nil, nil
+	}
+	var out []fmt.Stringer
+	for _, p := range append(r.prerequisites[:len(r.prerequisites):len(r.prerequisites)], r.orderOnly...) {
+		out = append(out, makeTarget(p))
+	}
+	return out, nil
+}
+
+// Incoming returns the targets which need the node
+func (m *makeGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	t, err := m.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, n := range m.order {
+		r, ok := m.targets[n]
+		if !ok {
+			continue
+		}
+		for _, p := range append(r.prerequisites[:len(r.prerequisites):len(r.prerequisites)], r.orderOnly...) {
+			if p == t {
+				in = append(in, makeTarget(n))
+				break
+			}
+		}
+	}
+	return in, nil
+}
+
+var _ walder.EdgeLabeler = &makeGraph{}
+
+func (m *makeGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, err := m.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := m.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := m.targets[f]
+	if !ok {
+		return nil, fmt.Errorf("'%s' has no rule", f)
+	}
+	for _, p := range r.prerequisites {
+		if p == t {
+			return [][2]string{{"prerequisite", "normal"}}, nil
+		}
+	}
+	for _, p := range r.orderOnly {
+		if p == t {
+			return [][2]string{{"prerequisite", "order-only"}}, nil
+		}
+	}
+	return nil, fmt.Errorf("'%s' is no prerequisite of '%s'", t, f)
+}
+
+var _ walder.Typer = &makeGraph{}
+
+func (m *makeGraph) GetType(str fmt.Stringer) (string, error) {
+	t, err := m.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case m.phony[t]:
+		return makePhony, nil
+	case strings.Contains(t, "%"):
+		return makePattern, nil
+	}
+	return makeFile, nil
+}
+
+var _ walder.NodeReader = &makeGraph{}
+
+// NodeRead returns the recipe of the target
+func (m *makeGraph) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	t, err := m.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := m.targets[t]
+	if !ok {
+		return nil, fmt.Errorf("'%s' has no rule", t)
+	}
+	return strings.NewReader(strings.Join(r.recipe, "\n")), nil
+}
+
+var _ walder.Executor = &makeGraph{}
+
+// Execute runs make for the target in the background, so the ui does not block while it runs.
+// When make finished its output is pushed as string graph.
+func (m *makeGraph) Execute(node fmt.Stringer) error {
+	t, err := m.lookup(node)
+	if err != nil {
+		return err
+	}
+	if m.walder == nil {
+		return fmt.Errorf("the makefile graph is not part of a walder")
+	}
+	cmd := exec.Command("make", "-C", filepath.Dir(m.path), "-f", filepath.Base(m.path), t)
+	done := m.walder.Syncer
+	go func() {
+		out, runErr := cmd.CombinedOutput()
+		done <- syncer{do: func(w *Walder) error {
+			g, err := String{}.Open(bytes.NewReader(out))
+			if err != nil {
+				return err
+			}
+			if err := w.Push(g); err != nil {
+				return err
+			}
+			if runErr != nil {
+				return fmt.Errorf("make %s: %w", t, runErr)
+			}
+			return nil
+		}}
+	}()
+	return nil
+}
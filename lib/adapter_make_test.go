@@ -0,0 +1,108 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const makeSample = `.PHONY: all clean
+OUT = out.txt
+
+all: $(OUT)
+
+$(OUT): in.txt
+	@echo built $@
+
+clean:
+	@echo cleaned
+`
+
+// openMake writes the makefile into a temporary directory and opens it
+func openMake(t *testing.T, content string) *makeGraph {
+	t.Helper()
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "Makefile"), []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "in.txt"), nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := MakeDim{}.NodeOpen(stringer(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g.(*makeGraph)
+}
+
+func TestMakeTargets(t *testing.T) {
+	m := openMake(t, makeSample)
+	tests := []struct {
+		target   string
+		outgoing string
+		kind     string
+	}{
+		{"all", "out.txt", makePhony},
+		{"out.txt", "in.txt", makeFile},
+		{"clean", "", makePhony},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			node := makeNode(t, m, test.target)
+			out, err := m.Outgoing(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(out); got != test.outgoing {
+				t.Errorf("want outgoing '%s', but got '%s'", test.outgoing, got)
+			}
+			kind, err := m.GetType(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kind != test.kind {
+				t.Errorf("want type '%s', but got '%s'", test.kind, kind)
+			}
+		})
+	}
+}
+
+// makeNode returns the node of the target
+func makeNode(t *testing.T, m *makeGraph, target string) fmt.Stringer {
+	t.Helper()
+	all, err := m.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range all {
+		if n.String() == target {
+			return n
+		}
+	}
+	t.Fatalf("no target '%s'", target)
+	return nil
+}
+
+func TestMakeExecute(t *testing.T) {
+	m := openMake(t, makeSample)
+	w := NewWalder()
+	m.renew(w)
+	err := m.Execute(makeNode(t, m, "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// make runs in the background and its output is pushed within Update
+	done := <-w.Syncer
+	err = done.do(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readAll(t, w.peek().graph)
+	if !strings.Contains(got, "built out.txt") {
+		t.Errorf("want the output of make, but got '%s'", got)
+	}
+}
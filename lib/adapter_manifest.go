@@ -0,0 +1,703 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/treilik/walder"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestDim is a generator for the relationships between the services of a docker-compose file
+// or the resources of Kubernetes manifests. Everything is read from the files, no cluster is contacted.
+type ManifestDim struct{}
+
+var _ walder.Dimensioner = ManifestDim{}
+
+func (d ManifestDim) String() string {
+	return "manifests"
+}
+
+func (d ManifestDim) New() (walder.Graph, error) {
+	return newManifestGraph(), nil
+}
+
+var _ walder.OpenReader = ManifestDim{}
+
+// Open reads a docker-compose file or a stream of Kubernetes manifests
+func (d ManifestDim) Open(from io.Reader) (walder.Graph, error) {
+	g := newManifestGraph()
+	if err := g.read(from, ""); err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	g.link()
+	return g, nil
+}
+
+var _ walder.NodeOpener = ManifestDim{}
+
+// NodeOpen reads the file of the node, for a directory its compose file
+// or else all YAML files below it are read as Kubernetes manifests
+func (d ManifestDim) NodeOpen(nodes ...fmt.Stringer) (walder.Graph, error) {
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("need exactly one node, got %d", len(nodes))
+	}
+	node := nodes[0]
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	path := node.String()
+	if p, ok := node.(walder.Pather); ok {
+		var err error
+		path, err = p.Path()
+		if err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files, err = manifestFiles(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	g := newManifestGraph()
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		err = g.read(f, file)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error while reading '%s': %w", file, err)
+		}
+	}
+	g.link()
+	return g, nil
+}
+
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+func manifestFiles(dir string) ([]string, error) {
+	for _, name := range composeFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return []string{filepath.Join(dir, name)}, nil
+		}
+	}
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if ext := filepath.Ext(path); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+const (
+	composeService = "service"
+	composeVolume  = "volume"
+	composeNetwork = "network"
+	composeConfig  = "config"
+	composeSecret  = "secret"
+)
+
+// clusterScoped holds the kinds which do not belong to a namespace
+var clusterScoped = map[string]bool{
+	"Namespace":                true,
+	"Node":                     true,
+	"PersistentVolume":         true,
+	"StorageClass":             true,
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+	"CustomResourceDefinition": true,
+	"IngressClass":             true,
+	"PriorityClass":            true,
+}
+
+// podOwners holds the kinds whose pods are selected by there template labels
+var podOwners = []string{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job", "Pod"}
+
+// manifestNode is a compose service or a Kubernetes resource named kind/name, prefixed by its namespace
+type manifestNode string
+
+func (m manifestNode) String() string {
+	return string(m)
+}
+
+type manifestRes struct {
+	kind      string
+	namespace string
+	name      string
+	file      string
+	value     *yaml.Node
+}
+
+type manifestEdge struct {
+	from     string
+	to       string
+	relation string
+	detail   string
+}
+
+type manifestGraph struct {
+	order     []string
+	resources map[string]*manifestRes
+	edges     []manifestEdge
+}
+
+func newManifestGraph() *manifestGraph {
+	return &manifestGraph{resources: make(map[string]*manifestRes)}
+}
+
+// yamlGet follows the keys through nested mappings and returns nil if one is missing
+func yamlGet(n *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		if n == nil || n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				next = n.Content[i+1]
+				break
+			}
+		}
+		n = next
+	}
+	return n
+}
+
+// yamlString returns the value of a scalar or "" for anything else
+func yamlString(n *yaml.Node) string {
+	if n == nil || n.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return n.Value
+}
+
+// yamlMap returns the scalar entries of a mapping or of a list of key=value strings
+func yamlMap(n *yaml.Node) map[string]string {
+	m := make(map[string]string)
+	if n == nil {
+		return m
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			m[n.Content[i].Value] = yamlString(n.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			key, value, _ := strings.Cut(yamlString(c), "=")
+			m[key] = value
+		}
+	}
+	return m
+}
+
+func (g *manifestGraph) read(from io.Reader, file string) error {
+	decoder := yaml.NewDecoder(from)
+	for {
+		doc := &yaml.Node{}
+		err := decoder.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := doc.Content[0]
+		switch {
+		case yamlGet(root, "kind") != nil:
+			g.addResource(root, file)
+		case yamlGet(root, "services") != nil:
+			g.addCompose(root, file)
+		}
+	}
+}
+
+func (g *manifestGraph) add(id string, r *manifestRes) {
+	if old, ok := g.resources[id]; ok {
+		// a referenced resource which is defined later gets its definition
+		if old.value == nil {
+			g.resources[id] = r
+		}
+		return
+	}
+	g.resources[id] = r
+	g.order = append(g.order, id)
+}
+
+func (g *manifestGraph) addCompose(root *yaml.Node, file string) {
+	for _, kind := range []string{composeService, composeVolume, composeNetwork, composeConfig, composeSecret} {
+		section := yamlGet(root, kind+"s")
+		if section == nil || section.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(section.Content); i += 2 {
+			name := section.Content[i].Value
+			g.add(composeID(kind, name), &manifestRes{kind: kind, name: name, file: file, value: section.Content[i+1]})
+		}
+	}
+}
+
+func composeID(kind, name string) string {
+	return kind + "/" + name
+}
+
+func (g *manifestGraph) addResource(root *yaml.Node, file string) {
+	kind := yamlString(yamlGet(root, "kind"))
+	if strings.HasSuffix(kind, "List") {
+		if items := yamlGet(root, "items"); items != nil {
+			for _, item := range items.Content {
+				if item.Kind == yaml.MappingNode {
+					g.addResource(item, file)
+				}
+			}
+		}
+		return
+	}
+	name := yamlString(yamlGet(root, "metadata", "name"))
+	namespace := yamlString(yamlGet(root, "metadata", "namespace"))
+	if namespace == "" && !clusterScoped[kind] {
+		namespace = "default"
+	}
+	g.add(kubeID(kind, namespace, name), &manifestRes{kind: kind, namespace: namespace, name: name, file: file, value: root})
+}
+
+func kubeID(kind, namespace, name string) string {
+	if namespace == "" || namespace == "default" {
+		return kind + "/" + name
+	}
+	return namespace + "/" + kind + "/" + name
+}
+
+// edge adds a edge and a node for the target if it is not defined
+func (g *manifestGraph) edge(from string, to string, target manifestRes, relation, detail string) {
+	if _, ok := g.resources[to]; !ok {
+		g.add(to, &target)
+	}
+	g.edges = append(g.edges, manifestEdge{from: from, to: to, relation: relation, detail: detail})
+}
+
+// link adds the edges after all files are read, so references between files are found
+func (g *manifestGraph) link() {
+	for _, id := range g.order[:len(g.order):len(g.order)] {
+		r := g.resources[id]
+		if r.value == nil {
+			continue
+		}
+		switch r.kind {
+		case composeService:
+			g.linkService(id, r)
+		case composeVolume, composeNetwork, composeConfig, composeSecret:
+		default:
+			g.linkResource(id, r)
+		}
+	}
+}
+
+func (g *manifestGraph) linkService(id string, r *manifestRes) {
+	service := func(name string) manifestRes {
+		return manifestRes{kind: composeService, name: name}
+	}
+	if deps := yamlGet(r.value, "depends_on"); deps != nil {
+		switch deps.Kind {
+		case yaml.SequenceNode:
+			for _, d := range deps.Content {
+				g.edge(id, composeID(composeService, d.Value), service(d.Value), "depends_on", "")
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(deps.Content); i += 2 {
+				name := deps.Content[i].Value
+				g.edge(id, composeID(composeService, name), service(name), "depends_on", yamlString(yamlGet(deps.Content[i+1], "condition")))
+			}
+		}
+	}
+	if links := yamlGet(r.value, "links"); links != nil {
+		for _, l := range links.Content {
+			name, alias, _ := strings.Cut(l.Value, ":")
+			g.edge(id, composeID(composeService, name), service(name), "links", alias)
+		}
+	}
+	if extends := yamlString(yamlGet(r.value, "extends", "service")); extends != "" {
+		g.edge(id, composeID(composeService, extends), service(extends), "extends", yamlString(yamlGet(r.value, "extends", "file")))
+	}
+	if mode := yamlString(yamlGet(r.value, "network_mode")); strings.HasPrefix(mode, "service:") {
+		name := strings.TrimPrefix(mode, "service:")
+		g.edge(id, composeID(composeService, name), service(name), "network_mode", "")
+	}
+	if volumes := yamlGet(r.value, "volumes"); volumes != nil {
+		for _, v := range volumes.Content {
+			source, target := yamlString(yamlGet(v, "source")), yamlString(yamlGet(v, "target"))
+			if v.Kind == yaml.ScalarNode {
+				source, target, _ = strings.Cut(v.Value, ":")
+			}
+			// bind mounts are paths, only named volumes are nodes
+			if source == "" || strings.ContainsAny(source[:1], "./~$") {
+				continue
+			}
+			g.edge(id, composeID(composeVolume, source), manifestRes{kind: composeVolume, name: source}, "volume", target)
+		}
+	}
+	if networks := yamlGet(r.value, "networks"); networks != nil {
+		for _, name := range composeNames(networks) {
+			g.edge(id, composeID(composeNetwork, name), manifestRes{kind: composeNetwork, name: name}, "network", "")
+		}
+	}
+	for _, kind := range []string{composeConfig, composeSecret} {
+		refs := yamlGet(r.value, kind+"s")
+		if refs == nil {
+			continue
+		}
+		for _, ref := range refs.Content {
+			name, target := ref.Value, ""
+			if ref.Kind == yaml.MappingNode {
+				name, target = yamlString(yamlGet(ref, "source")), yamlString(yamlGet(ref, "target"))
+			}
+			g.edge(id, composeID(kind, name), manifestRes{kind: kind, name: name}, kind, target)
+		}
+	}
+}
+
+// composeNames returns the names of a list or the keys of a mapping
+func composeNames(n *yaml.Node) []string {
+	var names []string
+	switch n.Kind {
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			names = append(names, c.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			names = append(names, n.Content[i].Value)
+		}
+	}
+	return names
+}
+
+func (g *manifestGraph) linkResource(id string, r *manifestRes) {
+	ref := func(kind, name, relation, detail string) {
+		namespace := r.namespace
+		if clusterScoped[kind] {
+			namespace = ""
+		}
+		g.edge(id, kubeID(kind, namespace, name), manifestRes{kind: kind, namespace: namespace, name: name}, relation, detail)
+	}
+	if owners := yamlGet(r.value, "metadata", "ownerReferences"); owners != nil {
+		for _, o := range owners.Content {
+			detail := ""
+			if yamlString(yamlGet(o, "controller")) == "true" {
+				detail = "controller"
+			}
+			ref(yamlString(yamlGet(o, "kind")), yamlString(yamlGet(o, "name")), "owner", detail)
+		}
+	}
+	switch r.kind {
+	case "Service":
+		if selector := yamlGet(r.value, "spec", "selector"); selector != nil {
+			g.selects(id, r, yamlMap(selector))
+		}
+	case "NetworkPolicy", "PodDisruptionBudget":
+		selector := yamlGet(r.value, "spec", "podSelector", "matchLabels")
+		if r.kind == "PodDisruptionBudget" {
+			selector = yamlGet(r.value, "spec", "selector", "matchLabels")
+		}
+		if selector != nil {
+			g.selects(id, r, yamlMap(selector))
+		}
+	case "HorizontalPodAutoscaler":
+		target := yamlGet(r.value, "spec", "scaleTargetRef")
+		ref(yamlString(yamlGet(target, "kind")), yamlString(yamlGet(target, "name")), "scales", "")
+	case "Ingress":
+		g.walk(r.value, func(key string, n *yaml.Node) {
+			if key == "service" && yamlGet(n, "name") != nil {
+				ref("Service", yamlString(yamlGet(n, "name")), "routes", yamlString(yamlGet(n, "port", "number")))
+			}
+			if key == "backend" && yamlGet(n, "serviceName") != nil {
+				ref("Service", yamlString(yamlGet(n, "serviceName")), "routes", yamlString(yamlGet(n, "servicePort")))
+			}
+		})
+	case "RoleBinding", "ClusterRoleBinding":
+		ref(yamlString(yamlGet(r.value, "roleRef", "kind")), yamlString(yamlGet(r.value, "roleRef", "name")), "role", "")
+		if subjects := yamlGet(r.value, "subjects"); subjects != nil {
+			for _, s := range subjects.Content {
+				if yamlString(yamlGet(s, "kind")) == "ServiceAccount" {
+					ref("ServiceAccount", yamlString(yamlGet(s, "name")), "subject", "")
+				}
+			}
+		}
+	}
+	spec := podSpec(r)
+	if spec == nil {
+		return
+	}
+	if account := yamlString(yamlGet(spec, "serviceAccountName")); account != "" {
+		ref("ServiceAccount", account, "service account", "")
+	}
+	g.walk(spec, func(key string, n *yaml.Node) {
+		switch key {
+		case "configMap", "configMapRef", "configMapKeyRef":
+			ref("ConfigMap", yamlString(yamlGet(n, "name")), "config", key)
+		case "secretRef", "secretKeyRef":
+			ref("Secret", yamlString(yamlGet(n, "name")), "secret", key)
+		case "secret":
+			// volumes name the secret in secretName, projections in name
+			name := yamlString(yamlGet(n, "secretName"))
+			if name == "" {
+				name = yamlString(yamlGet(n, "name"))
+			}
+			ref("Secret", name, "secret", key)
+		case "imagePullSecrets":
+			for _, s := range n.Content {
+				ref("Secret", yamlString(yamlGet(s, "name")), "secret", key)
+			}
+		case "persistentVolumeClaim":
+			ref("PersistentVolumeClaim", yamlString(yamlGet(n, "claimName")), "volume", key)
+		}
+	})
+}
+
+// podSpec returns the spec of the pods a resource runs
+func podSpec(r *manifestRes) *yaml.Node {
+	switch r.kind {
+	case "Pod":
+		return yamlGet(r.value, "spec")
+	case "CronJob":
+		return yamlGet(r.value, "spec", "jobTemplate", "spec", "template", "spec")
+	}
+	return yamlGet(r.value, "spec", "template", "spec")
+}
+
+// podLabels returns the labels of the pods a resource runs
+func podLabels(r *manifestRes) map[string]string {
+	if r.kind == "Pod" {
+		return yamlMap(yamlGet(r.value, "metadata", "labels"))
+	}
+	return yamlMap(yamlGet(r.value, "spec", "template", "metadata", "labels"))
+}
+
+// selects adds edges to the pod owners of the namespace whose pod labels match the selector
+func (g *manifestGraph) selects(id string, r *manifestRes, selector map[string]string) {
+	if len(selector) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(selector))
+	for k, v := range selector {
+		keys = append(keys, k+"="+v)
+	}
+	sort.Strings(keys)
+	for _, other := range g.order {
+		o := g.resources[other]
+		if o.value == nil || o.namespace != r.namespace || !isPodOwner(o.kind) {
+			continue
+		}
+		labels := podLabels(o)
+		match := true
+		for k, v := range selector {
+			if labels[k] != v {
+				match = false
+				break
+			}
+		}
+		if match {
+			g.edge(id, other, *o, "selects", strings.Join(keys, ","))
+		}
+	}
+}
+
+func isPodOwner(kind string) bool {
+	for _, k := range podOwners {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls visit for every value of a mapping below n together with its key
+func (g *manifestGraph) walk(n *yaml.Node, visit func(key string, value *yaml.Node)) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			visit(n.Content[i].Value, n.Content[i+1])
+			g.walk(n.Content[i+1], visit)
+		}
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			g.walk(c, visit)
+		}
+	}
+}
+
+func (g *manifestGraph) lookup(str fmt.Stringer) (string, *manifestRes, error) {
+	if str == nil {
+		return "", nil, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(manifestNode)
+	if !ok {
+		return "", nil, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	r, ok := g.resources[string(n)]
+	if !ok {
+		return "", nil, fmt.Errorf("'%s' is not part of the manifests", n)
+	}
+	return string(n), r, nil
+}
+
+var _ walder.Graph = &manifestGraph{}
+
+func (g *manifestGraph) String() string {
+	return "manifests"
+}
+
+func (g *manifestGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return g.NodeAll()
+}
+
+var _ walder.NodeAller = &manifestGraph{}
+
+func (g *manifestGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(g.order))
+	for _, id := range g.order {
+		all = append(all, manifestNode(id))
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &manifestGraph{}
+
+func (g *manifestGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	id, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.from == id && !seen[e.to] {
+			seen[e.to] = true
+			out = append(out, manifestNode(e.to))
+		}
+	}
+	return out, nil
+}
+
+func (g *manifestGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	id, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.to == id && !seen[e.from] {
+			seen[e.from] = true
+			in = append(in, manifestNode(e.from))
+		}
+	}
+	return in, nil
+}
+
+var _ walder.EdgeLabeler = &manifestGraph{}
+
+// EdgeLabels returns the kinds of relationship with details like the mount target or the selector
+func (g *manifestGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, _, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	for _, e := range g.edges {
+		if e.from == f && e.to == t {
+			labels = append(labels, [2]string{e.relation, e.detail})
+		}
+	}
+	if labels == nil {
+		return nil, fmt.Errorf("'%s' has no relation to '%s'", f, t)
+	}
+	return labels, nil
+}
+
+var _ walder.Typer = &manifestGraph{}
+
+func (g *manifestGraph) GetType(str fmt.Stringer) (string, error) {
+	_, r, err := g.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	return r.kind, nil
+}
+
+var _ walder.NodeLabeler = &manifestGraph{}
+
+// NodeLabels returns the labels of the resource or service sorted by key
+func (g *manifestGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	_, r, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if r.value == nil {
+		return [][2]string{{"state", "not defined"}}, nil
+	}
+	var labels map[string]string
+	switch r.kind {
+	case composeService, composeVolume, composeNetwork, composeConfig, composeSecret:
+		labels = yamlMap(yamlGet(r.value, "labels"))
+	default:
+		labels = yamlMap(yamlGet(r.value, "metadata", "labels"))
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([][2]string, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, [2]string{k, labels[k]})
+	}
+	return list, nil
+}
+
+var _ walder.NodeReader = &manifestGraph{}
+
+// NodeRead returns the YAML of the resource or service
+func (g *manifestGraph) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	id, r, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if r.value == nil {
+		return nil, fmt.Errorf("'%s' is referenced but not defined", id)
+	}
+	b := &bytes.Buffer{}
+	if err := encodeData(dataYAML, b, r.value); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,265 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const composeSample = `services:
+  web:
+    image: nginx
+    depends_on:
+      db:
+        condition: service_healthy
+    links:
+      - cache:redis
+    volumes:
+      - ./site:/usr/share/nginx/html
+      - static:/static
+    networks: [front]
+    secrets:
+      - source: key
+        target: /run/key
+  db:
+    image: postgres
+    network_mode: service:cache
+  cache:
+    extends:
+      service: base
+      file: common.yaml
+volumes:
+  static: {}
+`
+
+const kubeSample = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels: {app: api, tier: back}
+    spec:
+      serviceAccountName: runner
+      containers:
+        - name: api
+          envFrom:
+            - configMapRef: {name: settings}
+          env:
+            - name: TOKEN
+              valueFrom:
+                secretKeyRef: {name: token, key: t}
+      volumes:
+        - name: data
+          persistentVolumeClaim: {claimName: data}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+  namespace: shop
+spec:
+  selector: {app: api}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: other
+spec:
+  selector: {app: api}
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: entry
+  namespace: shop
+spec:
+  rules:
+    - http:
+        paths:
+          - backend:
+              service:
+                name: api
+                port: {number: 80}
+---
+kind: ClusterRoleBinding
+metadata:
+  name: read
+roleRef: {kind: ClusterRole, name: reader}
+subjects:
+  - {kind: ServiceAccount, name: runner, namespace: shop}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: shop
+`
+
+func TestManifestRelations(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		node   string
+		kind   string
+		out    string
+		labels map[string]string
+	}{
+		{
+			name: "compose service", source: composeSample, node: "service/web", kind: composeService,
+			out: "network/front secret/key service/cache service/db volume/static",
+			labels: map[string]string{
+				"service/db":    "depends_on:service_healthy",
+				"service/cache": "links:redis",
+				"volume/static": "volume:/static",
+				"secret/key":    "secret:/run/key",
+			},
+		},
+		{
+			name: "compose network mode", source: composeSample, node: "service/db", kind: composeService,
+			out:    "service/cache",
+			labels: map[string]string{"service/cache": "network_mode:"},
+		},
+		{
+			name: "compose extends undefined", source: composeSample, node: "service/cache", kind: composeService,
+			out:    "service/base",
+			labels: map[string]string{"service/base": "extends:common.yaml"},
+		},
+		{
+			name: "deployment", source: kubeSample, node: "shop/Deployment/api", kind: "Deployment",
+			out: "shop/ConfigMap/settings shop/PersistentVolumeClaim/data shop/Secret/token shop/ServiceAccount/runner",
+			labels: map[string]string{
+				"shop/ConfigMap/settings":         "config:configMapRef",
+				"shop/Secret/token":               "secret:secretKeyRef",
+				"shop/PersistentVolumeClaim/data": "volume:persistentVolumeClaim",
+				"shop/ServiceAccount/runner":      "service account:",
+			},
+		},
+		{
+			name: "service selects in namespace", source: kubeSample, node: "shop/Service/api", kind: "Service",
+			out:    "shop/Deployment/api",
+			labels: map[string]string{"shop/Deployment/api": "selects:app=api"},
+		},
+		{
+			name: "service in other namespace", source: kubeSample, node: "Service/other", kind: "Service",
+		},
+		{
+			name: "ingress", source: kubeSample, node: "shop/Ingress/entry", kind: "Ingress",
+			out:    "shop/Service/api",
+			labels: map[string]string{"shop/Service/api": "routes:80"},
+		},
+		{
+			name: "cluster role binding", source: kubeSample, node: "ClusterRoleBinding/read", kind: "ClusterRoleBinding",
+			out: "ClusterRole/reader ServiceAccount/runner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ManifestDim{}.Open(strings.NewReader(tt.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := g.(*manifestGraph)
+			n := findNode(t, m, tt.node)
+			kind, err := m.GetType(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kind != tt.kind {
+				t.Errorf("want kind '%s', but got '%s'", tt.kind, kind)
+			}
+			out, err := m.Outgoing(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(out); got != tt.out {
+				t.Errorf("want outgoing '%s', but got '%s'", tt.out, got)
+			}
+			for to, want := range tt.labels {
+				labels, err := m.EdgeLabels(n, manifestNode(to))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := labels[0][0] + ":" + labels[0][1]; got != want {
+					t.Errorf("want label '%s' to '%s', but got '%s'", want, to, got)
+				}
+			}
+		})
+	}
+}
+
+func TestManifestUndefined(t *testing.T) {
+	g, err := ManifestDim{}.Open(strings.NewReader(composeSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := g.(*manifestGraph)
+	labels, err := m.NodeLabels(manifestNode("service/base"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := labelValue(labels, "state"); state != "not defined" {
+		t.Errorf("want undefined service, but got %v", labels)
+	}
+	if _, err := m.NodeRead(manifestNode("service/base")); err == nil {
+		t.Error("want error reading a undefined service, but got none")
+	}
+	if _, err := m.NodeRead(manifestNode("service/web")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestManifestDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"deploy.yaml":        "kind: Deployment\nmetadata: {name: api}\nspec:\n  template:\n    spec:\n      serviceAccountName: runner\n",
+		"nested/account.yml": "kind: ServiceAccount\nmetadata: {name: runner}\n",
+		".hidden/skip.yaml":  "kind: ConfigMap\nmetadata: {name: skip}\n",
+		"notes.txt":          "kind: ConfigMap\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, err := ManifestDim{}.NodeOpen(stringer(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := g.(*manifestGraph)
+	all, err := m.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sortedNames(all), "Deployment/api ServiceAccount/runner"; got != want {
+		t.Errorf("want nodes '%s', but got '%s'", want, got)
+	}
+	// the account is defined in a other file than the reference
+	labels, err := m.NodeLabels(manifestNode("ServiceAccount/runner"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := labelValue(labels, "state"); ok {
+		t.Errorf("want the definition of the other file, but got %v", labels)
+	}
+
+	// a compose file in the directory is used instead of the manifests
+	err = os.WriteFile(filepath.Join(dir, "compose.yaml"), []byte(composeSample), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err = ManifestDim{}.NodeOpen(stringer(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := g.(*manifestGraph).lookup(manifestNode("Deployment/api")); err == nil {
+		t.Error("want only the compose file to be read")
+	}
+}
@@ -0,0 +1,834 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// MermaidDim is a generator for the Mermaid flowchart Dimension
+type MermaidDim struct{}
+
+var _ walder.Dimensioner = MermaidDim{}
+
+func (d MermaidDim) String() string {
+	return "mermaid"
+}
+
+// New returns a new empty top down flowchart
+func (d MermaidDim) New() (walder.Graph, error) {
+	return newMermaidGraph("flowchart TD"), nil
+}
+
+var _ walder.OpenReader = MermaidDim{}
+
+// Open parses a mermaid 'flowchart' or 'graph' diagram
+func (d MermaidDim) Open(from io.Reader) (walder.Graph, error) {
+	all, err := io.ReadAll(from)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	if len(bytes.TrimSpace(all)) == 0 {
+		return d.New()
+	}
+	m := &mermaidGraph{}
+	err = m.parse(all)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	return m, nil
+}
+
+// mermaidShapes maps the opening bracket of a node definition to its closing counterpart and the shape name.
+// The order is important since the longer openings have to be tried first.
+var mermaidShapes = []struct {
+	open, close, name string
+}{
+	{"(((", ")))", "double circle"},
+	{"([", "])", "stadium"},
+	{"[[", "]]", "subroutine"},
+	{"[(", ")]", "cylinder"},
+	{"((", "))", "circle"},
+	{"{{", "}}", "hexagon"},
+	{"[/", "/]", "parallelogram"},
+	{"[\\", "\\]", "parallelogram alt"},
+	{"[/", "\\]", "trapezoid"},
+	{"[\\", "/]", "trapezoid alt"},
+	{"(", ")", "round"},
+	{"[", "]", "rectangle"},
+	{"{", "}", "rhombus"},
+	{">", "]", "asymmetric"},
+}
+
+func mermaidShape(name string) (open, close string, err error) {
+	for _, s := range mermaidShapes {
+		if s.name == name {
+			return s.open, s.close, nil
+		}
+	}
+	names := make([]string, 0, len(mermaidShapes))
+	for _, s := range mermaidShapes {
+		names = append(names, s.name)
+	}
+	return "", "", fmt.Errorf("unknown shape '%s' please use one of: %s", name, strings.Join(names, ", "))
+}
+
+var (
+	mermaidHeader = regexp.MustCompile(`^(graph|flowchart)(\s+(TB|TD|BT|RL|LR))?\s*;?$`)
+	mermaidID     = regexp.MustCompile(`^[A-Za-z0-9_]+(?:[-.][A-Za-z0-9_]+)*`)
+	mermaidClass  = regexp.MustCompile(`^:::([A-Za-z0-9_\-]+)`)
+	mermaidEntity = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+	// link with the text between pipes: A -->|text| B
+	mermaidPipeLink = regexp.MustCompile(`^(<?)(-{2,}|={2,}|-\.+-)([>ox]?)\s*\|([^|]*)\|`)
+	// link with the text in the middle: A -- text --> B
+	mermaidTextLink = regexp.MustCompile(`^(<?)(--|==|-\.)\s+(.+?)\s+(-{2,}|={2,}|\.+-)([>ox]?)`)
+	// link without text: A --> B, a link without head needs at least three dashes like A --- B
+	mermaidLink = regexp.MustCompile(`^<?(?:-{2,}[>ox]|-{3,}|={2,}[>ox]|={3,}|-\.+-[>ox]?)`)
+)
+
+type mermaidNode struct {
+	id   string
+	text string
+}
+
+func (n mermaidNode) String() string {
+	if n.text != "" {
+		return n.text
+	}
+	return n.id
+}
+
+type mermaidNodeData struct {
+	text    string
+	shape   string
+	classes []string
+	style   [][2]string
+}
+
+type mermaidEdge struct {
+	from, to string
+	arrow    string
+	text     string
+}
+
+type mermaidSubgraph struct {
+	id        string
+	title     string
+	direction string
+	parent    string
+	members   []string
+}
+
+type mermaidGraph struct {
+	header string
+	// directives are the comments before the header like '%%{init: {"theme": "dark"}}%%'
+	directives []string
+	order      []string
+	nodes      map[string]*mermaidNodeData
+	edges      []mermaidEdge
+	classDefs  [][2]string
+	subgraphs  []*mermaidSubgraph
+	// other holds the statements which are passed through like comments and clicks
+	other  []string
+	lastID int
+}
+
+func newMermaidGraph(header string) *mermaidGraph {
+	return &mermaidGraph{
+		header: header,
+		nodes:  make(map[string]*mermaidNodeData),
+	}
+}
+
+func (m *mermaidGraph) parse(content []byte) error {
+	m.nodes = make(map[string]*mermaidNodeData)
+
+	var open []*mermaidSubgraph
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	var lineNumber int
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "%%") {
+			if m.header == "" {
+				m.directives = append(m.directives, line)
+			} else {
+				m.other = append(m.other, line)
+			}
+			continue
+		}
+		if m.header == "" {
+			if !mermaidHeader.MatchString(line) {
+				return fmt.Errorf("line %d: want a flowchart or graph header, but got '%s'", lineNumber, line)
+			}
+			m.header = strings.TrimSuffix(line, ";")
+			continue
+		}
+		for _, statement := range splitMermaid(line) {
+			statement = strings.TrimSpace(statement)
+			if statement == "" {
+				continue
+			}
+			var current *mermaidSubgraph
+			if len(open) > 0 {
+				current = open[len(open)-1]
+			}
+			keyword, rest, _ := strings.Cut(statement, " ")
+			rest = strings.TrimSpace(rest)
+			switch keyword {
+			case "subgraph":
+				s := m.newSubgraph(rest)
+				if current != nil {
+					s.parent = current.id
+				}
+				m.subgraphs = append(m.subgraphs, s)
+				open = append(open, s)
+				continue
+			case "end":
+				if current == nil {
+					return fmt.Errorf("line %d: 'end' without 'subgraph'", lineNumber)
+				}
+				open = open[:len(open)-1]
+				continue
+			case "direction":
+				if current != nil {
+					current.direction = rest
+					continue
+				}
+			case "classDef":
+				name, style, _ := strings.Cut(rest, " ")
+				m.classDefs = append(m.classDefs, [2]string{name, strings.TrimSpace(style)})
+				continue
+			case "class":
+				ids, name, _ := strings.Cut(rest, " ")
+				for _, id := range strings.Split(ids, ",") {
+					m.addClass(strings.TrimSpace(id), strings.TrimSpace(name))
+				}
+				continue
+			case "style":
+				id, style, _ := strings.Cut(rest, " ")
+				m.ensure(id, current)
+				for _, prop := range strings.Split(style, ",") {
+					k, v, _ := strings.Cut(prop, ":")
+					m.setStyle(id, strings.TrimSpace(k), strings.TrimSpace(v))
+				}
+				continue
+			case "linkStyle", "click", "accTitle", "accDescr":
+				m.other = append(m.other, statement)
+				continue
+			}
+			err := m.parseChain(statement, current)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if m.header == "" {
+		return fmt.Errorf("no flowchart or graph header found")
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("subgraph '%s' is not closed", open[len(open)-1].id)
+	}
+	return nil
+}
+
+func (m *mermaidGraph) newSubgraph(definition string) *mermaidSubgraph {
+	s := &mermaidSubgraph{}
+	id, rest, _ := strings.Cut(definition, " ")
+	if i := strings.Index(id, "["); i > 0 {
+		id, rest = id[:i], definition[i:]
+	}
+	s.id = id
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "[") && strings.HasSuffix(rest, "]") {
+		s.title = unquoteMermaid(rest[1 : len(rest)-1])
+	}
+	if s.id == "" || strings.ContainsAny(s.id, "\"") {
+		s.id = fmt.Sprintf("subgraph%d", len(m.subgraphs)+1)
+		s.title = unquoteMermaid(definition)
+	}
+	return s
+}
+
+// parseChain parses statements like 'A[text] & B --> C -- label --> D'
+func (m *mermaidGraph) parseChain(statement string, sub *mermaidSubgraph) error {
+	rest := statement
+	var former []string
+	var arrow, text string
+	for {
+		group, r, err := m.parseGroup(rest, sub)
+		if err != nil {
+			return err
+		}
+		rest = strings.TrimSpace(r)
+		for _, f := range former {
+			for _, g := range group {
+				m.edges = append(m.edges, mermaidEdge{from: f, to: g, arrow: arrow, text: text})
+			}
+		}
+		if rest == "" {
+			return nil
+		}
+		arrow, text, rest, err = parseMermaidLink(rest)
+		if err != nil {
+			return err
+		}
+		rest = strings.TrimSpace(rest)
+		former = group
+	}
+}
+
+// parseGroup parses nodes joined by '&'
+func (m *mermaidGraph) parseGroup(statement string, sub *mermaidSubgraph) ([]string, string, error) {
+	var group []string
+	rest := statement
+	for {
+		id, r, err := m.parseNode(rest, sub)
+		if err != nil {
+			return nil, rest, err
+		}
+		group = append(group, id)
+		rest = strings.TrimSpace(r)
+		if !strings.HasPrefix(rest, "&") {
+			return group, rest, nil
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+}
+
+func (m *mermaidGraph) parseNode(statement string, sub *mermaidSubgraph) (string, string, error) {
+	id := mermaidID.FindString(statement)
+	if id == "" {
+		return "", statement, fmt.Errorf("want node id, but got '%s'", statement)
+	}
+	rest := statement[len(id):]
+	data := m.ensure(id, sub)
+
+	// several shapes share the same opening, thus the one which closes first wins
+	shape, end := -1, -1
+	for i, s := range mermaidShapes {
+		if !strings.HasPrefix(rest, s.open) {
+			continue
+		}
+		if shape >= 0 && len(s.open) < len(mermaidShapes[shape].open) {
+			break
+		}
+		inner := rest[len(s.open):]
+		var start int
+		if strings.HasPrefix(inner, "\"") {
+			closing := strings.Index(inner[1:], "\"")
+			if closing < 0 {
+				return "", rest, fmt.Errorf("unclosed quote in '%s'", statement)
+			}
+			start = closing + 2
+		}
+		closing := strings.Index(inner[start:], s.close)
+		if closing < 0 {
+			continue
+		}
+		if end < 0 || start+closing < end {
+			shape, end = i, start+closing
+		}
+	}
+	if shape >= 0 {
+		s := mermaidShapes[shape]
+		inner := rest[len(s.open):]
+		data.text = unquoteMermaid(inner[:end])
+		data.shape = s.name
+		rest = inner[end+len(s.close):]
+	}
+	if class := mermaidClass.FindStringSubmatch(rest); class != nil {
+		m.addClass(id, class[1])
+		rest = rest[len(class[0]):]
+	}
+	return id, rest, nil
+}
+
+func parseMermaidLink(statement string) (arrow, text, rest string, err error) {
+	if l := mermaidPipeLink.FindStringSubmatch(statement); l != nil {
+		return l[1] + l[2] + l[3], strings.TrimSpace(unquoteMermaid(l[4])), statement[len(l[0]):], nil
+	}
+	if l := mermaidTextLink.FindStringSubmatch(statement); l != nil {
+		var base string
+		switch l[2] {
+		case "==":
+			base = "=="
+		case "-.":
+			base = "-.-"
+		default:
+			base = "--"
+		}
+		head := l[5]
+		if head == "" && base != "-.-" {
+			base += base[:1]
+		}
+		return l[1] + base + head, strings.TrimSpace(unquoteMermaid(l[3])), statement[len(l[0]):], nil
+	}
+	if l := mermaidLink.FindStringSubmatch(statement); l != nil {
+		return l[0], "", statement[len(l[0]):], nil
+	}
+	return "", "", statement, fmt.Errorf("want link, but got '%s'", statement)
+}
+
+// splitMermaid splits a line into its statements at every ';'
+// which is neither quoted nor the end of an entity like '#quot;'
+func splitMermaid(line string) []string {
+	var statements []string
+	var quoted bool
+	entity := -1
+	start := 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '#':
+			entity = i
+		case r == ';' && !quoted:
+			if entity >= 0 && mermaidEntity.MatchString(line[entity+1:i]) {
+				break
+			}
+			statements = append(statements, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(statements, line[start:])
+}
+
+func unquoteMermaid(in string) string {
+	in = strings.TrimSpace(in)
+	if len(in) >= 2 && strings.HasPrefix(in, "\"") && strings.HasSuffix(in, "\"") {
+		in = in[1 : len(in)-1]
+	}
+	return strings.ReplaceAll(in, "#quot;", "\"")
+}
+
+// quoteMermaid quotes the text only if it contains characters which would end the text early
+func quoteMermaid(in string) string {
+	if !strings.ContainsAny(in, "()[]{}<>|;:#&/\\\"") && strings.TrimSpace(in) == in {
+		return in
+	}
+	return quote(strings.ReplaceAll(in, "\"", "#quot;"))
+}
+
+// ensure returns the data of the node with the given id and creates it if needed
+func (m *mermaidGraph) ensure(id string, sub *mermaidSubgraph) *mermaidNodeData {
+	data, ok := m.nodes[id]
+	if ok {
+		return data
+	}
+	data = &mermaidNodeData{}
+	m.nodes[id] = data
+	m.order = append(m.order, id)
+	if sub != nil {
+		sub.members = append(sub.members, id)
+	}
+	return data
+}
+
+func (m *mermaidGraph) addClass(id, class string) {
+	if id == "" || class == "" {
+		return
+	}
+	data := m.ensure(id, nil)
+	for _, c := range data.classes {
+		if c == class {
+			return
+		}
+	}
+	data.classes = append(data.classes, class)
+}
+
+func (m *mermaidGraph) setStyle(id, key, value string) {
+	if key == "" {
+		return
+	}
+	data := m.ensure(id, nil)
+	for i, kv := range data.style {
+		if kv[0] == key {
+			data.style[i][1] = value
+			return
+		}
+	}
+	data.style = append(data.style, [2]string{key, value})
+}
+
+func (m *mermaidGraph) isSubgraph(id string) bool {
+	for _, s := range m.subgraphs {
+		if s.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mermaidGraph) lookup(str fmt.Stringer) (mermaidNode, *mermaidNodeData, error) {
+	n, ok := str.(mermaidNode)
+	if !ok {
+		return n, nil, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	data, ok := m.nodes[n.id]
+	if !ok {
+		return n, nil, fmt.Errorf("'%s' is not part of this graph", n.id)
+	}
+	return mermaidNode{id: n.id, text: data.text}, data, nil
+}
+
+func (m *mermaidGraph) node(id string) mermaidNode {
+	var text string
+	if data, ok := m.nodes[id]; ok {
+		text = data.text
+	}
+	return mermaidNode{id: id, text: text}
+}
+
+var _ walder.Graph = &mermaidGraph{}
+
+func (m *mermaidGraph) String() string {
+	return m.header
+}
+
+func (m *mermaidGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return m.NodeAll()
+}
+
+var _ walder.NodeAller = &mermaidGraph{}
+
+func (m *mermaidGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(m.order))
+	for _, id := range m.order {
+		all = append(all, m.node(id))
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &mermaidGraph{}
+
+func (m *mermaidGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, _, err := m.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	for _, e := range m.edges {
+		if e.from == n.id {
+			out = append(out, m.node(e.to))
+		}
+	}
+	return out, nil
+}
+
+func (m *mermaidGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, _, err := m.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, e := range m.edges {
+		if e.to == n.id {
+			in = append(in, m.node(e.from))
+		}
+	}
+	return in, nil
+}
+
+var _ walder.GraphCreater = &mermaidGraph{}
+
+func (m *mermaidGraph) NodeCreate(input fmt.Stringer) (fmt.Stringer, error) {
+	if input == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	id := m.nextID()
+	data := m.ensure(id, nil)
+	data.text = input.String()
+	return m.node(id), nil
+}
+
+func (m *mermaidGraph) NodeUpdate(toUpdate fmt.Stringer) (fmt.Stringer, error) {
+	n, _, err := m.lookup(toUpdate)
+	return n, err
+}
+
+func (m *mermaidGraph) EdgeCreate(from, to fmt.Stringer) error {
+	f, _, err := m.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, _, err := m.lookup(to)
+	if err != nil {
+		return err
+	}
+	m.edges = append(m.edges, mermaidEdge{from: f.id, to: t.id, arrow: "-->"})
+	return nil
+}
+
+var _ walder.NodeDeleter = &mermaidGraph{}
+
+func (m *mermaidGraph) NodeDelete(toDelete fmt.Stringer) error {
+	n, _, err := m.lookup(toDelete)
+	if err != nil {
+		return err
+	}
+	delete(m.nodes, n.id)
+	m.order = removeString(m.order, n.id)
+	for _, s := range m.subgraphs {
+		s.members = removeString(s.members, n.id)
+	}
+	edges := make([]mermaidEdge, 0, len(m.edges))
+	for _, e := range m.edges {
+		if e.from == n.id || e.to == n.id {
+			continue
+		}
+		edges = append(edges, e)
+	}
+	m.edges = edges
+	return nil
+}
+
+var _ walder.EdgeDeleter = &mermaidGraph{}
+
+func (m *mermaidGraph) EdgeDelete(from, to fmt.Stringer) error {
+	f, _, err := m.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, _, err := m.lookup(to)
+	if err != nil {
+		return err
+	}
+	edges := make([]mermaidEdge, 0, len(m.edges))
+	for _, e := range m.edges {
+		if e.from == f.id && e.to == t.id {
+			continue
+		}
+		edges = append(edges, e)
+	}
+	m.edges = edges
+	return nil
+}
+
+var _ walder.NodeLabelAdder = &mermaidGraph{}
+
+// NodeLabels returns the text, shape and classes of a node followed by its style properties
+func (m *mermaidGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	n, data, err := m.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{{"id", n.id}}
+	if data.text != "" {
+		labels = append(labels, [2]string{"text", data.text})
+	}
+	if data.shape != "" {
+		labels = append(labels, [2]string{"shape", data.shape})
+	}
+	for _, c := range data.classes {
+		labels = append(labels, [2]string{"class", c})
+	}
+	return append(labels, data.style...), nil
+}
+
+// NodeLabelAdd sets the text or shape of a node or adds a class,
+// every other key is treated as style property.
+func (m *mermaidGraph) NodeLabelAdd(str fmt.Stringer, key, value string) (fmt.Stringer, error) {
+	n, data, err := m.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	switch key {
+	case "id":
+		return nil, fmt.Errorf("the id of a node can not be changed")
+	case "text", "label":
+		data.text = value
+	case "shape":
+		if _, _, err := mermaidShape(value); err != nil {
+			return nil, err
+		}
+		data.shape = value
+	case "class":
+		m.addClass(n.id, value)
+	default:
+		m.setStyle(n.id, key, value)
+	}
+	return m.node(n.id), nil
+}
+
+var _ walder.EdgeLabelAdder = &mermaidGraph{}
+
+func (m *mermaidGraph) edge(from, to fmt.Stringer) (*mermaidEdge, error) {
+	f, _, err := m.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := m.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range m.edges {
+		if e.from == f.id && e.to == t.id {
+			return &m.edges[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no edge found from '%s' to '%s'", f.id, t.id)
+}
+
+func (m *mermaidGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	e, err := m.edge(from, to)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{{"arrow", e.arrow}}
+	if e.text != "" {
+		labels = append(labels, [2]string{"text", e.text})
+	}
+	return labels, nil
+}
+
+// EdgeLabelAdd sets the text or the arrow of a edge
+func (m *mermaidGraph) EdgeLabelAdd(from, to fmt.Stringer, key, value string) error {
+	e, err := m.edge(from, to)
+	if err != nil {
+		return err
+	}
+	switch key {
+	case "text", "label":
+		e.text = value
+	case "arrow":
+		if l := mermaidLink.FindString(value); l == "" || l != value {
+			return fmt.Errorf("'%s' is not a valid arrow", value)
+		}
+		e.arrow = value
+	default:
+		return fmt.Errorf("unknown edge label '%s' please use one of: text, arrow", key)
+	}
+	return nil
+}
+
+var _ walder.GetReader = &mermaidGraph{}
+
+func (m *mermaidGraph) GetReader() (io.Reader, error) {
+	b := &bytes.Buffer{}
+	for _, d := range m.directives {
+		fmt.Fprintf(b, "%s\n", d)
+	}
+	b.WriteString(m.header)
+	b.WriteString("\n")
+
+	for _, c := range m.classDefs {
+		fmt.Fprintf(b, "    classDef %s %s\n", c[0], c[1])
+	}
+
+	members := make(map[string]bool)
+	for _, s := range m.subgraphs {
+		for _, id := range s.members {
+			members[id] = true
+		}
+	}
+	for _, id := range m.order {
+		if members[id] {
+			continue
+		}
+		m.writeNode(b, "    ", id)
+	}
+	for _, s := range m.subgraphs {
+		if s.parent != "" {
+			continue
+		}
+		m.writeSubgraph(b, "    ", s)
+	}
+	for _, e := range m.edges {
+		if e.text == "" {
+			fmt.Fprintf(b, "    %s %s %s\n", e.from, e.arrow, e.to)
+			continue
+		}
+		fmt.Fprintf(b, "    %s %s|%s| %s\n", e.from, e.arrow, quoteMermaid(e.text), e.to)
+	}
+	for _, id := range m.order {
+		data := m.nodes[id]
+		for _, c := range data.classes {
+			fmt.Fprintf(b, "    class %s %s\n", id, c)
+		}
+		if len(data.style) == 0 {
+			continue
+		}
+		props := make([]string, 0, len(data.style))
+		for _, kv := range data.style {
+			props = append(props, kv[0]+":"+kv[1])
+		}
+		fmt.Fprintf(b, "    style %s %s\n", id, strings.Join(props, ","))
+	}
+	for _, o := range m.other {
+		fmt.Fprintf(b, "    %s\n", o)
+	}
+	return b, nil
+}
+
+func (m *mermaidGraph) writeNode(b *bytes.Buffer, indent, id string) {
+	if m.isSubgraph(id) {
+		return
+	}
+	data := m.nodes[id]
+	if data.text == "" && data.shape == "" {
+		fmt.Fprintf(b, "%s%s\n", indent, id)
+		return
+	}
+	open, close, err := mermaidShape(data.shape)
+	if err != nil {
+		open, close = "[", "]"
+	}
+	fmt.Fprintf(b, "%s%s%s%s%s\n", indent, id, open, quoteMermaid(data.text), close)
+}
+
+func (m *mermaidGraph) writeSubgraph(b *bytes.Buffer, indent string, s *mermaidSubgraph) {
+	if s.title != "" {
+		fmt.Fprintf(b, "%ssubgraph %s [%s]\n", indent, s.id, quoteMermaid(s.title))
+	} else {
+		fmt.Fprintf(b, "%ssubgraph %s\n", indent, s.id)
+	}
+	inner := indent + "    "
+	if s.direction != "" {
+		fmt.Fprintf(b, "%sdirection %s\n", inner, s.direction)
+	}
+	for _, id := range s.members {
+		m.writeNode(b, inner, id)
+	}
+	for _, child := range m.subgraphs {
+		if child.parent == s.id {
+			m.writeSubgraph(b, inner, child)
+		}
+	}
+	fmt.Fprintf(b, "%send\n", indent)
+}
+
+func (m *mermaidGraph) nextID() string {
+	for {
+		m.lastID++
+		id := "n" + strconv.Itoa(m.lastID)
+		if _, ok := m.nodes[id]; !ok {
+			return id
+		}
+	}
+}
+
+func removeString(list []string, toRemove string) []string {
+	kept := list[:0]
+	for _, l := range list {
+		if l == toRemove {
+			continue
+		}
+		kept = append(kept, l)
+	}
+	return kept
+}
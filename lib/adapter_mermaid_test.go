@@ -0,0 +1,60 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMermaidRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"link", "flowchart LR\n    A --> B\n", "flowchart LR\n    A\n    B\n    A --> B\n"},
+		{"text link without space", "graph TD\n    A -- yes -->B\n", "graph TD\n    A\n    B\n    A -->|yes| B\n"},
+		{"open link", "graph TD\n    A --- B\n", "graph TD\n    A\n    B\n    A --- B\n"},
+		{"dotted text link", "graph TD\n    A -. maybe .-> B\n", "graph TD\n    A\n    B\n    A -.->|maybe| B\n"},
+		{"comments and directives",
+			"%%{init: {\"theme\": \"dark\"}}%%\nflowchart TD\n    %% a comment; with semicolon\n    A --> B\n",
+			"%%{init: {\"theme\": \"dark\"}}%%\nflowchart TD\n    A\n    B\n    A --> B\n    %% a comment; with semicolon\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g, err := MermaidDim{}.Open(strings.NewReader(test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readAll(t, g); got != test.want {
+				t.Errorf("want:\n%s\nbut got:\n%s", test.want, got)
+			}
+			all, err := g.(*mermaidGraph).NodeAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(all); got != "A B" {
+				t.Errorf("want nodes 'A B', but got '%s'", got)
+			}
+		})
+	}
+}
+
+func TestMermaidErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"no header", "A --> B\n"},
+		{"bare dashes", "graph TD\n    A -- B\n"},
+		{"unclosed subgraph", "graph TD\n    subgraph s\n    A\n"},
+		{"end without subgraph", "graph TD\n    end\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := MermaidDim{}.Open(strings.NewReader(test.source))
+			if err == nil {
+				t.Error("want error, but got nil")
+			}
+		})
+	}
+}
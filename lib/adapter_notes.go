@@ -0,0 +1,508 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/treilik/walder"
+	"gopkg.in/yaml.v3"
+)
+
+// NotesDim is a generator for a graph of markdown notes linked by wiki and markdown links
+type NotesDim struct{}
+
+var _ walder.Dimensioner = NotesDim{}
+
+func (d NotesDim) String() string {
+	return "notes"
+}
+
+func (d NotesDim) New() (walder.Graph, error) {
+	return nil, fmt.Errorf("notes need a directory, open one from the filesystem")
+}
+
+var _ walder.NodeOpener = NotesDim{}
+
+// NodeOpen opens the directory of the node as a collection of notes
+func (d NotesDim) NodeOpen(nodes ...fmt.Stringer) (walder.Graph, error) {
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("need exactly one node, got %d", len(nodes))
+	}
+	node := nodes[0]
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	path := node.String()
+	if p, ok := node.(walder.Pather); ok {
+		var err error
+		path, err = p.Path()
+		if err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", path)
+	}
+	return &notesGraph{dir: path}, nil
+}
+
+var (
+	wikiLink     = regexp.MustCompile(`\[\[([^\]|#]*)(#[^\]|]*)?(\|[^\]]*)?\]\]`)
+	markdownLink = regexp.MustCompile(`\[[^\]]*\]\(([^)\s]+)(\s+"[^"]*")?\)`)
+	inlineTag    = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+)
+
+const noteExt = ".md"
+
+// noteNode is a markdown file named by its path relative to the notes directory without extension
+type noteNode struct {
+	path string
+	name string
+}
+
+func (n noteNode) String() string {
+	return n.name
+}
+
+var _ walder.Pather = noteNode{}
+
+func (n noteNode) Path() (string, error) {
+	return n.path, nil
+}
+
+type notesGraph struct {
+	dir string
+}
+
+// note is the parsed content of a note file
+type note struct {
+	frontMatter *yaml.Node
+	// head is the front matter including its delimiters
+	head string
+	body string
+}
+
+func splitNote(content string) (note, error) {
+	var n note
+	n.body = content
+	for _, delim := range []string{"---\n", "---\r\n"} {
+		if !strings.HasPrefix(content, delim) {
+			continue
+		}
+		rest := content[len(delim):]
+		end := strings.Index(rest, "\n---")
+		if end < 0 {
+			break
+		}
+		closing := end + len("\n---")
+		if i := strings.IndexByte(rest[closing:], '\n'); i >= 0 {
+			closing += i + 1
+		} else {
+			closing = len(rest)
+		}
+		n.head = content[:len(delim)+closing]
+		n.body = rest[closing:]
+		fm := &yaml.Node{}
+		if err := yaml.Unmarshal([]byte(rest[:end]), fm); err != nil {
+			return n, fmt.Errorf("invalid front matter: %w", err)
+		}
+		if len(fm.Content) == 1 && fm.Content[0].Kind == yaml.MappingNode {
+			n.frontMatter = fm.Content[0]
+		}
+		break
+	}
+	return n, nil
+}
+
+func (g *notesGraph) node(path string) noteNode {
+	rel, err := filepath.Rel(g.dir, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	return noteNode{path: path, name: strings.TrimSuffix(filepath.ToSlash(rel), noteExt)}
+}
+
+func (g *notesGraph) lookup(str fmt.Stringer) (noteNode, error) {
+	if str == nil {
+		return noteNode{}, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(noteNode)
+	if !ok {
+		return n, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	if _, err := os.Stat(n.path); err != nil {
+		return n, err
+	}
+	return g.node(n.path), nil
+}
+
+func (g *notesGraph) read(n noteNode) (note, error) {
+	content, err := os.ReadFile(n.path)
+	if err != nil {
+		return note{}, err
+	}
+	return splitNote(string(content))
+}
+
+// notes returns all markdown files below the directory, hidden directories are skipped
+func (g *notesGraph) notes() ([]noteNode, error) {
+	var notes []noteNode
+	err := filepath.WalkDir(g.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != g.dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), noteExt) {
+			notes = append(notes, g.node(path))
+		}
+		return nil
+	})
+	return notes, err
+}
+
+// resolveWiki finds the note by its full name or else by its base name
+func resolveWiki(notes []noteNode, target string) (noteNode, bool) {
+	target = strings.TrimSuffix(strings.TrimSpace(target), noteExt)
+	for _, n := range notes {
+		if n.name == target {
+			return n, true
+		}
+	}
+	for _, n := range notes {
+		if filepath.Base(n.name) == target {
+			return n, true
+		}
+	}
+	return noteNode{}, false
+}
+
+// links returns the existing notes linked from the note, each only once
+func (g *notesGraph) links(n noteNode, notes []noteNode) ([]noteNode, error) {
+	content, err := g.read(n)
+	if err != nil {
+		return nil, err
+	}
+	var links []noteNode
+	seen := make(map[string]bool)
+	add := func(l noteNode) {
+		if !seen[l.path] {
+			seen[l.path] = true
+			links = append(links, l)
+		}
+	}
+	for _, m := range wikiLink.FindAllStringSubmatch(content.body, -1) {
+		if l, ok := resolveWiki(notes, m[1]); ok {
+			add(l)
+		}
+	}
+	for _, m := range markdownLink.FindAllStringSubmatch(content.body, -1) {
+		target := m[1]
+		if u, err := url.Parse(target); err != nil || u.Scheme != "" || u.Host != "" {
+			continue
+		}
+		target, _, _ = strings.Cut(target, "#")
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		if target == "" {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(n.path), filepath.FromSlash(target))
+		if !strings.HasSuffix(path, noteExt) {
+			path += noteExt
+		}
+		for _, other := range notes {
+			if other.path == path {
+				add(other)
+			}
+		}
+	}
+	return links, nil
+}
+
+var _ walder.Graph = &notesGraph{}
+
+func (g *notesGraph) String() string {
+	return fmt.Sprintf("notes: %s", filepath.Base(g.dir))
+}
+
+func (g *notesGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return g.NodeAll()
+}
+
+var _ walder.NodeAller = &notesGraph{}
+
+func (g *notesGraph) NodeAll() ([]fmt.Stringer, error) {
+	notes, err := g.notes()
+	if err != nil {
+		return nil, err
+	}
+	all := make([]fmt.Stringer, 0, len(notes))
+	for _, n := range notes {
+		all = append(all, n)
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &notesGraph{}
+
+// Outgoing returns the notes linked from the note
+func (g *notesGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := g.notes()
+	if err != nil {
+		return nil, err
+	}
+	links, err := g.links(n, notes)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]fmt.Stringer, 0, len(links))
+	for _, l := range links {
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+// Incoming returns the backlinks of the note
+func (g *notesGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := g.notes()
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, other := range notes {
+		links, err := g.links(other, notes)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range links {
+			if l.path == n.path {
+				in = append(in, other)
+				break
+			}
+		}
+	}
+	return in, nil
+}
+
+var _ walder.NodeLabeler = &notesGraph{}
+
+// NodeLabels returns the fields of the front matter
+func (g *notesGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	content, err := g.read(n)
+	if err != nil {
+		return nil, err
+	}
+	if content.frontMatter == nil {
+		return nil, nil
+	}
+	var labels [][2]string
+	fm := content.frontMatter.Content
+	for i := 0; i+1 < len(fm); i += 2 {
+		labels = append(labels, [2]string{fm[i].Value, frontMatterValue(fm[i+1])})
+	}
+	return labels, nil
+}
+
+func frontMatterValue(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(n.Content))
+		for _, c := range n.Content {
+			values = append(values, frontMatterValue(c))
+		}
+		return strings.Join(values, ", ")
+	case yaml.MappingNode:
+		b := &bytes.Buffer{}
+		if err := encodeData(dataJSON, b, n); err != nil {
+			return err.Error()
+		}
+		return strings.Join(strings.Fields(b.String()), " ")
+	}
+	return n.Value
+}
+
+var _ walder.Typer = &notesGraph{}
+
+// GetType returns the sorted tags of the front matter and the body, or "note" if there are none
+func (g *notesGraph) GetType(str fmt.Stringer) (string, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	content, err := g.read(n)
+	if err != nil {
+		return "", err
+	}
+	tags := make(map[string]bool)
+	if fm := content.frontMatter; fm != nil {
+		for i := 0; i+1 < len(fm.Content); i += 2 {
+			if key := fm.Content[i].Value; key != "tags" && key != "tag" {
+				continue
+			}
+			value := fm.Content[i+1]
+			if value.Kind == yaml.SequenceNode {
+				for _, t := range value.Content {
+					tags[strings.TrimPrefix(t.Value, "#")] = true
+				}
+				continue
+			}
+			for _, t := range strings.FieldsFunc(value.Value, func(r rune) bool { return r == ',' || r == ' ' }) {
+				tags[strings.TrimPrefix(t, "#")] = true
+			}
+		}
+	}
+	for _, m := range inlineTag.FindAllStringSubmatch(content.body, -1) {
+		tags[m[2]] = true
+	}
+	if len(tags) == 0 {
+		return "note", nil
+	}
+	sorted := make([]string, 0, len(tags))
+	for t := range tags {
+		sorted = append(sorted, t)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, " "), nil
+}
+
+var _ walder.NodeReader = &notesGraph{}
+
+// NodeRead returns the body of the note without its front matter
+func (g *notesGraph) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	content, err := g.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(content.body), nil
+}
+
+var _ walder.NodeWriter = &notesGraph{}
+
+func (g *notesGraph) NodeUpdate(toUpdate fmt.Stringer) (fmt.Stringer, error) {
+	return g.lookup(toUpdate)
+}
+
+// NodeWrite replaces the body of the note and keeps its front matter
+func (g *notesGraph) NodeWrite(str fmt.Stringer) (io.WriteCloser, error) {
+	n, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	content, err := g.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return &applyBuffer{apply: func(body []byte) error {
+		return os.WriteFile(n.path, append([]byte(content.head), body...), 0644)
+	}}, nil
+}
+
+var _ walder.GraphCreater = &notesGraph{}
+
+// NodeCreate creates a new note with the input as title in the notes directory
+func (g *notesGraph) NodeCreate(input fmt.Stringer) (fmt.Stringer, error) {
+	if input == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	name := strings.TrimSuffix(strings.TrimSpace(input.String()), noteExt)
+	if name == "" {
+		return nil, fmt.Errorf("note name can not be empty")
+	}
+	path := filepath.Join(g.dir, filepath.FromSlash(name)+noteExt)
+	// notes outside of the directory would not be part of the graph
+	rel, err := filepath.Rel(g.dir, path)
+	if err != nil {
+		return nil, err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("note '%s' would be outside of '%s'", name, g.dir)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(f, "# %s\n", filepath.Base(name)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return g.node(path), nil
+}
+
+// EdgeCreate appends a wiki link to the target at the end of the source note
+func (g *notesGraph) EdgeCreate(from, to fmt.Stringer) error {
+	f, err := g.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, fmt.Sprintf("[[%s]]\n", t.name)...)
+	return os.WriteFile(f.path, content, 0644)
+}
+
+var _ walder.NodeFromCreater = &notesGraph{}
+
+// NodeFromCreate creates a new note and links to it from all given notes
+func (g *notesGraph) NodeFromCreate(input fmt.Stringer, from ...fmt.Stringer) (fmt.Stringer, error) {
+	for _, f := range from {
+		if _, err := g.lookup(f); err != nil {
+			return nil, err
+		}
+	}
+	n, err := g.NodeCreate(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range from {
+		if err := g.EdgeCreate(f, n); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,75 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openNotes opens a temporary notes directory with the files
+func openNotes(t *testing.T, files map[string]string) (*notesGraph, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, err := NotesDim{}.NodeOpen(stringer(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g.(*notesGraph), dir
+}
+
+func TestNotesCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", input: "idea", want: "idea"},
+		{name: "extension", input: "idea.md", want: "idea"},
+		{name: "subdirectory", input: "sub/idea", want: "sub/idea"},
+		{name: "inside after cleaning", input: "sub/../idea", want: "idea"},
+		{name: "empty", input: "  ", wantErr: true},
+		{name: "existing", input: "home", wantErr: true},
+		{name: "parent", input: "../x", wantErr: true},
+		{name: "grandparent", input: "../../x", wantErr: true},
+		{name: "escaping subdirectory", input: "sub/../../x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, dir := openNotes(t, map[string]string{"home.md": "# home\n"})
+			n, err := g.NodeCreate(stringer(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, but created '%s'", n)
+				}
+				if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "x.md")); err == nil {
+					t.Errorf("note was written outside of the directory")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.String() != tt.want {
+				t.Errorf("want '%s', but got '%s'", tt.want, n)
+			}
+			all, err := g.NodeAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := sortedNames(all), sortedNames([]fmt.Stringer{stringer("home"), n}); got != want {
+				t.Errorf("want notes '%s', but got '%s'", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/treilik/walder"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIDim is a generator for the $ref graph of OpenAPI, Swagger and JSON Schema documents
+type OpenAPIDim struct{}
+
+var _ walder.Dimensioner = OpenAPIDim{}
+
+func (d OpenAPIDim) String() string {
+	return "openapi"
+}
+
+func (d OpenAPIDim) New() (walder.Graph, error) {
+	return d.Open(strings.NewReader("{}"))
+}
+
+var _ walder.OpenReader = OpenAPIDim{}
+
+func (d OpenAPIDim) Open(from io.Reader) (walder.Graph, error) {
+	all, err := io.ReadAll(from)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	format := dataYAML
+	if bytes.HasPrefix(bytes.TrimSpace(all), []byte("{")) {
+		format = dataJSON
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(all))
+	doc := &yaml.Node{}
+	if err := dec.Decode(doc); err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	if len(doc.Content) != 1 {
+		return nil, fmt.Errorf("want a single document, but got %d", len(doc.Content))
+	}
+	// references only point into the same document, so a stream of documents is refused
+	if err := dec.Decode(&yaml.Node{}); err != io.EOF {
+		return nil, fmt.Errorf("want a single document, but got more")
+	}
+	g := &refGraph{
+		format: format,
+		nodes:  make(map[string]*refNodeData),
+		edges:  make(map[string][]refEdge),
+	}
+	g.collect(doc.Content[0], nil, "")
+	g.link()
+	return g, nil
+}
+
+// refKinds names the kind of the named members of a components object
+var refKinds = map[string]string{
+	"schemas":         "schema",
+	"parameters":      "parameter",
+	"responses":       "response",
+	"requestBodies":   "requestBody",
+	"headers":         "header",
+	"examples":        "example",
+	"securitySchemes": "securityScheme",
+	"links":           "link",
+	"callbacks":       "callback",
+	"pathItems":       "pathItem",
+}
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+const (
+	refDocument  = "document"
+	refPath      = "path"
+	refOperation = "operation"
+	refSchema    = "schema"
+	refExternal  = "external"
+	refMissing   = "missing"
+)
+
+// refKind returns the kind of node the location defines or "" if it is only part of one
+func refKind(path []string) string {
+	switch l := len(path); {
+	case l == 0:
+		return refDocument
+	case l == 2 && path[0] == "paths":
+		return refPath
+	case l == 3 && path[0] == "paths":
+		for _, m := range httpMethods {
+			if path[2] == m {
+				return refOperation
+			}
+		}
+	case l == 3 && path[0] == "components":
+		if kind, ok := refKinds[path[1]]; ok {
+			return kind
+		}
+		return path[1]
+	case l == 2 && (path[0] == "parameters" || path[0] == "responses"):
+		// swagger 2.0 keeps them at the top level
+		return refKinds[path[0]]
+	}
+	if l := len(path); l >= 2 && (path[l-2] == "$defs" || path[l-2] == "definitions") {
+		return refSchema
+	}
+	return ""
+}
+
+// refNode is a definition identified by its JSON pointer
+type refNode struct {
+	pointer string
+	name    string
+}
+
+func (n refNode) String() string {
+	return n.name
+}
+
+type refNodeData struct {
+	kind  string
+	value *yaml.Node
+	// refs holds the targets of all $ref and where they occur
+	refs []refEdge
+}
+
+type refEdge struct {
+	to string
+	at string
+}
+
+type refGraph struct {
+	format dataFormat
+	order  []string
+	nodes  map[string]*refNodeData
+	edges  map[string][]refEdge
+}
+
+func refPointer(path []string) string {
+	if len(path) == 0 {
+		return "#"
+	}
+	escaped := make([]string, 0, len(path))
+	for _, p := range path {
+		p = strings.ReplaceAll(p, "~", "~0")
+		escaped = append(escaped, strings.ReplaceAll(p, "/", "~1"))
+	}
+	return "#/" + strings.Join(escaped, "/")
+}
+
+func refName(kind string, path []string) string {
+	switch kind {
+	case refDocument:
+		return "#"
+	case refPath:
+		return path[1]
+	case refOperation:
+		return fmt.Sprintf("%s %s", strings.ToUpper(path[2]), path[1])
+	}
+	return path[len(path)-1]
+}
+
+// collect walks the document, creating a node for every definition
+// and assigning every $ref to the innermost definition containing it
+func (g *refGraph) collect(n *yaml.Node, path []string, owner string) {
+	if kind := refKind(path); kind != "" && n.Kind == yaml.MappingNode {
+		pointer := refPointer(path)
+		g.nodes[pointer] = &refNodeData{kind: kind, value: n}
+		g.order = append(g.order, pointer)
+		if kind == refOperation {
+			g.edges[owner] = append(g.edges[owner], refEdge{to: pointer, at: refPointer(path)})
+		}
+		owner = pointer
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i].Value, n.Content[i+1]
+			if key == "$ref" && value.Kind == yaml.ScalarNode {
+				if data, ok := g.nodes[owner]; ok {
+					data.refs = append(data.refs, refEdge{to: value.Value, at: refPointer(path)})
+				}
+				continue
+			}
+			g.collect(value, append(path[:len(path):len(path)], key), owner)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			g.collect(c, append(path[:len(path):len(path)], fmt.Sprint(i)), owner)
+		}
+	}
+}
+
+// link resolves the collected references to the definitions containing there targets
+func (g *refGraph) link() {
+	for _, from := range g.order {
+		for _, ref := range g.nodes[from].refs {
+			g.edges[from] = append(g.edges[from], refEdge{to: g.resolve(ref.to), at: ref.at})
+		}
+	}
+}
+
+// resolve returns the innermost definition holding the target, unknown targets get a node of there own
+func (g *refGraph) resolve(ref string) string {
+	if !strings.HasPrefix(ref, "#") {
+		if _, ok := g.nodes[ref]; !ok {
+			g.nodes[ref] = &refNodeData{kind: refExternal}
+			g.order = append(g.order, ref)
+		}
+		return ref
+	}
+	pointer := strings.TrimSuffix(ref, "/")
+	if unescaped, err := url.PathUnescape(pointer); err == nil {
+		pointer = unescaped
+	}
+	// a reference into a definition points to the definition, but not to the whole document
+	for p := pointer; ; {
+		if data, ok := g.nodes[p]; ok && (p == pointer || data.kind != refDocument) {
+			return p
+		}
+		i := strings.LastIndex(p, "/")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	if _, ok := g.nodes[pointer]; !ok {
+		g.nodes[pointer] = &refNodeData{kind: refMissing}
+		g.order = append(g.order, pointer)
+	}
+	return pointer
+}
+
+func (g *refGraph) node(pointer string) refNode {
+	data := g.nodes[pointer]
+	switch data.kind {
+	case refExternal, refMissing:
+		return refNode{pointer: pointer, name: pointer}
+	}
+	var path []string
+	if pointer != "#" {
+		for _, p := range strings.Split(strings.TrimPrefix(pointer, "#/"), "/") {
+			p = strings.ReplaceAll(p, "~1", "/")
+			path = append(path, strings.ReplaceAll(p, "~0", "~"))
+		}
+	}
+	return refNode{pointer: pointer, name: refName(data.kind, path)}
+}
+
+func (g *refGraph) lookup(str fmt.Stringer) (refNode, *refNodeData, error) {
+	if str == nil {
+		return refNode{}, nil, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(refNode)
+	if !ok {
+		return n, nil, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	data, ok := g.nodes[n.pointer]
+	if !ok {
+		return n, nil, fmt.Errorf("'%s' is not part of this document", n.pointer)
+	}
+	return n, data, nil
+}
+
+var _ walder.Graph = &refGraph{}
+
+func (g *refGraph) String() string {
+	return "openapi"
+}
+
+func (g *refGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return g.NodeAll()
+}
+
+var _ walder.NodeAller = &refGraph{}
+
+func (g *refGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(g.order))
+	for _, p := range g.order {
+		all = append(all, g.node(p))
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &refGraph{}
+
+// Outgoing returns the referenced definitions and for paths there operations
+func (g *refGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges[n.pointer] {
+		if seen[e.to] {
+			continue
+		}
+		seen[e.to] = true
+		out = append(out, g.node(e.to))
+	}
+	return out, nil
+}
+
+// Incoming returns the definitions referencing the node
+func (g *refGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, from := range g.order {
+		for _, e := range g.edges[from] {
+			if e.to == n.pointer {
+				in = append(in, g.node(from))
+				break
+			}
+		}
+	}
+	return in, nil
+}
+
+var _ walder.Typer = &refGraph{}
+
+func (g *refGraph) GetType(str fmt.Stringer) (string, error) {
+	_, data, err := g.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	return data.kind, nil
+}
+
+var _ walder.EdgeLabeler = &refGraph{}
+
+// EdgeLabels returns the locations of the references
+func (g *refGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, _, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	for _, e := range g.edges[f.pointer] {
+		if e.to == t.pointer {
+			labels = append(labels, [2]string{"at", e.at})
+		}
+	}
+	if labels == nil {
+		return nil, fmt.Errorf("'%s' does not reference '%s'", f.String(), t.String())
+	}
+	return labels, nil
+}
+
+var _ walder.NodeLabeler = &refGraph{}
+
+func (g *refGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	n, data, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{{"pointer", n.pointer}, {"kind", data.kind}}
+	if data.value == nil {
+		return labels, nil
+	}
+	for i := 0; i+1 < len(data.value.Content); i += 2 {
+		switch key, value := data.value.Content[i].Value, data.value.Content[i+1]; key {
+		case "operationId", "summary", "title", "type", "description":
+			if value.Kind == yaml.ScalarNode {
+				labels = append(labels, [2]string{key, value.Value})
+			}
+		}
+	}
+	return labels, nil
+}
+
+var _ walder.NodeReader = &refGraph{}
+
+// NodeRead returns the definition in the format of the document
+func (g *refGraph) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	n, data, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if data.value == nil {
+		return nil, fmt.Errorf("'%s' is not defined in this document", n.pointer)
+	}
+	b := &bytes.Buffer{}
+	if err := encodeData(g.format, b, data.value); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,154 @@
+package lib
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/treilik/walder"
+)
+
+const openAPISample = `openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          $ref: '#/components/responses/Pets'
+components:
+  responses:
+    Pets:
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Pet/properties/tags'
+  schemas:
+    Pet:
+      type: object
+      properties:
+        tags:
+          type: array
+        owner:
+          $ref: 'owner.yaml'
+    Any~/thing:
+      $ref: '#/components/schemas/Any~0~1thing'
+    Lost:
+      $ref: '#/components/schemas/Gone'
+`
+
+func TestOpenAPIReferences(t *testing.T) {
+	g, err := OpenAPIDim{}.Open(strings.NewReader(openAPISample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := g.(*refGraph)
+	tests := []struct {
+		node string
+		kind string
+		out  string
+		in   string
+	}{
+		{node: "#", kind: refDocument, out: "", in: ""},
+		{node: "/pets", kind: refPath, out: "GET /pets", in: ""},
+		{node: "GET /pets", kind: refOperation, out: "Pets", in: "/pets"},
+		{node: "Pets", kind: "response", out: "Pet", in: "GET /pets"},
+		{node: "Pet", kind: refSchema, out: "owner.yaml", in: "Pets"},
+		{node: "owner.yaml", kind: refExternal, out: "", in: "Pet"},
+		{node: "Any~/thing", kind: refSchema, out: "Any~/thing", in: "Any~/thing"},
+		{node: "Lost", kind: refSchema, out: "#/components/schemas/Gone", in: ""},
+		{node: "#/components/schemas/Gone", kind: refMissing, out: "", in: "Lost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.node, func(t *testing.T) {
+			n := findNode(t, r, tt.node)
+			kind, err := r.GetType(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kind != tt.kind {
+				t.Errorf("want kind '%s', but got '%s'", tt.kind, kind)
+			}
+			out, err := r.Outgoing(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(out); got != tt.out {
+				t.Errorf("want outgoing '%s', but got '%s'", tt.out, got)
+			}
+			in, err := r.Incoming(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(in); got != tt.in {
+				t.Errorf("want incoming '%s', but got '%s'", tt.in, got)
+			}
+		})
+	}
+
+	labels, err := r.EdgeLabels(findNode(t, r, "Pets"), findNode(t, r, "Pet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at, _ := labelValue(labels, "at"); at != "#/components/responses/Pets/content/application~1json/schema" {
+		t.Errorf("want the location of the reference, but got '%s'", at)
+	}
+	labels, err = r.NodeLabels(findNode(t, r, "GET /pets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := labelValue(labels, "operationId"); id != "listPets" {
+		t.Errorf("want operationId 'listPets', but got '%s'", id)
+	}
+}
+
+func TestOpenAPIRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		node   string
+		want   string
+	}{
+		{"yaml", "definitions:\n  A:\n    type: string\n", "A", "type: string\n"},
+		{"json", `{"$defs": {"A": {"type": "string"}}}`, "A", "{\n  \"type\": \"string\"\n}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := OpenAPIDim{}.Open(strings.NewReader(tt.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := findNode(t, g.(walder.NodeAller), tt.node)
+			reader, err := g.(walder.NodeReader).NodeRead(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("want:\n%s\nbut got:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOpenAPIErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"documents", "a: 1\n---\nb: 2\n"},
+		{"invalid", "a: [1\n"},
+		{"empty", ""},
+		{"invalid second document", "a: 1\n---\nb: [1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (OpenAPIDim{}).Open(strings.NewReader(tt.source)); err == nil {
+				t.Error("want error, but got none")
+			}
+		})
+	}
+}
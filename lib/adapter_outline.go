@@ -0,0 +1,401 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// OutlineDim is a generator for the heading hierarchy of a markdown document
+type OutlineDim struct{}
+
+var _ walder.Dimensioner = OutlineDim{}
+
+func (d OutlineDim) String() string {
+	return "markdown outline"
+}
+
+func (d OutlineDim) New() (walder.Graph, error) {
+	return newOutline(), nil
+}
+
+var _ walder.OpenReader = OutlineDim{}
+
+func (d OutlineDim) Open(from io.Reader) (walder.Graph, error) {
+	o := newOutline()
+	if err := o.parse(from); err != nil {
+		return nil, fmt.Errorf("error while opening from Reader: %w", err)
+	}
+	return o, nil
+}
+
+var (
+	atxHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextHeading = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	codeFence     = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+)
+
+// section is a heading with the text up to the next heading, the root holds the text before the first heading.
+// The level is the one written in the document, for the root it is one less then the top most headings.
+type section struct {
+	title    string
+	level    int
+	setext   bool
+	body     string
+	parent   *section
+	children []*section
+}
+
+type outlineNode struct {
+	s *section
+}
+
+func (n outlineNode) String() string {
+	if n.s.parent == nil {
+		return "document"
+	}
+	return n.s.title
+}
+
+type outline struct {
+	root *section
+}
+
+func newOutline() *outline {
+	return &outline{root: &section{}}
+}
+
+// parse builds the tree, deeper headings become children of the last heading with a lower level
+func (o *outline) parse(from io.Reader) error {
+	type open struct {
+		s     *section
+		level int
+	}
+	stack := []open{{s: o.root}}
+	cur := o.root
+	var body []string
+	flush := func() {
+		cur.body = strings.Join(body, "")
+		body = nil
+	}
+	push := func(title string, level int, setext bool) {
+		flush()
+		for len(stack) > 1 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].s
+		s := &section{title: title, level: level, setext: setext, parent: parent}
+		parent.children = append(parent.children, s)
+		stack = append(stack, open{s: s, level: level})
+		cur = s
+		if parent == o.root && (len(o.root.children) == 1 || level <= o.root.level) {
+			o.root.level = level - 1
+		}
+	}
+
+	r := bufio.NewReader(from)
+	var fence string
+	for {
+		line, err := r.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		text := strings.TrimRight(line, "\r\n")
+		if m := codeFence.FindStringSubmatch(text); m != nil {
+			switch {
+			case fence == "":
+				fence = m[1]
+			case strings.HasPrefix(m[1], fence) && strings.TrimSpace(strings.TrimSpace(text)[len(m[1]):]) == "":
+				fence = ""
+			}
+		}
+		if fence != "" {
+			body = append(body, line)
+			continue
+		}
+		if m := atxHeading.FindStringSubmatch(text); m != nil {
+			push(m[2], len(m[1]), false)
+			continue
+		}
+		// a underline turns the previous paragraph line into a heading
+		if m := setextHeading.FindStringSubmatch(text); m != nil && len(body) > 0 {
+			prev := strings.TrimRight(body[len(body)-1], "\r\n")
+			if strings.TrimSpace(prev) != "" && (len(body) == 1 || strings.TrimSpace(body[len(body)-2]) == "") {
+				body = body[:len(body)-1]
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				push(strings.TrimSpace(prev), level, true)
+				continue
+			}
+		}
+		body = append(body, line)
+	}
+	flush()
+	return nil
+}
+
+func (o *outline) lookup(str fmt.Stringer) (*section, error) {
+	if str == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(outlineNode)
+	if !ok {
+		return nil, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	for s := n.s; s != nil; s = s.parent {
+		if s == o.root {
+			return n.s, nil
+		}
+	}
+	return nil, fmt.Errorf("'%s' is not part of this document", n.String())
+}
+
+// within reports if s is the ancestor or below it
+func (s *section) within(ancestor *section) bool {
+	for ; s != nil; s = s.parent {
+		if s == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *section) index() int {
+	for i, c := range s.parent.children {
+		if c == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// shift changes the level of the section and its subsections by the offset
+func (s *section) shift(offset int) {
+	s.level += offset
+	for _, c := range s.children {
+		c.shift(offset)
+	}
+}
+
+func (s *section) nodes() []fmt.Stringer {
+	nodes := make([]fmt.Stringer, 0, len(s.children))
+	for _, c := range s.children {
+		nodes = append(nodes, outlineNode{c})
+	}
+	return nodes
+}
+
+var _ walder.Graph = &outline{}
+
+func (o *outline) String() string {
+	return "markdown outline"
+}
+
+func (o *outline) HomeNodes() ([]fmt.Stringer, error) {
+	return []fmt.Stringer{outlineNode{o.root}}, nil
+}
+
+var _ walder.NodeAller = &outline{}
+
+func (o *outline) NodeAll() ([]fmt.Stringer, error) {
+	var all []fmt.Stringer
+	var walk func(s *section)
+	walk = func(s *section) {
+		all = append(all, outlineNode{s})
+		for _, c := range s.children {
+			walk(c)
+		}
+	}
+	walk(o.root)
+	return all, nil
+}
+
+var _ walder.GraphDirectedTree = &outline{}
+
+func (o *outline) Parent(str fmt.Stringer) (fmt.Stringer, error) {
+	s, err := o.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if s.parent == nil {
+		return nil, fmt.Errorf("the document has no parent")
+	}
+	return outlineNode{s.parent}, nil
+}
+
+func (o *outline) Children(str fmt.Stringer) ([]fmt.Stringer, error) {
+	s, err := o.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	return s.nodes(), nil
+}
+
+var _ walder.GraphDirected = &outline{}
+
+func (o *outline) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	return o.Children(str)
+}
+
+func (o *outline) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	s, err := o.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if s.parent == nil {
+		return nil, nil
+	}
+	return []fmt.Stringer{outlineNode{s.parent}}, nil
+}
+
+var _ walder.NodeLabeler = &outline{}
+
+func (o *outline) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	s, err := o.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{{"sections", fmt.Sprint(len(s.children))}}
+	if s.parent == nil {
+		return labels, nil
+	}
+	return append(labels, [2]string{"level", fmt.Sprint(s.level)}), nil
+}
+
+var _ walder.NodeReader = &outline{}
+
+// NodeRead returns the text between the heading and the next heading
+func (o *outline) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	s, err := o.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(s.body), nil
+}
+
+var _ walder.NodeWriter = &outline{}
+
+func (o *outline) NodeUpdate(toUpdate fmt.Stringer) (fmt.Stringer, error) {
+	s, err := o.lookup(toUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return outlineNode{s}, nil
+}
+
+// NodeWrite replaces the text of the section, the subsections are kept
+func (o *outline) NodeWrite(str fmt.Stringer) (io.WriteCloser, error) {
+	s, err := o.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	return &applyBuffer{apply: func(content []byte) error {
+		s.body = string(content)
+		return nil
+	}}, nil
+}
+
+var _ walder.EdgeMover = &outline{}
+
+// EdgeMove makes the section the last subsection of to, it gets the level below to and its subsections are shifted alike
+func (o *outline) EdgeMove(toMove, from, to fmt.Stringer) error {
+	m, err := o.lookup(toMove)
+	if err != nil {
+		return err
+	}
+	f, err := o.lookup(from)
+	if err != nil {
+		return err
+	}
+	t, err := o.lookup(to)
+	if err != nil {
+		return err
+	}
+	if m.parent != f {
+		return fmt.Errorf("'%s' is no subsection of '%s'", toMove.String(), from.String())
+	}
+	if t.within(m) {
+		return fmt.Errorf("cant move subtree into it self")
+	}
+	f.children = append(f.children[:m.index()], f.children[m.index()+1:]...)
+	m.parent = t
+	t.children = append(t.children, m)
+	m.shift(t.level + 1 - m.level)
+	return nil
+}
+
+var _ walder.NodeSwaper = &outline{}
+
+// NodeSwap swaps the positions of two sections together with there subsections,
+// each takes the level of the other so the levels around them stay the same
+func (o *outline) NodeSwap(first, second fmt.Stringer) error {
+	a, err := o.lookup(first)
+	if err != nil {
+		return err
+	}
+	b, err := o.lookup(second)
+	if err != nil {
+		return err
+	}
+	if a.parent == nil || b.parent == nil {
+		return fmt.Errorf("the document can not be swapped")
+	}
+	if a.within(b) || b.within(a) {
+		return fmt.Errorf("cant swap a section with one of its subsections")
+	}
+	ai, bi := a.index(), b.index()
+	a.parent.children[ai], b.parent.children[bi] = b, a
+	a.parent, b.parent = b.parent, a.parent
+	offset := b.level - a.level
+	a.shift(offset)
+	b.shift(-offset)
+	return nil
+}
+
+var _ walder.GetReader = &outline{}
+
+// GetReader writes the document with the heading levels as they were read or set by the changes
+func (o *outline) GetReader() (io.Reader, error) {
+	b := &bytes.Buffer{}
+	if err := o.write(b, o.root); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (o *outline) write(b *bytes.Buffer, s *section) error {
+	if s != o.root {
+		switch level := s.level; {
+		case level > 6:
+			return fmt.Errorf("'%s' would need heading level %d, but markdown only has 6", s.title, level)
+		case s.setext && level <= 2:
+			underline := "="
+			if level == 2 {
+				underline = "-"
+			}
+			fmt.Fprintf(b, "%s\n%s\n", s.title, strings.Repeat(underline, len([]rune(s.title))))
+		default:
+			fmt.Fprintf(b, "%s %s\n", strings.Repeat("#", level), s.title)
+		}
+	}
+	b.WriteString(s.body)
+	if s.body != "" && !strings.HasSuffix(s.body, "\n") {
+		b.WriteString("\n")
+	}
+	for _, c := range s.children {
+		if err := o.write(b, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
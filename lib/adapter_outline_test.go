@@ -0,0 +1,229 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// openOutline returns the outline of the markdown or fails the test
+func openOutline(t *testing.T, source string) *outline {
+	t.Helper()
+	g, err := OutlineDim{}.Open(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g.(*outline)
+}
+
+func TestOutlineRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"atx", "intro\n# a\ntext\n## b\n# c\n"},
+		{"setext", "a\n=\ntext\n\nb\n-\n"},
+		{"code fence", "# a\n```\n# no heading\n```\n"},
+		{"second level base", "## a\n### b\n## c\n"},
+		{"skipped levels", "# A\n### B\ntext\n"},
+		{"deeper first heading", "## A\n# B\n"},
+		{"deeper first with subsections", "### A\n#### a\n# B\n## b\n"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := openOutline(t, tt.source)
+			if got := readAll(t, o); got != tt.source {
+				t.Errorf("want:\n%s\nbut got:\n%s", tt.source, got)
+			}
+		})
+	}
+}
+
+func TestOutlineTree(t *testing.T) {
+	o := openOutline(t, "# a\n## b\n```\n# c\n```\n### d\n# e\n")
+	tests := []struct {
+		section  string
+		children string
+		level    string
+	}{
+		{"document", "a e", ""},
+		{"a", "b", "1"},
+		{"b", "d", "2"},
+		{"d", "", "3"},
+		{"e", "", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.section, func(t *testing.T) {
+			n := findNode(t, o, tt.section)
+			children, err := o.Children(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(children); got != tt.children {
+				t.Errorf("want children '%s', but got '%s'", tt.children, got)
+			}
+			labels, err := o.NodeLabels(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := labelValue(labels, "level"); got != tt.level {
+				t.Errorf("want level '%s', but got '%s'", tt.level, got)
+			}
+		})
+	}
+}
+
+func TestOutlineRestructure(t *testing.T) {
+	const source = "# a\n## b\n# c\n"
+	tests := []struct {
+		name    string
+		change  func(o *outline, s func(string) fmt.Stringer) error
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "move",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.EdgeMove(s("b"), s("a"), s("c"))
+			},
+			want: "# a\n# c\n## b\n",
+		},
+		{
+			name: "move up",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.EdgeMove(s("b"), s("a"), s("document"))
+			},
+			want: "# a\n# c\n# b\n",
+		},
+		{
+			name: "move into it self",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.EdgeMove(s("a"), s("document"), s("b"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "move from wrong parent",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.EdgeMove(s("b"), s("c"), s("document"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "swap",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.NodeSwap(s("a"), s("c"))
+			},
+			want: "# c\n# a\n## b\n",
+		},
+		{
+			name: "swap levels",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.NodeSwap(s("b"), s("c"))
+			},
+			want: "# a\n## c\n# b\n",
+		},
+		{
+			name: "swap with subsection",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.NodeSwap(s("a"), s("b"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "swap document",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.NodeSwap(s("document"), s("c"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := openOutline(t, source)
+			s := func(title string) fmt.Stringer { return findNode(t, o, title) }
+			err := tt.change(o, s)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error, but got none")
+				}
+				if got := readAll(t, o); got != source {
+					t.Errorf("failed change modified the outline:\n%s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readAll(t, o); got != tt.want {
+				t.Errorf("want:\n%s\nbut got:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOutlineLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		change func(o *outline, s func(string) fmt.Stringer) error
+		want   string
+	}{
+		{
+			name:   "move keeps skipped levels below",
+			source: "# a\n### b\n#### c\n# d\n",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.EdgeMove(s("b"), s("a"), s("d"))
+			},
+			want: "# a\n# d\n## b\n### c\n",
+		},
+		{
+			name:   "move into the document",
+			source: "## a\n# b\n## c\n### d\n",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.EdgeMove(s("c"), s("b"), s("document"))
+			},
+			want: "## a\n# b\n# c\n## d\n",
+		},
+		{
+			name:   "swap takes the level of the other",
+			source: "# a\n### b\n#### c\n# d\n",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.NodeSwap(s("b"), s("d"))
+			},
+			want: "# a\n### d\n# b\n## c\n",
+		},
+		{
+			name:   "unchanged sections keep there level",
+			source: "# a\n### b\n# c\n## d\n",
+			change: func(o *outline, s func(string) fmt.Stringer) error {
+				return o.EdgeMove(s("d"), s("c"), s("a"))
+			},
+			want: "# a\n### b\n## d\n# c\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := openOutline(t, tt.source)
+			s := func(title string) fmt.Stringer { return findNode(t, o, title) }
+			if err := tt.change(o, s); err != nil {
+				t.Fatal(err)
+			}
+			if got := readAll(t, o); got != tt.want {
+				t.Errorf("want:\n%s\nbut got:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOutlineTooDeep(t *testing.T) {
+	o := openOutline(t, "# 1\n## 2\n### 3\n#### 4\n##### 5\n###### 6\n# x\n")
+	s := func(title string) fmt.Stringer { return findNode(t, o, title) }
+	if err := o.EdgeMove(s("x"), s("document"), s("6")); err != nil {
+		t.Fatal(err)
+	}
+	if g, err := o.GetReader(); err == nil {
+		t.Errorf("want error for heading level 7, but got %v", g)
+	}
+}
@@ -0,0 +1,605 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/treilik/walder"
+)
+
+// ProcDim is a generator for the process tree read from /proc.
+// ExecuteSignal is send by Execute after a confirmation, without it Execute refuses
+// until a signal is chosen as dimension of the graph.
+// DeleteSignal is send by NodeDelete and defaults to SIGTERM.
+type ProcDim struct {
+	Root          string
+	ExecuteSignal syscall.Signal
+	DeleteSignal  syscall.Signal
+}
+
+var _ walder.Dimensioner = ProcDim{}
+
+func (d ProcDim) String() string {
+	return "processes"
+}
+
+// New returns the process tree, /proc is read on every call so the views refresh
+// with every update of the ui like the lists do
+func (d ProcDim) New() (walder.Graph, error) {
+	p := &procGraph{
+		root:          d.Root,
+		executeSignal: d.ExecuteSignal,
+		deleteSignal:  d.DeleteSignal,
+	}
+	if p.root == "" {
+		p.root = "/proc"
+	}
+	if p.deleteSignal == 0 {
+		p.deleteSignal = syscall.SIGTERM
+	}
+	if _, err := os.Stat(p.root); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// clockTicks is the USER_HZ in which /proc reports cpu times, it is 100 on all common architectures
+const clockTicks = 100
+
+// procNode remembers the start time of the process,
+// so a new process reusing the pid is not mistaken for it
+type procNode struct {
+	pid   int
+	name  string
+	start uint64
+}
+
+func (p procNode) String() string {
+	return fmt.Sprintf("%d %s", p.pid, p.name)
+}
+
+// procStat holds the fields of /proc/<pid>/stat which are used
+type procStat struct {
+	pid   int
+	name  string
+	state string
+	ppid  int
+	utime uint64
+	stime uint64
+	// start is the time the process started after boot in clock ticks
+	start uint64
+}
+
+type procGraph struct {
+	root          string
+	executeSignal syscall.Signal
+	deleteSignal  syscall.Signal
+	walder        *Walder
+}
+
+// procSignals are the signals which can be chosen to be send by Execute
+var procSignals = []struct {
+	name   string
+	signal syscall.Signal
+}{
+	{"SIGHUP", syscall.SIGHUP},
+	{"SIGINT", syscall.SIGINT},
+	{"SIGUSR1", syscall.SIGUSR1},
+	{"SIGUSR2", syscall.SIGUSR2},
+	{"SIGSTOP", syscall.SIGSTOP},
+	{"SIGCONT", syscall.SIGCONT},
+	{"SIGTERM", syscall.SIGTERM},
+	{"SIGKILL", syscall.SIGKILL},
+}
+
+func procSignalName(sig syscall.Signal) string {
+	for _, s := range procSignals {
+		if s.signal == sig {
+			return s.name
+		}
+	}
+	return sig.String()
+}
+
+var _ internal = &procGraph{}
+
+func (p *procGraph) renew(w *Walder) {
+	p.walder = w
+}
+
+var _ walder.DimensionChanger = &procGraph{}
+
+// DimensionGetAll returns the signals which can be send by Execute
+func (p *procGraph) DimensionGetAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(procSignals))
+	for _, s := range procSignals {
+		all = append(all, stringer(s.name))
+	}
+	return all, nil
+}
+
+// DimensionSet sets the signal which is send by Execute
+func (p *procGraph) DimensionSet(dim fmt.Stringer) error {
+	if dim == nil {
+		return fmt.Errorf("recieved nil value")
+	}
+	for _, s := range procSignals {
+		if s.name == dim.String() {
+			p.executeSignal = s.signal
+			return nil
+		}
+	}
+	return fmt.Errorf("dimension '%s' not known to this graph", dim)
+}
+
+func (p *procGraph) path(pid int, elem ...string) string {
+	return filepath.Join(append([]string{p.root, strconv.Itoa(pid)}, elem...)...)
+}
+
+func (p *procGraph) stat(pid int) (procStat, error) {
+	content, err := os.ReadFile(p.path(pid, "stat"))
+	if err != nil {
+		return procStat{}, err
+	}
+	// the name is in parentheses and may contain spaces and parentheses itself
+	line := string(content)
+	open, close := strings.IndexByte(line, '('), strings.LastIndexByte(line, ')')
+	if open < 0 || close < open {
+		return procStat{}, fmt.Errorf("malformed stat of %d", pid)
+	}
+	fields := strings.Fields(line[close+1:])
+	if len(fields) < 20 {
+		return procStat{}, fmt.Errorf("malformed stat of %d", pid)
+	}
+	s := procStat{pid: pid, name: line[open+1 : close], state: fields[0]}
+	s.ppid, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return s, err
+	}
+	s.utime, err = strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return s, err
+	}
+	s.stime, err = strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return s, err
+	}
+	s.start, err = strconv.ParseUint(fields[19], 10, 64)
+	return s, err
+}
+
+// stats returns all processes sorted by pid, processes which ended while reading are skipped
+func (p *procGraph) stats() ([]procStat, error) {
+	entries, err := os.ReadDir(p.root)
+	if err != nil {
+		return nil, err
+	}
+	var stats []procStat
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		s, err := p.stat(pid)
+		if err != nil {
+			continue
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(a, b int) bool { return stats[a].pid < stats[b].pid })
+	return stats, nil
+}
+
+func (p *procGraph) lookup(str fmt.Stringer) (procStat, error) {
+	if str == nil {
+		return procStat{}, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(procNode)
+	if !ok {
+		return procStat{}, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	s, err := p.stat(n.pid)
+	if err != nil {
+		return s, fmt.Errorf("process %d is gone: %w", n.pid, err)
+	}
+	if s.start != n.start {
+		return s, fmt.Errorf("process %d is gone, the pid is used by '%s' now", n.pid, s.name)
+	}
+	return s, nil
+}
+
+func (s procStat) node() procNode {
+	return procNode{pid: s.pid, name: s.name, start: s.start}
+}
+
+var _ walder.Graph = &procGraph{}
+
+func (p *procGraph) String() string {
+	return "processes"
+}
+
+// HomeNodes returns the processes without parent, which are init and kthreadd
+func (p *procGraph) HomeNodes() ([]fmt.Stringer, error) {
+	stats, err := p.stats()
+	if err != nil {
+		return nil, err
+	}
+	var home []fmt.Stringer
+	for _, s := range stats {
+		if s.ppid == 0 {
+			home = append(home, s.node())
+		}
+	}
+	return home, nil
+}
+
+var _ walder.NodeAller = &procGraph{}
+
+func (p *procGraph) NodeAll() ([]fmt.Stringer, error) {
+	stats, err := p.stats()
+	if err != nil {
+		return nil, err
+	}
+	all := make([]fmt.Stringer, 0, len(stats))
+	for _, s := range stats {
+		all = append(all, s.node())
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirectedTree = &procGraph{}
+
+func (p *procGraph) Parent(str fmt.Stringer) (fmt.Stringer, error) {
+	s, err := p.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if s.ppid == 0 {
+		return nil, fmt.Errorf("process %d has no parent", s.pid)
+	}
+	parent, err := p.stat(s.ppid)
+	if err != nil {
+		return nil, err
+	}
+	return parent.node(), nil
+}
+
+func (p *procGraph) Children(str fmt.Stringer) ([]fmt.Stringer, error) {
+	s, err := p.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := p.stats()
+	if err != nil {
+		return nil, err
+	}
+	var children []fmt.Stringer
+	for _, c := range stats {
+		if c.ppid == s.pid {
+			children = append(children, c.node())
+		}
+	}
+	return children, nil
+}
+
+var _ walder.GraphDirected = &procGraph{}
+
+func (p *procGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	return p.Children(str)
+}
+
+func (p *procGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	s, err := p.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if s.ppid == 0 {
+		return nil, nil
+	}
+	parent, err := p.Parent(str)
+	if err != nil {
+		return nil, err
+	}
+	return []fmt.Stringer{parent}, nil
+}
+
+var _ walder.NodeLabeler = &procGraph{}
+
+// NodeLabels returns the command line, user, resident memory and used cpu time of the process
+func (p *procGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	s, err := p.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{{"state", s.state}}
+
+	cmdline, err := os.ReadFile(p.path(s.pid, "cmdline"))
+	if err == nil {
+		labels = append(labels, [2]string{"cmdline", strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))})
+	}
+	status, err := p.status(s.pid)
+	if err == nil {
+		if uid := strings.Fields(status["Uid"]); len(uid) > 0 {
+			name := uid[0]
+			if u, err := user.LookupId(uid[0]); err == nil {
+				name = u.Username
+			}
+			labels = append(labels, [2]string{"user", name})
+		}
+		if rss, ok := status["VmRSS"]; ok {
+			labels = append(labels, [2]string{"rss", rss})
+		}
+	}
+	cpu := time.Duration(s.utime+s.stime) * time.Second / clockTicks
+	labels = append(labels, [2]string{"cpu", cpu.String()})
+	return labels, nil
+}
+
+func (p *procGraph) status(pid int) (map[string]string, error) {
+	f, err := os.Open(p.path(pid, "status"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	status := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			status[key] = strings.TrimSpace(value)
+		}
+	}
+	return status, scanner.Err()
+}
+
+var _ walder.Executor = &procGraph{}
+
+// Execute asks to confirm and only then sends the execute signal to the process,
+// if it is still the same process
+func (p *procGraph) Execute(node fmt.Stringer) error {
+	s, err := p.lookup(node)
+	if err != nil {
+		return err
+	}
+	if p.executeSignal == 0 {
+		return fmt.Errorf("no signal to execute set, choose one as dimension of the graph")
+	}
+	if p.walder == nil {
+		return fmt.Errorf("the process graph is not part of a walder")
+	}
+	sig := p.executeSignal
+	send := stringer(fmt.Sprintf("send %s to %s", procSignalName(sig), s.node()))
+	// cancel comes first so an accidental second enter does not send the signal
+	return p.walder.Push(&chooser{
+		from: []fmt.Stringer{stringer("cancel"), send},
+		execFunc: func(w *Walder, choice fmt.Stringer) error {
+			w.pop()
+			if choice != send {
+				return nil
+			}
+			s, err := p.lookup(node)
+			if err != nil {
+				return err
+			}
+			return p.signal(s.pid, sig)
+		},
+	})
+}
+
+var _ walder.NodeDeleter = &procGraph{}
+
+func (p *procGraph) NodeUpdate(toUpdate fmt.Stringer) (fmt.Stringer, error) {
+	s, err := p.lookup(toUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return s.node(), nil
+}
+
+// NodeDelete sends the delete signal to the process, if it is still the same process
+func (p *procGraph) NodeDelete(toDelete fmt.Stringer) error {
+	s, err := p.lookup(toDelete)
+	if err != nil {
+		return err
+	}
+	return p.signal(s.pid, p.deleteSignal)
+}
+
+func (p *procGraph) signal(pid int, sig syscall.Signal) error {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Signal(sig)
+}
+
+var _ walder.Dimensions = &procGraph{}
+
+// Dimensions returns the open file descriptors and the sockets of the process
+func (p *procGraph) Dimensions(str fmt.Stringer) ([]walder.Graph, error) {
+	s, err := p.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	return []walder.Graph{
+		&fdGraph{proc: p, pid: s.pid},
+		&fdGraph{proc: p, pid: s.pid, sockets: true},
+	}, nil
+}
+
+// fdNode is a open file descriptor and what it points to
+type fdNode struct {
+	fd     int
+	target string
+}
+
+func (f fdNode) String() string {
+	return fmt.Sprintf("%d -> %s", f.fd, f.target)
+}
+
+var _ walder.Pather = fdNode{}
+
+func (f fdNode) Path() (string, error) {
+	if !filepath.IsAbs(f.target) {
+		return "", fmt.Errorf("'%s' is not a file", f.target)
+	}
+	return f.target, nil
+}
+
+// fdGraph lists the file descriptors of a process or only its sockets
+type fdGraph struct {
+	proc    *procGraph
+	pid     int
+	sockets bool
+}
+
+var _ walder.Graph = &fdGraph{}
+
+func (g *fdGraph) String() string {
+	if g.sockets {
+		return fmt.Sprintf("sockets of %d", g.pid)
+	}
+	return fmt.Sprintf("file descriptors of %d", g.pid)
+}
+
+func (g *fdGraph) HomeNodes() ([]fmt.Stringer, error) {
+	entries, err := os.ReadDir(g.proc.path(g.pid, "fd"))
+	if err != nil {
+		return nil, err
+	}
+	var fds []fdNode
+	for _, e := range entries {
+		fd, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		target, err := os.Readlink(g.proc.path(g.pid, "fd", e.Name()))
+		if err != nil {
+			continue
+		}
+		if g.sockets && !strings.HasPrefix(target, "socket:[") {
+			continue
+		}
+		fds = append(fds, fdNode{fd: fd, target: target})
+	}
+	sort.Slice(fds, func(a, b int) bool { return fds[a].fd < fds[b].fd })
+	home := make([]fmt.Stringer, 0, len(fds))
+	for _, f := range fds {
+		home = append(home, f)
+	}
+	return home, nil
+}
+
+var _ walder.NodeLabeler = &fdGraph{}
+
+// NodeLabels returns the fdinfo and for sockets the protocol, addresses and state
+func (g *fdGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	f, ok := str.(fdNode)
+	if !ok {
+		return nil, fmt.Errorf("want %T, but got %T", f, str)
+	}
+	var labels [][2]string
+	info, err := os.ReadFile(g.proc.path(g.pid, "fdinfo", strconv.Itoa(f.fd)))
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(info)), "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			labels = append(labels, [2]string{key, strings.TrimSpace(value)})
+		}
+	}
+	inode := strings.TrimSuffix(strings.TrimPrefix(f.target, "socket:["), "]")
+	if inode == f.target {
+		return labels, nil
+	}
+	return append(labels, g.socket(inode)...), nil
+}
+
+// socket searches the network tables of the process for the inode
+func (g *fdGraph) socket(inode string) [][2]string {
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		f, err := os.Open(g.proc.path(g.pid, "net", proto))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[9] != inode {
+				continue
+			}
+			f.Close()
+			return [][2]string{
+				{"protocol", proto},
+				{"local", procAddr(fields[1])},
+				{"remote", procAddr(fields[2])},
+				{"state", tcpStates[fields[3]]},
+			}
+		}
+		f.Close()
+	}
+	f, err := os.Open(g.proc.path(g.pid, "net", "unix"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 || fields[6] != inode {
+			continue
+		}
+		labels := [][2]string{{"protocol", "unix"}}
+		if len(fields) > 7 {
+			labels = append(labels, [2]string{"path", fields[7]})
+		}
+		return labels
+	}
+	return nil
+}
+
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+// procAddr decodes the hex address and port used in /proc/net, the address is stored in host byte order per 32 bit word
+func procAddr(hexAddr string) string {
+	addr, port, ok := strings.Cut(hexAddr, ":")
+	if !ok {
+		return hexAddr
+	}
+	raw, err := hex.DecodeString(addr)
+	if err != nil || len(raw)%4 != 0 {
+		return hexAddr
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return hexAddr
+	}
+	return net.JoinHostPort(net.IP(raw).String(), strconv.FormatUint(p, 10))
+}
@@ -0,0 +1,152 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// writeStat writes a fake /proc/<pid>/stat with the parent and start time
+func writeStat(t *testing.T, root string, pid int, name string, ppid int, start uint64) {
+	t.Helper()
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := fmt.Sprintf("%d (%s) S %d 1 1 0 -1 0 0 0 0 0 7 3 0 0 20 0 1 0 %d 0 0\n", pid, name, ppid, start)
+	err = os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcTree(t *testing.T) {
+	root := t.TempDir()
+	writeStat(t, root, 1, "init", 0, 1)
+	writeStat(t, root, 900001, "sh (login)", 1, 50)
+	writeStat(t, root, 900002, "sleep", 900001, 60)
+
+	g, err := ProcDim{Root: root}.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := g.(*procGraph)
+	home, err := p.HomeNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(home); got != "1 init" {
+		t.Errorf("want home '1 init', but got '%s'", got)
+	}
+	children, err := p.Children(procNode{pid: 1, name: "init", start: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(children); got != "900001 sh (login)" {
+		t.Errorf("want child '900001 sh (login)', but got '%s'", got)
+	}
+	parent, err := p.Parent(procNode{pid: 900002, name: "sleep", start: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent != (procNode{pid: 900001, name: "sh (login)", start: 50}) {
+		t.Errorf("want parent 900001, but got '%s'", parent)
+	}
+}
+
+func TestProcReusedPid(t *testing.T) {
+	root := t.TempDir()
+	writeStat(t, root, 900003, "old", 1, 70)
+	g, err := ProcDim{Root: root, ExecuteSignal: syscall.SIGCONT, DeleteSignal: syscall.SIGCONT}.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := g.(*procGraph)
+	all, err := p.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := all[0]
+	// the process ended and an other one started with the same pid
+	writeStat(t, root, 900003, "new", 1, 80)
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"execute", func() error { return p.Execute(old) }},
+		{"delete", func() error { return p.NodeDelete(old) }},
+		{"update", func() error { _, err := p.NodeUpdate(old); return err }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.call(); err == nil {
+				t.Error("want error, but got nil")
+			}
+		})
+	}
+}
+
+func TestProcExecute(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	err := cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	pid := cmd.Process.Pid
+
+	root := t.TempDir()
+	writeStat(t, root, pid, "sleep", 1, 90)
+	g, err := ProcDim{Root: root}.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := g.(*procGraph)
+	w := NewWalder()
+	p.renew(w)
+	node := procNode{pid: pid, name: "sleep", start: 90}
+
+	if err := p.Execute(node); err == nil {
+		t.Fatal("want error without a chosen signal, but got nil")
+	}
+	err = p.DimensionSet(stringer("SIGTERM"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// choose runs Execute and picks the option at the index from the pushed chooser
+	choose := func(index int) {
+		t.Helper()
+		err := p.Execute(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, ok := w.peek().graph.(*chooser)
+		if !ok {
+			t.Fatalf("want a chooser to confirm, but got %T", w.peek().graph)
+		}
+		err = c.Execute(c.from[index])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := w.peek().graph.(*chooser); ok {
+			t.Fatal("want the chooser popped after the choice")
+		}
+	}
+
+	choose(0)
+	if err := cmd.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Fatalf("want the process alive after cancel, but got %s", err)
+	}
+	choose(1)
+	err = cmd.Wait()
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Errorf("want the process terminated by SIGTERM, but got %v", err)
+	}
+}
@@ -0,0 +1,769 @@
+package lib
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/treilik/walder"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteDim is a generator for the schema of a SQLite database,
+// the rows of a table are reached through the Dimensions of the table.
+type SQLiteDim struct{}
+
+var _ walder.Dimensioner = SQLiteDim{}
+
+func (d SQLiteDim) String() string {
+	return "sqlite"
+}
+
+func (d SQLiteDim) New() (walder.Graph, error) {
+	return nil, fmt.Errorf("a sqlite database needs to be opened from the filesystem")
+}
+
+var _ walder.NodeOpener = SQLiteDim{}
+
+// NodeOpen opens the database file of the node with foreign keys enforced
+func (d SQLiteDim) NodeOpen(nodes ...fmt.Stringer) (walder.Graph, error) {
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("need exactly one node, got %d", len(nodes))
+	}
+	node := nodes[0]
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	path := node.String()
+	if p, ok := node.(walder.Pather); ok {
+		var err error
+		path, err = p.Path()
+		if err != nil {
+			return nil, err
+		}
+	}
+	db, err := sql.Open("sqlite", "file:"+(&url.URL{Path: path}).EscapedPath()+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// a file which is no database is only noticed on the first query
+	if _, err := db.Exec("SELECT count(*) FROM sqlite_master"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("'%s' is not a sqlite database: %w", path, err)
+	}
+	return &sqliteGraph{db: db, path: path}, nil
+}
+
+// quoteIdent quotes a table or column name for the use in a statement
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+type sqliteTable string
+
+func (t sqliteTable) String() string {
+	return string(t)
+}
+
+// sqliteFK is a foreign key, the columns of from reference the columns of to in the parent table
+type sqliteFK struct {
+	table  string
+	parent string
+	from   []string
+	to     []string
+}
+
+type sqliteColumn struct {
+	name    string
+	kind    string
+	notNull bool
+	pk      int
+}
+
+// sqliteGraph shows the tables of the database, which are read anew on every call
+type sqliteGraph struct {
+	db   *sql.DB
+	path string
+}
+
+func (g *sqliteGraph) tables() ([]string, error) {
+	rows, err := g.db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+func (g *sqliteGraph) columns(table string) ([]sqliteColumn, error) {
+	rows, err := g.db.Query("SELECT name, type, \"notnull\", pk FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var columns []sqliteColumn
+	for rows.Next() {
+		var c sqliteColumn
+		if err := rows.Scan(&c.name, &c.kind, &c.notNull, &c.pk); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// primaryKey returns the columns of the primary key in there order
+func (g *sqliteGraph) primaryKey(table string) ([]string, error) {
+	columns, err := g.columns(table)
+	if err != nil {
+		return nil, err
+	}
+	pk := make([]string, 0, len(columns))
+	for i := 1; ; i++ {
+		found := false
+		for _, c := range columns {
+			if c.pk == i {
+				pk = append(pk, c.name)
+				found = true
+			}
+		}
+		if !found {
+			return pk, nil
+		}
+	}
+}
+
+// foreignKeys returns the foreign keys of the table, referencing a table without columns means its primary key
+func (g *sqliteGraph) foreignKeys(table string) ([]sqliteFK, error) {
+	rows, err := g.db.Query("SELECT id, \"table\", \"from\", \"to\" FROM pragma_foreign_key_list(?) ORDER BY id, seq", table)
+	if err != nil {
+		return nil, err
+	}
+	var fks []sqliteFK
+	last := -1
+	for rows.Next() {
+		var id int
+		var parent, from string
+		var to sql.NullString
+		if err := rows.Scan(&id, &parent, &from, &to); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if id != last {
+			fks = append(fks, sqliteFK{table: table, parent: parent})
+			last = id
+		}
+		fk := &fks[len(fks)-1]
+		fk.from = append(fk.from, from)
+		fk.to = append(fk.to, to.String)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, fk := range fks {
+		if fk.to[0] != "" {
+			continue
+		}
+		pk, err := g.primaryKey(fk.parent)
+		if err != nil {
+			return nil, err
+		}
+		fks[i].to = pk
+	}
+	return fks, nil
+}
+
+// referencing returns the foreign keys of all tables which reference the table
+func (g *sqliteGraph) referencing(table string) ([]sqliteFK, error) {
+	tables, err := g.tables()
+	if err != nil {
+		return nil, err
+	}
+	var refs []sqliteFK
+	for _, t := range tables {
+		fks, err := g.foreignKeys(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, fk := range fks {
+			if strings.EqualFold(fk.parent, table) {
+				refs = append(refs, fk)
+			}
+		}
+	}
+	return refs, nil
+}
+
+func (g *sqliteGraph) lookup(str fmt.Stringer) (string, error) {
+	if str == nil {
+		return "", fmt.Errorf("recieved nil value")
+	}
+	t, ok := str.(sqliteTable)
+	if !ok {
+		return "", fmt.Errorf("want %T, but got %T", t, str)
+	}
+	tables, err := g.tables()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range tables {
+		if strings.EqualFold(name, string(t)) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("table '%s' does not exist", t)
+}
+
+var _ walder.Graph = &sqliteGraph{}
+
+func (g *sqliteGraph) String() string {
+	return fmt.Sprintf("sqlite: %s", g.path)
+}
+
+func (g *sqliteGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return g.NodeAll()
+}
+
+var _ walder.NodeAller = &sqliteGraph{}
+
+func (g *sqliteGraph) NodeAll() ([]fmt.Stringer, error) {
+	tables, err := g.tables()
+	if err != nil {
+		return nil, err
+	}
+	all := make([]fmt.Stringer, 0, len(tables))
+	for _, t := range tables {
+		all = append(all, sqliteTable(t))
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &sqliteGraph{}
+
+// Outgoing returns the tables referenced by the foreign keys of the table
+func (g *sqliteGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	table, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	fks, err := g.foreignKeys(table)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, fk := range fks {
+		parent, err := g.lookup(sqliteTable(fk.parent))
+		if err != nil {
+			return nil, err
+		}
+		if !seen[parent] {
+			seen[parent] = true
+			out = append(out, sqliteTable(parent))
+		}
+	}
+	return out, nil
+}
+
+// Incoming returns the tables with foreign keys to the table
+func (g *sqliteGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	table, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := g.referencing(table)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, fk := range refs {
+		if !seen[fk.table] {
+			seen[fk.table] = true
+			in = append(in, sqliteTable(fk.table))
+		}
+	}
+	return in, nil
+}
+
+var _ walder.EdgeLabeler = &sqliteGraph{}
+
+// EdgeLabels returns the columns of the foreign keys and the columns they reference
+func (g *sqliteGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	fks, err := g.foreignKeys(f)
+	if err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	for _, fk := range fks {
+		if strings.EqualFold(fk.parent, t) {
+			labels = append(labels, [2]string{strings.Join(fk.from, ", "), strings.Join(fk.to, ", ")})
+		}
+	}
+	if labels == nil {
+		return nil, fmt.Errorf("'%s' has no foreign key to '%s'", f, t)
+	}
+	return labels, nil
+}
+
+var _ walder.NodeLabeler = &sqliteGraph{}
+
+// NodeLabels returns the columns of the table with there types and the number of rows
+func (g *sqliteGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	table, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := g.columns(table)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([][2]string, 0, len(columns)+1)
+	for _, c := range columns {
+		kind := c.kind
+		if c.pk > 0 {
+			kind += " primary key"
+		}
+		if c.notNull {
+			kind += " not null"
+		}
+		labels = append(labels, [2]string{c.name, strings.TrimSpace(kind)})
+	}
+	var count int
+	if err := g.db.QueryRow("SELECT count(*) FROM " + quoteIdent(table)).Scan(&count); err != nil {
+		return nil, err
+	}
+	return append(labels, [2]string{"rows", fmt.Sprint(count)}), nil
+}
+
+var _ walder.GetReader = &sqliteGraph{}
+
+// GetReader dumps the schema as SQL statements
+func (g *sqliteGraph) GetReader() (io.Reader, error) {
+	rows, err := g.db.Query("SELECT sql FROM sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY type = 'table' DESC, type, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	b := &bytes.Buffer{}
+	for rows.Next() {
+		var statement string
+		if err := rows.Scan(&statement); err != nil {
+			return nil, err
+		}
+		b.WriteString(statement)
+		b.WriteString(";\n")
+	}
+	return b, rows.Err()
+}
+
+var _ walder.Closer = &sqliteGraph{}
+
+// Close closes the database, which is opened by NodeOpen
+func (g *sqliteGraph) Close() error {
+	return g.db.Close()
+}
+
+var _ walder.Dimensions = &sqliteGraph{}
+
+// Dimensions returns the rows of the table
+func (g *sqliteGraph) Dimensions(str fmt.Stringer) ([]walder.Graph, error) {
+	table, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	return []walder.Graph{&sqliteRows{schema: g, table: table}}, nil
+}
+
+// sqliteRow is a row identified by its rowid, or by its primary key for tables without rowid
+type sqliteRow struct {
+	table string
+	// key is the rowid or the values of the primary key quoted as SQL literals and joined by commas
+	key string
+}
+
+func (r sqliteRow) String() string {
+	return fmt.Sprintf("%s %s", r.table, r.key)
+}
+
+// sqliteKey tells how the rows of a table are identified,
+// expression selects the key of a row, condition matches it and order sorts by it
+type sqliteKey struct {
+	expression string
+	condition  string
+	order      string
+}
+
+// rowKey returns the first of the names of the rowid not used as column name,
+// or the primary key for tables created WITHOUT ROWID or with columns named like all names of the rowid
+func (g *sqliteGraph) rowKey(table string) (sqliteKey, error) {
+	columns, err := g.columns(table)
+	if err != nil {
+		return sqliteKey{}, err
+	}
+	used := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		used[strings.ToLower(c.name)] = true
+	}
+	for _, alias := range []string{"rowid", "_rowid_", "oid"} {
+		if used[alias] {
+			continue
+		}
+		rows, err := g.db.Query("SELECT " + alias + " FROM " + quoteIdent(table) + " LIMIT 0")
+		if err != nil {
+			// the table has no rowid at all
+			break
+		}
+		rows.Close()
+		return sqliteKey{expression: alias, condition: alias + " = ?", order: alias}, nil
+	}
+	pk, err := g.primaryKey(table)
+	if err != nil {
+		return sqliteKey{}, err
+	}
+	if len(pk) == 0 {
+		return sqliteKey{}, fmt.Errorf("table '%s' has neither a rowid nor a primary key", table)
+	}
+	quoted := make([]string, 0, len(pk))
+	order := make([]string, 0, len(pk))
+	for _, c := range pk {
+		// quote doubles the quotes inside of strings, so the joined literals are unambiguous
+		quoted = append(quoted, "quote("+quoteIdent(c)+")")
+		order = append(order, quoteIdent(c))
+	}
+	expression := strings.Join(quoted, " || ',' || ")
+	return sqliteKey{expression: expression, condition: expression + " = ?", order: strings.Join(order, ", ")}, nil
+}
+
+// sqliteRows shows the rows of a table, the foreign keys lead to the rows of other tables
+type sqliteRows struct {
+	schema *sqliteGraph
+	table  string
+}
+
+func (r *sqliteRows) lookup(str fmt.Stringer) (sqliteRow, error) {
+	if str == nil {
+		return sqliteRow{}, fmt.Errorf("recieved nil value")
+	}
+	row, ok := str.(sqliteRow)
+	if !ok {
+		return row, fmt.Errorf("want %T, but got %T", row, str)
+	}
+	key, err := r.schema.rowKey(row.table)
+	if err != nil {
+		return row, err
+	}
+	var exists bool
+	err = r.schema.db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+quoteIdent(row.table)+" WHERE "+key.condition+")", row.key).Scan(&exists)
+	if err != nil {
+		return row, err
+	}
+	if !exists {
+		return row, fmt.Errorf("row '%s' does not exist", row.String())
+	}
+	return row, nil
+}
+
+// rowids returns the rows of the table matching the columns
+func (r *sqliteRows) rowids(table string, columns []string, values []interface{}) ([]fmt.Stringer, error) {
+	key, err := r.schema.rowKey(table)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT " + key.expression + " FROM " + quoteIdent(table)
+	if len(columns) > 0 {
+		conditions := make([]string, 0, len(columns))
+		for _, c := range columns {
+			conditions = append(conditions, quoteIdent(c)+" = ?")
+		}
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	rows, err := r.schema.db.Query(query+" ORDER BY "+key.order, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var found []fmt.Stringer
+	for rows.Next() {
+		row := sqliteRow{table: table}
+		if err := rows.Scan(&row.key); err != nil {
+			return nil, err
+		}
+		found = append(found, row)
+	}
+	return found, rows.Err()
+}
+
+// read returns the values of the columns of the row
+func (r *sqliteRows) read(row sqliteRow, columns []string) ([]interface{}, error) {
+	quoted := make([]string, 0, len(columns))
+	for _, c := range columns {
+		quoted = append(quoted, quoteIdent(c))
+	}
+	key, err := r.schema.rowKey(row.table)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	err = r.schema.db.QueryRow("SELECT "+strings.Join(quoted, ", ")+" FROM "+quoteIdent(row.table)+" WHERE "+key.condition, row.key).Scan(pointers...)
+	return values, err
+}
+
+// values returns the values of the columns of the row, nil if one of them is NULL
+func (r *sqliteRows) values(row sqliteRow, columns []string) ([]interface{}, error) {
+	values, err := r.read(row, columns)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		if v == nil {
+			return nil, nil
+		}
+	}
+	return values, nil
+}
+
+var _ walder.Graph = &sqliteRows{}
+
+func (r *sqliteRows) String() string {
+	return fmt.Sprintf("rows of %s", r.table)
+}
+
+func (r *sqliteRows) HomeNodes() ([]fmt.Stringer, error) {
+	return r.rowids(r.table, nil, nil)
+}
+
+var _ walder.NodeAller = &sqliteRows{}
+
+// NodeAll returns the rows of the table, rows of other tables are only reached through foreign keys
+func (r *sqliteRows) NodeAll() ([]fmt.Stringer, error) {
+	return r.rowids(r.table, nil, nil)
+}
+
+var _ walder.GraphDirected = &sqliteRows{}
+
+// Outgoing returns the rows referenced by the foreign keys of the row
+func (r *sqliteRows) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	row, err := r.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	fks, err := r.schema.foreignKeys(row.table)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	for _, fk := range fks {
+		values, err := r.values(row, fk.from)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			continue
+		}
+		parents, err := r.rowids(fk.parent, fk.to, values)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, parents...)
+	}
+	return out, nil
+}
+
+// Incoming returns the rows of other tables referencing the row
+func (r *sqliteRows) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	row, err := r.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := r.schema.referencing(row.table)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, fk := range refs {
+		values, err := r.values(row, fk.to)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			continue
+		}
+		children, err := r.rowids(fk.table, fk.from, values)
+		if err != nil {
+			return nil, err
+		}
+		in = append(in, children...)
+	}
+	return in, nil
+}
+
+var _ walder.NodeLabelAdder = &sqliteRows{}
+
+// NodeLabels returns the values of the columns of the row
+func (r *sqliteRows) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	row, err := r.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := r.schema.columns(row.table)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, c.name)
+	}
+	values, err := r.read(row, names)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([][2]string, 0, len(names))
+	for i, n := range names {
+		value := "NULL"
+		switch v := values[i].(type) {
+		case nil:
+		case []byte:
+			value = string(v)
+		default:
+			value = fmt.Sprint(v)
+		}
+		labels = append(labels, [2]string{n, value})
+	}
+	return labels, nil
+}
+
+// NodeLabelAdd sets the column of the row to the value, the returned row has a new key if a column of the key was set
+func (r *sqliteRows) NodeLabelAdd(node fmt.Stringer, key, value string) (fmt.Stringer, error) {
+	row, err := r.lookup(node)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.column(row.table, key); err != nil {
+		return nil, err
+	}
+	rowKey, err := r.schema.rowKey(row.table)
+	if err != nil {
+		return nil, err
+	}
+	updated := sqliteRow{table: row.table}
+	err = r.schema.db.QueryRow("UPDATE "+quoteIdent(row.table)+" SET "+quoteIdent(key)+" = ? WHERE "+rowKey.condition+" RETURNING "+rowKey.expression, value, row.key).Scan(&updated.key)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (r *sqliteRows) column(table, name string) error {
+	columns, err := r.schema.columns(table)
+	if err != nil {
+		return err
+	}
+	for _, c := range columns {
+		if c.name == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("table '%s' has no column '%s'", table, name)
+}
+
+var _ walder.NodeCreater = &sqliteRows{}
+
+// NodeCreate inserts a row into the table, the input sets columns like "name=value, other=value"
+func (r *sqliteRows) NodeCreate(input fmt.Stringer) (fmt.Stringer, error) {
+	if input == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	var columns []string
+	var values []interface{}
+	for _, assignment := range strings.Split(input.String(), ",") {
+		if strings.TrimSpace(assignment) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(assignment, "=")
+		if !ok {
+			return nil, fmt.Errorf("want column=value, but got '%s'", assignment)
+		}
+		key = strings.TrimSpace(key)
+		if err := r.column(r.table, key); err != nil {
+			return nil, err
+		}
+		columns = append(columns, quoteIdent(key))
+		values = append(values, strings.TrimSpace(value))
+	}
+	key, err := r.schema.rowKey(r.table)
+	if err != nil {
+		return nil, err
+	}
+	statement := "INSERT INTO " + quoteIdent(r.table) + " DEFAULT VALUES"
+	if len(columns) > 0 {
+		statement = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(r.table), strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	}
+	row := sqliteRow{table: r.table}
+	err = r.transaction(func(tx *sql.Tx) error {
+		return tx.QueryRow(statement+" RETURNING "+key.expression, values...).Scan(&row.key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// transaction runs do and commits only if it succeeds
+func (r *sqliteRows) transaction(do func(tx *sql.Tx) error) error {
+	tx, err := r.schema.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := do(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+var _ walder.NodeDeleter = &sqliteRows{}
+
+func (r *sqliteRows) NodeUpdate(toUpdate fmt.Stringer) (fmt.Stringer, error) {
+	return r.lookup(toUpdate)
+}
+
+// NodeDelete deletes the row, foreign key constraints may refuse it
+func (r *sqliteRows) NodeDelete(toDelete fmt.Stringer) error {
+	row, err := r.lookup(toDelete)
+	if err != nil {
+		return err
+	}
+	key, err := r.schema.rowKey(row.table)
+	if err != nil {
+		return err
+	}
+	return r.transaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM "+quoteIdent(row.table)+" WHERE "+key.condition, row.key)
+		return err
+	})
+}
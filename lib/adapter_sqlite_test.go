@@ -0,0 +1,134 @@
+package lib
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// openSQLite creates a database with the statements and opens it like a file of the filesystem
+func openSQLite(t *testing.T, statements ...string) *sqliteGraph {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statements {
+		if _, err := db.Exec(s); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+	}
+	db.Close()
+	g, err := SQLiteDim{}.NodeOpen(stringer(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := g.(*sqliteGraph)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLiteRows(t *testing.T) {
+	g := openSQLite(t,
+		"CREATE TABLE author (id INTEGER PRIMARY KEY, name TEXT)",
+		"CREATE TABLE book (isbn TEXT, edition INT, author INT REFERENCES author, PRIMARY KEY (isbn, edition)) WITHOUT ROWID",
+		"CREATE TABLE note (rowid TEXT PRIMARY KEY, isbn TEXT, edition INT, FOREIGN KEY (isbn, edition) REFERENCES book)",
+		"INSERT INTO author VALUES (1, 'ann'), (2, 'bob')",
+		"INSERT INTO book VALUES ('it''s,1', 1, 1), ('x', 2, 2)",
+		"INSERT INTO note VALUES ('n1', 'it''s,1', 1)",
+	)
+	tests := []struct {
+		table    string
+		all      string
+		node     string
+		outgoing string
+		incoming string
+	}{
+		{"author", "author 1 author 2", "author 1", "", "book 'it''s,1',1"},
+		{"book", "book 'it''s,1',1 book 'x',2", "book 'it''s,1',1", "author 1", "note 1"},
+		{"note", "note 1", "note 1", "book 'it''s,1',1", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.table, func(t *testing.T) {
+			dims, err := g.Dimensions(sqliteTable(test.table))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := dims[0].(*sqliteRows)
+			all, err := r.NodeAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(all); got != test.all {
+				t.Errorf("want rows '%s', but got '%s'", test.all, got)
+			}
+			var node fmt.Stringer
+			for _, n := range all {
+				if n.String() == test.node {
+					node = n
+				}
+			}
+			out, err := r.Outgoing(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(out); got != test.outgoing {
+				t.Errorf("want outgoing '%s', but got '%s'", test.outgoing, got)
+			}
+			in, err := r.Incoming(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(in); got != test.incoming {
+				t.Errorf("want incoming '%s', but got '%s'", test.incoming, got)
+			}
+		})
+	}
+}
+
+func TestSQLiteWithoutRowid(t *testing.T) {
+	g := openSQLite(t, "CREATE TABLE tag (name TEXT PRIMARY KEY, color TEXT) WITHOUT ROWID")
+	dims, err := g.Dimensions(sqliteTable("tag"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := dims[0].(*sqliteRows)
+
+	created, err := r.NodeCreate(stringer("name=go, color=blue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.String() != "tag 'go'" {
+		t.Errorf("want 'tag 'go'', but got '%s'", created)
+	}
+	labels, err := r.NodeLabels(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := labelValue(labels, "color"); v != "blue" {
+		t.Errorf("want color 'blue', but got '%s'", v)
+	}
+	// setting a column of the key changes the key of the row
+	renamed, err := r.NodeLabelAdd(created, "name", "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.String() != "tag 'golang'" {
+		t.Errorf("want 'tag 'golang'', but got '%s'", renamed)
+	}
+	if _, err := r.NodeUpdate(created); err == nil {
+		t.Error("want error for the old key, but got nil")
+	}
+	if err := r.NodeDelete(renamed); err != nil {
+		t.Fatal(err)
+	}
+	all, err := r.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 0 {
+		t.Errorf("want no rows, but got '%s'", names(all))
+	}
+}
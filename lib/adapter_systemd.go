@@ -0,0 +1,549 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// SystemdDim is a generator for the dependency graph of systemd units.
+// Dirs are searched in order, a unit in an earlier directory hides one of the same name in a later.
+type SystemdDim struct {
+	Dirs []string
+}
+
+var _ walder.Dimensioner = SystemdDim{}
+
+func (d SystemdDim) String() string {
+	return "systemd units"
+}
+
+// New reads the units of the configured directories or of the default system directories
+func (d SystemdDim) New() (walder.Graph, error) {
+	dirs := d.Dirs
+	if len(dirs) == 0 {
+		dirs = []string{"/etc/systemd/system", "/run/systemd/system", "/usr/lib/systemd/system", "/lib/systemd/system"}
+	}
+	var existing []string
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			existing = append(existing, dir)
+		}
+	}
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("none of the unit directories exist: %s", strings.Join(dirs, ", "))
+	}
+	return newSystemdGraph(existing...)
+}
+
+var _ walder.NodeOpener = SystemdDim{}
+
+// NodeOpen reads the units of the directory of the node
+func (d SystemdDim) NodeOpen(nodes ...fmt.Stringer) (walder.Graph, error) {
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("need exactly one node, got %d", len(nodes))
+	}
+	node := nodes[0]
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	path := node.String()
+	if p, ok := node.(walder.Pather); ok {
+		var err error
+		path, err = p.Path()
+		if err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", path)
+	}
+	return newSystemdGraph(path)
+}
+
+const (
+	dimOrdering    dimension = "ordering"
+	dimRequirement dimension = "requirement"
+)
+
+// unitDirectives maps the dependency directives to the view they belong to
+var unitDirectives = map[string]dimension{
+	"Requires":  dimRequirement,
+	"Requisite": dimRequirement,
+	"BindsTo":   dimRequirement,
+	"Wants":     dimRequirement,
+	"PartOf":    dimRequirement,
+	"After":     dimOrdering,
+	"Before":    dimOrdering,
+}
+
+type unitNode string
+
+func (u unitNode) String() string {
+	return string(u)
+}
+
+type unitData struct {
+	// files holds the unit file followed by its drop-ins
+	files       []string
+	masked      bool
+	description string
+	deps        map[string][]string
+}
+
+// unitEdge is a dependency, for ordering edges from is started before to
+type unitEdge struct {
+	from      string
+	to        string
+	directive string
+	// declared is the unit in which the directive is written
+	declared string
+}
+
+type systemdGraph struct {
+	dirs    []string
+	view    dimension
+	order   []string
+	units   map[string]*unitData
+	aliases map[string]string
+	edges   []unitEdge
+}
+
+func newSystemdGraph(dirs ...string) (*systemdGraph, error) {
+	g := &systemdGraph{
+		dirs:    dirs,
+		view:    dimOrdering,
+		units:   make(map[string]*unitData),
+		aliases: make(map[string]string),
+	}
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	if err := g.link(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func isUnitName(name string) bool {
+	switch filepath.Ext(name) {
+	case ".service", ".socket", ".target", ".device", ".mount", ".automount", ".swap", ".timer", ".path", ".slice", ".scope":
+		return true
+	}
+	return false
+}
+
+func (g *systemdGraph) unit(name string) *unitData {
+	if real, ok := g.aliases[name]; ok {
+		name = real
+	}
+	u, ok := g.units[name]
+	if !ok {
+		u = &unitData{deps: make(map[string][]string)}
+		g.units[name] = u
+		g.order = append(g.order, name)
+	}
+	return u
+}
+
+// load reads the unit files, aliases, drop-ins and .wants and .requires directories of all directories
+func (g *systemdGraph) load() error {
+	dropIns := make(map[string]map[string]string)
+	for _, dir := range g.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			name, path := e.Name(), filepath.Join(dir, e.Name())
+			switch {
+			case isUnitName(name) && !e.IsDir():
+				if _, ok := g.units[name]; ok {
+					continue
+				}
+				if _, ok := g.aliases[name]; ok {
+					continue
+				}
+				if target, err := os.Readlink(path); err == nil {
+					if target == os.DevNull {
+						g.unit(name).masked = true
+						continue
+					}
+					if base := filepath.Base(target); base != name && isUnitName(base) {
+						g.aliases[name] = base
+						continue
+					}
+				}
+				g.unit(name).files = []string{path}
+			case e.IsDir() && strings.HasSuffix(name, ".d"):
+				unit := strings.TrimSuffix(name, ".d")
+				confs, err := filepath.Glob(filepath.Join(path, "*.conf"))
+				if err != nil {
+					return err
+				}
+				if dropIns[unit] == nil {
+					dropIns[unit] = make(map[string]string)
+				}
+				for _, conf := range confs {
+					if _, ok := dropIns[unit][filepath.Base(conf)]; !ok {
+						dropIns[unit][filepath.Base(conf)] = conf
+					}
+				}
+			}
+		}
+	}
+	for unit, confs := range dropIns {
+		names := make([]string, 0, len(confs))
+		for name := range confs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		u := g.unit(unit)
+		for _, name := range names {
+			u.files = append(u.files, confs[name])
+		}
+	}
+	for _, dir := range g.dirs {
+		for _, suffix := range []string{".wants", ".requires"} {
+			wantDirs, err := filepath.Glob(filepath.Join(dir, "*"+suffix))
+			if err != nil {
+				return err
+			}
+			directive := "Wants"
+			if suffix == ".requires" {
+				directive = "Requires"
+			}
+			for _, wantDir := range wantDirs {
+				entries, err := os.ReadDir(wantDir)
+				if err != nil {
+					return err
+				}
+				u := g.unit(strings.TrimSuffix(filepath.Base(wantDir), suffix))
+				for _, e := range entries {
+					if isUnitName(e.Name()) {
+						u.deps[directive] = append(u.deps[directive], e.Name())
+					}
+				}
+			}
+		}
+	}
+	for _, name := range g.order {
+		if err := g.parse(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parse reads the files of the unit, instances without a file of there own use the file of there template
+func (g *systemdGraph) parse(name string) error {
+	u := g.units[name]
+	if u.masked {
+		return nil
+	}
+	if len(u.files) == 0 {
+		if t, ok := g.units[templateName(name)]; ok {
+			u.files = t.files[:len(t.files):len(t.files)]
+		}
+	}
+	for _, file := range u.files {
+		if err := u.parse(file, instanceName(name)); err != nil {
+			return fmt.Errorf("error while parsing '%s': %w", file, err)
+		}
+	}
+	return nil
+}
+
+// templateName returns foo@.service for foo@bar.service
+func templateName(name string) string {
+	at := strings.IndexByte(name, '@')
+	if at < 0 {
+		return ""
+	}
+	return name[:at+1] + filepath.Ext(name)
+}
+
+func instanceName(name string) string {
+	at := strings.IndexByte(name, '@')
+	if at < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(name[at+1:], filepath.Ext(name))
+}
+
+// parse reads the [Unit] section of a unit file or drop-in, a empty assignment resets the list
+func (u *unitData) parse(path, instance string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	var section, pending string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		line, pending = pending+line, ""
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		if section != "Unit" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		value = strings.ReplaceAll(value, "%i", instance)
+		if key == "Description" {
+			u.description = value
+			continue
+		}
+		if _, ok := unitDirectives[key]; !ok {
+			continue
+		}
+		if value == "" {
+			u.deps[key] = nil
+			continue
+		}
+		u.deps[key] = append(u.deps[key], strings.Fields(value)...)
+	}
+	return scanner.Err()
+}
+
+// link turns the directives into edges, After is turned around so ordering edges point in start order
+func (g *systemdGraph) link() error {
+	directives := make([]string, 0, len(unitDirectives))
+	for d := range unitDirectives {
+		directives = append(directives, d)
+	}
+	sort.Strings(directives)
+	// units like instances of templates are added while linking and might have dependencies of there own,
+	// so the loop runs until no more units are added
+	for i := 0; i < len(g.order); i++ {
+		name := g.order[i]
+		u := g.units[name]
+		for _, directive := range directives {
+			for _, dep := range u.deps[directive] {
+				if _, ok := g.units[dep]; !ok && g.aliases[dep] == "" {
+					g.unit(dep)
+					if err := g.parse(dep); err != nil {
+						return err
+					}
+				}
+				if real, ok := g.aliases[dep]; ok {
+					dep = real
+				}
+				e := unitEdge{from: name, to: dep, directive: directive, declared: name}
+				if directive == "After" {
+					e.from, e.to = dep, name
+				}
+				g.edges = append(g.edges, e)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *systemdGraph) lookup(str fmt.Stringer) (string, *unitData, error) {
+	if str == nil {
+		return "", nil, fmt.Errorf("recieved nil value")
+	}
+	u, ok := str.(unitNode)
+	if !ok {
+		return "", nil, fmt.Errorf("want %T, but got %T", u, str)
+	}
+	data, ok := g.units[string(u)]
+	if !ok {
+		return "", nil, fmt.Errorf("'%s' is not a known unit", u)
+	}
+	return string(u), data, nil
+}
+
+var _ walder.Graph = &systemdGraph{}
+
+func (g *systemdGraph) String() string {
+	return fmt.Sprintf("systemd units: %s", g.view)
+}
+
+// HomeNodes returns the default target if there is one and else all units
+func (g *systemdGraph) HomeNodes() ([]fmt.Stringer, error) {
+	name := "default.target"
+	if real, ok := g.aliases[name]; ok {
+		name = real
+	}
+	if _, ok := g.units[name]; ok {
+		return []fmt.Stringer{unitNode(name)}, nil
+	}
+	return g.NodeAll()
+}
+
+var _ walder.NodeAller = &systemdGraph{}
+
+func (g *systemdGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(g.order))
+	for _, n := range g.order {
+		all = append(all, unitNode(n))
+	}
+	return all, nil
+}
+
+var _ walder.DimensionChanger = &systemdGraph{}
+
+func (g *systemdGraph) DimensionGetAll() ([]fmt.Stringer, error) {
+	return []fmt.Stringer{
+		stringer(dimOrdering),
+		stringer(dimRequirement),
+	}, nil
+}
+
+// DimensionSet switches between the start order and the requirements of the units
+func (g *systemdGraph) DimensionSet(dim fmt.Stringer) error {
+	switch dim := dim.String(); dim {
+	case string(dimOrdering), string(dimRequirement):
+		g.view = dimension(dim)
+		return nil
+	}
+	return fmt.Errorf("dimension '%s' not known to this graph", dim)
+}
+
+func (g *systemdGraph) visible(e unitEdge) bool {
+	return unitDirectives[e.directive] == g.view
+}
+
+var _ walder.GraphDirected = &systemdGraph{}
+
+// Outgoing returns the required units or in the ordering view the units started after the node
+func (g *systemdGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	name, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.from == name && g.visible(e) && !seen[e.to] {
+			seen[e.to] = true
+			out = append(out, unitNode(e.to))
+		}
+	}
+	return out, nil
+}
+
+func (g *systemdGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	name, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.to == name && g.visible(e) && !seen[e.from] {
+			seen[e.from] = true
+			in = append(in, unitNode(e.from))
+		}
+	}
+	return in, nil
+}
+
+var _ walder.EdgeLabeler = &systemdGraph{}
+
+// EdgeLabels returns the directives making up the edge and the units declaring them
+func (g *systemdGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, _, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	for _, e := range g.edges {
+		if e.from == f && e.to == t && g.visible(e) {
+			labels = append(labels, [2]string{e.directive, e.declared})
+		}
+	}
+	if labels == nil {
+		return nil, fmt.Errorf("there is no %s edge from '%s' to '%s'", g.view, f, t)
+	}
+	return labels, nil
+}
+
+var _ walder.Typer = &systemdGraph{}
+
+// GetType returns the kind of unit like service or target
+func (g *systemdGraph) GetType(str fmt.Stringer) (string, error) {
+	name, _, err := g.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(filepath.Ext(name), "."), nil
+}
+
+var _ walder.NodeLabeler = &systemdGraph{}
+
+func (g *systemdGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	_, u, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	if u.description != "" {
+		labels = append(labels, [2]string{"description", u.description})
+	}
+	for _, file := range u.files {
+		labels = append(labels, [2]string{"file", file})
+	}
+	switch {
+	case u.masked:
+		labels = append(labels, [2]string{"state", "masked"})
+	case len(u.files) == 0:
+		labels = append(labels, [2]string{"state", "not found"})
+	}
+	return labels, nil
+}
+
+var _ walder.NodeReader = &systemdGraph{}
+
+// NodeRead returns the unit file followed by its drop-ins
+func (g *systemdGraph) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	name, u, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(u.files) == 0 {
+		return nil, fmt.Errorf("'%s' has no unit file", name)
+	}
+	b := &bytes.Buffer{}
+	for i, file := range u.files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			fmt.Fprintf(b, "\n# %s\n", file)
+		}
+		b.Write(content)
+	}
+	return b, nil
+}
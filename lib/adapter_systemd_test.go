@@ -0,0 +1,61 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSystemdLink(t *testing.T) {
+	dir := t.TempDir()
+	units := map[string]string{
+		"a.service":    "[Unit]\nWants=foo@x.service\nAfter=foo@x.service\n",
+		"foo@.service": "[Unit]\nDescription=foo %i\nRequires=b.service\n",
+		"b.service":    "[Unit]\nDescription=b\n",
+	}
+	for name, content := range units {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, err := newSystemdGraph(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		view     dimension
+		node     string
+		outgoing string
+		incoming string
+	}{
+		{dimRequirement, "a.service", "foo@x.service", ""},
+		{dimRequirement, "foo@x.service", "b.service", "a.service"},
+		// the template is a unit of its own and declares the requirement as well
+		{dimRequirement, "b.service", "", "foo@.service foo@x.service"},
+		{dimOrdering, "foo@x.service", "a.service", ""},
+		{dimOrdering, "a.service", "", "foo@x.service"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.view)+" "+test.node, func(t *testing.T) {
+			err := g.DimensionSet(stringer(test.view))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := g.Outgoing(unitNode(test.node))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(out); got != test.outgoing {
+				t.Errorf("want outgoing '%s', but got '%s'", test.outgoing, got)
+			}
+			in, err := g.Incoming(unitNode(test.node))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(in); got != test.incoming {
+				t.Errorf("want incoming '%s', but got '%s'", test.incoming, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/treilik/walder"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TerraformDim is a generator for the dependency graph of a Terraform configuration.
+// The .tf files are only parsed, terraform is never run.
+type TerraformDim struct{}
+
+var _ walder.Dimensioner = TerraformDim{}
+
+func (d TerraformDim) String() string {
+	return "terraform"
+}
+
+func (d TerraformDim) New() (walder.Graph, error) {
+	return nil, fmt.Errorf("a terraform configuration needs to be opened from the filesystem")
+}
+
+var _ walder.NodeOpener = TerraformDim{}
+
+// NodeOpen parses the .tf files of the directory of the node, for a file its directory is used
+func (d TerraformDim) NodeOpen(nodes ...fmt.Stringer) (walder.Graph, error) {
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("need exactly one node, got %d", len(nodes))
+	}
+	node := nodes[0]
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	path := node.String()
+	if p, ok := node.(walder.Pather); ok {
+		var err error
+		path, err = p.Path()
+		if err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	return openTerraform(path)
+}
+
+const (
+	tfResource = "resource"
+	tfData     = "data"
+	tfModule   = "module"
+	tfVariable = "variable"
+	tfOutput   = "output"
+	tfLocal    = "local"
+	tfProvider = "provider"
+)
+
+// tfNode is a block named by its address like aws_instance.web, var.region or module.vpc
+type tfNode string
+
+func (t tfNode) String() string {
+	return string(t)
+}
+
+type tfBlock struct {
+	kind string
+	// labels are the block labels, for locals the name
+	labels []string
+	file   string
+	rng    hcl.Range
+	body   *hclsyntax.Body
+	// expr is only set for locals, which are attributes and not blocks
+	expr hclsyntax.Expression
+}
+
+type tfEdge struct {
+	from string
+	to   string
+	// attribute is the attribute path in which the reference is written
+	attribute string
+	dependsOn bool
+}
+
+type tfGraph struct {
+	dir     string
+	order   []string
+	blocks  map[string]*tfBlock
+	edges   []tfEdge
+	sources map[string][]byte
+}
+
+func openTerraform(dir string) (*tfGraph, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("'%s' contains no .tf files", dir)
+	}
+	sort.Strings(files)
+	g := &tfGraph{
+		dir:    dir,
+		blocks: make(map[string]*tfBlock),
+	}
+	parser := hclparse.NewParser()
+	for _, file := range files {
+		f, diags := parser.ParseHCLFile(file)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("want %T, but got %T", body, f.Body)
+		}
+		g.collect(file, body)
+	}
+	g.sources = parser.Sources()
+	g.link()
+	return g, nil
+}
+
+func (g *tfGraph) add(address string, b *tfBlock) {
+	if _, ok := g.blocks[address]; !ok {
+		g.order = append(g.order, address)
+	}
+	g.blocks[address] = b
+}
+
+func (g *tfGraph) collect(file string, body *hclsyntax.Body) {
+	for _, block := range body.Blocks {
+		b := &tfBlock{kind: block.Type, labels: block.Labels, file: file, rng: block.Range(), body: block.Body}
+		switch {
+		case block.Type == "locals":
+			for _, name := range sortedAttributes(block.Body) {
+				attr := block.Body.Attributes[name]
+				g.add("local."+name, &tfBlock{kind: tfLocal, labels: []string{name}, file: file, rng: attr.SrcRange, expr: attr.Expr})
+			}
+		case block.Type == tfResource && len(block.Labels) == 2:
+			g.add(block.Labels[0]+"."+block.Labels[1], b)
+		case block.Type == tfData && len(block.Labels) == 2:
+			g.add("data."+block.Labels[0]+"."+block.Labels[1], b)
+		case block.Type == tfModule && len(block.Labels) == 1:
+			g.add("module."+block.Labels[0], b)
+		case block.Type == tfVariable && len(block.Labels) == 1:
+			g.add("var."+block.Labels[0], b)
+		case block.Type == tfOutput && len(block.Labels) == 1:
+			g.add("output."+block.Labels[0], b)
+		case block.Type == tfProvider && len(block.Labels) == 1:
+			address := "provider." + block.Labels[0]
+			if alias := literalAttribute(block.Body, "alias"); alias != "" {
+				address += "." + alias
+			}
+			g.add(address, b)
+		}
+	}
+}
+
+func sortedAttributes(body *hclsyntax.Body) []string {
+	names := make([]string, 0, len(body.Attributes))
+	for name := range body.Attributes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		return body.Attributes[names[a]].SrcRange.Start.Byte < body.Attributes[names[b]].SrcRange.Start.Byte
+	})
+	return names
+}
+
+// literalAttribute returns the value of a attribute which is a plain string
+func literalAttribute(body *hclsyntax.Body, name string) string {
+	attr, ok := body.Attributes[name]
+	if !ok {
+		return ""
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !value.Type().Equals(cty.String) || value.IsNull() {
+		return ""
+	}
+	return value.AsString()
+}
+
+// link adds a edge for every reference to a known block
+func (g *tfGraph) link() {
+	for _, from := range g.order {
+		b := g.blocks[from]
+		if b.expr != nil {
+			g.references(from, b.labels[0], b.expr, false)
+			continue
+		}
+		g.walk(from, "", b.body)
+	}
+}
+
+func (g *tfGraph) walk(from, prefix string, body *hclsyntax.Body) {
+	for _, name := range sortedAttributes(body) {
+		expr := body.Attributes[name].Expr
+		if prefix == "" && (name == "provider" || name == "providers") {
+			g.providers(from, name, expr)
+			continue
+		}
+		g.references(from, prefix+name, expr, name == "depends_on" && prefix == "")
+	}
+	for _, block := range body.Blocks {
+		// provider meta blocks and dynamic blocks are walked like any other nested block
+		g.walk(from, prefix+block.Type+".", block.Body)
+	}
+}
+
+func (g *tfGraph) references(from, attribute string, expr hclsyntax.Expression, dependsOn bool) {
+	for _, traversal := range expr.Variables() {
+		to := g.resolve(traversal)
+		if to == "" || to == from {
+			continue
+		}
+		g.edges = append(g.edges, tfEdge{from: from, to: to, attribute: attribute, dependsOn: dependsOn})
+	}
+}
+
+// providers adds the edges of the provider meta arguments, which name the provider without the provider prefix
+func (g *tfGraph) providers(from, attribute string, expr hclsyntax.Expression) {
+	for _, traversal := range expr.Variables() {
+		parts := traversalNames(traversal)
+		if len(parts) > 2 {
+			parts = parts[:2]
+		}
+		to := "provider." + strings.Join(parts, ".")
+		if _, ok := g.blocks[to]; !ok {
+			continue
+		}
+		g.edges = append(g.edges, tfEdge{from: from, to: to, attribute: attribute})
+	}
+}
+
+// traversalNames returns the root and the attribute names up to the first index
+func traversalNames(traversal hcl.Traversal) []string {
+	parts := []string{traversal.RootName()}
+	for _, step := range traversal[1:] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		parts = append(parts, attr.Name)
+	}
+	return parts
+}
+
+// resolve returns the address of the block a traversal references or "" if it is no block
+func (g *tfGraph) resolve(traversal hcl.Traversal) string {
+	parts := traversalNames(traversal)
+	var address string
+	switch parts[0] {
+	case "var", "local", "module":
+		if len(parts) < 2 {
+			return ""
+		}
+		address = strings.Join(parts[:2], ".")
+	case "data":
+		if len(parts) < 3 {
+			return ""
+		}
+		address = strings.Join(parts[:3], ".")
+	case "count", "each", "self", "path", "terraform":
+		return ""
+	default:
+		if len(parts) < 2 {
+			return ""
+		}
+		address = strings.Join(parts[:2], ".")
+	}
+	if _, ok := g.blocks[address]; !ok {
+		return ""
+	}
+	return address
+}
+
+func (g *tfGraph) lookup(str fmt.Stringer) (string, *tfBlock, error) {
+	if str == nil {
+		return "", nil, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(tfNode)
+	if !ok {
+		return "", nil, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	b, ok := g.blocks[string(n)]
+	if !ok {
+		return "", nil, fmt.Errorf("'%s' is not part of this configuration", n)
+	}
+	return string(n), b, nil
+}
+
+var _ walder.Graph = &tfGraph{}
+
+func (g *tfGraph) String() string {
+	return fmt.Sprintf("terraform: %s", filepath.Base(g.dir))
+}
+
+func (g *tfGraph) HomeNodes() ([]fmt.Stringer, error) {
+	return g.NodeAll()
+}
+
+var _ walder.NodeAller = &tfGraph{}
+
+func (g *tfGraph) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(g.order))
+	for _, address := range g.order {
+		all = append(all, tfNode(address))
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &tfGraph{}
+
+// Outgoing returns the blocks referenced by the node
+func (g *tfGraph) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	address, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.from == address && !seen[e.to] {
+			seen[e.to] = true
+			out = append(out, tfNode(e.to))
+		}
+	}
+	return out, nil
+}
+
+// Incoming returns the blocks referencing the node
+func (g *tfGraph) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	address, _, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range g.edges {
+		if e.to == address && !seen[e.from] {
+			seen[e.from] = true
+			in = append(in, tfNode(e.from))
+		}
+	}
+	return in, nil
+}
+
+var _ walder.EdgeLabeler = &tfGraph{}
+
+// EdgeLabels returns the attributes holding the references, explicit dependencies are labeled depends_on
+func (g *tfGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	f, _, err := g.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+	t, _, err := g.lookup(to)
+	if err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	for _, e := range g.edges {
+		if e.from != f || e.to != t {
+			continue
+		}
+		key := "reference"
+		if e.dependsOn {
+			key = "depends_on"
+		}
+		labels = append(labels, [2]string{key, e.attribute})
+	}
+	if labels == nil {
+		return nil, fmt.Errorf("'%s' does not reference '%s'", f, t)
+	}
+	return labels, nil
+}
+
+var _ walder.Typer = &tfGraph{}
+
+// GetType returns the kind of block like resource, data or module
+func (g *tfGraph) GetType(str fmt.Stringer) (string, error) {
+	_, b, err := g.lookup(str)
+	if err != nil {
+		return "", err
+	}
+	return b.kind, nil
+}
+
+var _ walder.NodeLabeler = &tfGraph{}
+
+func (g *tfGraph) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	_, b, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	labels := [][2]string{
+		{"file", filepath.Base(b.file)},
+		{"line", fmt.Sprint(b.rng.Start.Line)},
+	}
+	switch b.kind {
+	case tfResource, tfData:
+		labels = append(labels, [2]string{"type", b.labels[0]})
+	}
+	if b.body == nil {
+		return labels, nil
+	}
+	for _, name := range []string{"source", "version", "description", "provider"} {
+		if value := literalAttribute(b.body, name); value != "" {
+			labels = append(labels, [2]string{name, value})
+		}
+	}
+	return labels, nil
+}
+
+var _ walder.NodeReader = &tfGraph{}
+
+// NodeRead returns the source of the block
+func (g *tfGraph) NodeRead(str fmt.Stringer) (io.Reader, error) {
+	_, b, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	src, ok := g.sources[b.file]
+	if !ok || b.rng.End.Byte > len(src) {
+		return nil, fmt.Errorf("the source of '%s' is not known", b.file)
+	}
+	return strings.NewReader(string(b.rng.SliceBytes(src))), nil
+}
+
+var _ walder.Dimensions = &tfGraph{}
+
+// Dimensions returns the configuration of a module which is stored in a local directory
+func (g *tfGraph) Dimensions(str fmt.Stringer) ([]walder.Graph, error) {
+	address, b, err := g.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if b.kind != tfModule {
+		return nil, fmt.Errorf("'%s' is no module", address)
+	}
+	source := literalAttribute(b.body, "source")
+	if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		return nil, fmt.Errorf("the source '%s' of '%s' is not a local directory", source, address)
+	}
+	child, err := openTerraform(filepath.Join(g.dir, source))
+	if err != nil {
+		return nil, err
+	}
+	return []walder.Graph{child}, nil
+}
@@ -0,0 +1,231 @@
+package lib
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openTerraformFiles writes the files into a temporary directory and opens it
+func openTerraformFiles(t *testing.T, files map[string]string) *tfGraph {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, err := TerraformDim{}.NodeOpen(stringer(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g.(*tfGraph)
+}
+
+const terraformMain = `provider "aws" {
+  region = var.region
+}
+
+provider "aws" {
+  alias  = "east"
+  region = "us-east-1"
+}
+
+resource "aws_instance" "web" {
+  provider      = aws.east
+  ami           = data.aws_ami.base.id
+  instance_type = local.size
+  subnet_id     = module.vpc.subnet_id
+  tags = {
+    Name = "web-${count.index}"
+  }
+  network_interface {
+    device_index = aws_network_interface.nic[0].id
+  }
+  depends_on = [aws_s3_bucket.logs]
+}
+
+resource "aws_network_interface" "nic" {}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "${var.region}-logs"
+}
+
+data "aws_ami" "base" {
+  owners = [var.owner]
+}
+
+module "vpc" {
+  source = "./vpc"
+  providers = {
+    aws = aws.east
+  }
+}
+
+module "remote" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+`
+
+const terraformVariables = `variable "region" {
+  description = "where to deploy"
+}
+
+variable "owner" {}
+
+locals {
+  size  = "t3.${local.class}"
+  class = "micro"
+}
+
+output "ip" {
+  value = aws_instance.web.public_ip
+}
+`
+
+func TestTerraformReferences(t *testing.T) {
+	g := openTerraformFiles(t, map[string]string{
+		"main.tf":      terraformMain,
+		"variables.tf": terraformVariables,
+		"vpc/main.tf":  "output \"subnet_id\" {\n  value = \"x\"\n}\n",
+		"notes.txt":    "resource \"ignored\" \"x\" {}\n",
+	})
+	tests := []struct {
+		node   string
+		kind   string
+		out    string
+		labels map[string]string
+	}{
+		{
+			node: "aws_instance.web", kind: tfResource,
+			out: "aws_network_interface.nic aws_s3_bucket.logs data.aws_ami.base local.size module.vpc provider.aws.east",
+			labels: map[string]string{
+				"data.aws_ami.base":         "reference:ami",
+				"aws_network_interface.nic": "reference:network_interface.device_index",
+				"aws_s3_bucket.logs":        "depends_on:depends_on",
+				"provider.aws.east":         "reference:provider",
+			},
+		},
+		{node: "provider.aws", kind: tfProvider, out: "var.region"},
+		{node: "provider.aws.east", kind: tfProvider, out: ""},
+		{node: "aws_s3_bucket.logs", kind: tfResource, out: "var.region"},
+		{node: "data.aws_ami.base", kind: tfData, out: "var.owner"},
+		{node: "local.size", kind: tfLocal, out: "local.class", labels: map[string]string{"local.class": "reference:size"}},
+		{node: "output.ip", kind: tfOutput, out: "aws_instance.web"},
+		{node: "module.vpc", kind: tfModule, out: "provider.aws.east", labels: map[string]string{"provider.aws.east": "reference:providers"}},
+		{node: "aws_network_interface.nic", kind: tfResource, out: ""},
+		{node: "var.region", kind: tfVariable, out: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.node, func(t *testing.T) {
+			n := tfNode(tt.node)
+			kind, err := g.GetType(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kind != tt.kind {
+				t.Errorf("want kind '%s', but got '%s'", tt.kind, kind)
+			}
+			out, err := g.Outgoing(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(out); got != tt.out {
+				t.Errorf("want outgoing '%s', but got '%s'", tt.out, got)
+			}
+			for to, want := range tt.labels {
+				labels, err := g.EdgeLabels(n, tfNode(to))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := labels[0][0] + ":" + labels[0][1]; got != want {
+					t.Errorf("want label '%s' to '%s', but got '%s'", want, to, got)
+				}
+			}
+		})
+	}
+
+	labels, err := g.NodeLabels(tfNode("var.region"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file, _ := labelValue(labels, "file"); file != "variables.tf" {
+		t.Errorf("want file 'variables.tf', but got '%s'", file)
+	}
+	if description, _ := labelValue(labels, "description"); description != "where to deploy" {
+		t.Errorf("want description 'where to deploy', but got '%s'", description)
+	}
+	r, err := g.NodeRead(tfNode("var.owner"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src, _ := io.ReadAll(r); string(src) != "variable \"owner\" {}" {
+		t.Errorf("want the source of the block, but got %q", src)
+	}
+}
+
+func TestTerraformModules(t *testing.T) {
+	g := openTerraformFiles(t, map[string]string{
+		"main.tf":     terraformMain,
+		"vpc/main.tf": "output \"subnet_id\" {\n  value = \"x\"\n}\n",
+	})
+	tests := []struct {
+		node    string
+		want    string
+		wantErr bool
+	}{
+		{node: "module.vpc", want: "output.subnet_id"},
+		{node: "module.remote", wantErr: true},
+		{node: "aws_s3_bucket.logs", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.node, func(t *testing.T) {
+			children, err := g.Dimensions(tfNode(tt.node))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			all, err := children[0].(*tfGraph).NodeAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(all); got != tt.want {
+				t.Errorf("want nodes '%s', but got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTerraformErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"no tf files", map[string]string{"main.txt": ""}},
+		{"invalid", map[string]string{"main.tf": "resource \"a\" {"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := (TerraformDim{}).NodeOpen(stringer(dir)); err == nil {
+				t.Error("want error, but got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/treilik/walder"
+)
+
+// indexGraph is a copy of the edges of a graph with the nodes numbered in the order of NodeAll,
+// the algorithms which have to visit every node many times work on it instead of the origin
+type indexGraph struct {
+	nodes []fmt.Stringer
+	out   [][]int
+	in    [][]int
+}
+
+func newIndexGraph(directed walder.GraphOutgoing) (*indexGraph, error) {
+	if directed == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	x, err := newEdgeIndex(directed, false)
+	if err != nil {
+		return nil, err
+	}
+	g := &indexGraph{
+		nodes: x.nodes,
+		out:   make([][]int, len(x.nodes)),
+		in:    make([][]int, len(x.nodes)),
+	}
+	err = x.walk(func(i, j int) error {
+		g.out[i] = append(g.out[i], j)
+		g.in[j] = append(g.in[j], i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// edgeIndex numbers the nodes of a graph in the order of NodeAll,
+// so the algorithms can read the edges by the indices of there ends
+type edgeIndex struct {
+	nodes []fmt.Stringer
+	index map[string]int
+	// undirected is true if the edges have no direction, because it was asked for or the graph says so
+	undirected bool
+	edgesOf    func(fmt.Stringer) ([]fmt.Stringer, error)
+}
+
+// newEdgeIndex reads the outgoing edges of a walder.GraphOutgoing or else the neighbors of a walder.GraphNeighbors.
+// With undirected the neighbors are preferred and of a walder.GraphDirected both directions are read.
+// Undirected dot graphs and graphs with only neighbors are always marked as undirected.
+func newEdgeIndex(g walder.Graph, undirected bool) (*edgeIndex, error) {
+	if g == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	aller, ok := g.(walder.NodeAller)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement %s", g, nodeAllerString)
+	}
+	x := &edgeIndex{undirected: undirected}
+	if d, ok := g.(*DotGraph); ok && !d.graph.Directed {
+		x.undirected = true
+	}
+	neighbors, hasNeighbors := g.(walder.GraphNeighbors)
+	switch v := g.(type) {
+	case walder.GraphDirected:
+		x.edgesOf = v.Outgoing
+		if hasNeighbors && undirected {
+			x.edgesOf = neighbors.Neighbors
+		} else if undirected {
+			x.edgesOf = func(node fmt.Stringer) ([]fmt.Stringer, error) {
+				out, err := v.Outgoing(node)
+				if err != nil {
+					return nil, err
+				}
+				in, err := v.Incoming(node)
+				if err != nil {
+					return nil, err
+				}
+				return append(out, in...), nil
+			}
+		}
+	case walder.GraphOutgoing:
+		x.edgesOf = v.Outgoing
+		if hasNeighbors && undirected {
+			x.edgesOf = neighbors.Neighbors
+		}
+	case walder.GraphNeighbors:
+		x.edgesOf = v.Neighbors
+		x.undirected = true
+	default:
+		return nil, fmt.Errorf("want %s or %s, but got %T", graphNeighborsString, graphOutgoingString, g)
+	}
+	all, err := aller.NodeAll()
+	if err != nil {
+		return nil, err
+	}
+	x.nodes = all
+	x.index = make(map[string]int, len(all))
+	for i, n := range all {
+		x.index[n.String()] = i
+	}
+	return x, nil
+}
+
+// walk calls visit for every node and every node its edges lead to, parallel edges are visited once
+func (x *edgeIndex) walk(visit func(i, j int) error) error {
+	for i, n := range x.nodes {
+		nodes, err := x.edgesOf(n)
+		if err != nil {
+			return err
+		}
+		seen := make(map[int]bool)
+		for _, o := range nodes {
+			j, ok := x.index[o.String()]
+			if !ok {
+				return fmt.Errorf("'%s' was not returned by NodeAll", o)
+			}
+			if seen[j] {
+				continue
+			}
+			seen[j] = true
+			err := visit(i, j)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scores maps the scores to the strings of the nodes
+func (g *indexGraph) scores(values []float64) map[string]float64 {
+	scores := make(map[string]float64, len(values))
+	for i, v := range values {
+		scores[g.nodes[i].String()] = v
+	}
+	return scores
+}
+
+// distances returns the amount of edges from the source to every node, or -1 if it is not reachable
+func (g *indexGraph) distances(source int) []int {
+	distance := make([]int, len(g.nodes))
+	for i := range distance {
+		distance[i] = -1
+	}
+	distance[source] = 0
+	queue := []int{source}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range g.out[v] {
+			if distance[w] < 0 {
+				distance[w] = distance[v] + 1
+				queue = append(queue, w)
+			}
+		}
+	}
+	return distance
+}
+
+const (
+	rankIterations = 100
+	rankTolerance  = 1e-9
+)
+
+// PageRank returns the stationary distribution of a random walk along the edges,
+// which jumps to a random node with the probability of 1 - damping.
+// Nodes without outgoing edges jump to a random node.
+func PageRank(directed walder.GraphDirected, damping float64) (map[string]float64, error) {
+	if damping < 0 || damping > 1 {
+		return nil, fmt.Errorf("the damping has to be between 0 and 1, but is %g", damping)
+	}
+	g, err := newIndexGraph(directed)
+	if err != nil {
+		return nil, err
+	}
+	n := float64(len(g.nodes))
+	rank := make([]float64, len(g.nodes))
+	for i := range rank {
+		rank[i] = 1 / n
+	}
+	for iteration := 0; iteration < rankIterations; iteration++ {
+		var dangling float64
+		for v, out := range g.out {
+			if len(out) == 0 {
+				dangling += rank[v]
+			}
+		}
+		next := make([]float64, len(rank))
+		for v := range next {
+			next[v] = (1-damping)/n + damping*dangling/n
+			for _, u := range g.in[v] {
+				next[v] += damping * rank[u] / float64(len(g.out[u]))
+			}
+		}
+		var diff float64
+		for v := range next {
+			diff += math.Abs(next[v] - rank[v])
+		}
+		rank = next
+		if diff < rankTolerance {
+			break
+		}
+	}
+	return g.scores(rank), nil
+}
+
+// Betweenness returns for every node the amount of shortest paths between other nodes which pass through it,
+// paths with the same length share the count. It is computed with the algorithm of Brandes.
+func Betweenness(directed walder.GraphDirected) (map[string]float64, error) {
+	g, err := newIndexGraph(directed)
+	if err != nil {
+		return nil, err
+	}
+	n := len(g.nodes)
+	betweenness := make([]float64, n)
+	for s := 0; s < n; s++ {
+		var order []int
+		predecessors := make([][]int, n)
+		paths := make([]float64, n)
+		distance := make([]int, n)
+		for i := range distance {
+			distance[i] = -1
+		}
+		paths[s] = 1
+		distance[s] = 0
+
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			order = append(order, v)
+			for _, w := range g.out[v] {
+				if distance[w] < 0 {
+					distance[w] = distance[v] + 1
+					queue = append(queue, w)
+				}
+				if distance[w] == distance[v]+1 {
+					paths[w] += paths[v]
+					predecessors[w] = append(predecessors[w], v)
+				}
+			}
+		}
+
+		// accumulate the dependencies starting with the farthest nodes
+		dependency := make([]float64, n)
+		for i := len(order) - 1; i >= 0; i-- {
+			w := order[i]
+			for _, v := range predecessors[w] {
+				dependency[v] += paths[v] / paths[w] * (1 + dependency[w])
+			}
+			if w != s {
+				betweenness[w] += dependency[w]
+			}
+		}
+	}
+	return g.scores(betweenness), nil
+}
+
+// Closeness returns the inverse of the average distance to the reachable nodes,
+// scaled by the reachable part of the graph so nodes which reach only few nodes are not favored.
+func Closeness(directed walder.GraphDirected) (map[string]float64, error) {
+	g, err := newIndexGraph(directed)
+	if err != nil {
+		return nil, err
+	}
+	n := len(g.nodes)
+	closeness := make([]float64, n)
+	for v := range g.nodes {
+		var sum, reachable int
+		for _, d := range g.distances(v) {
+			if d > 0 {
+				sum += d
+				reachable++
+			}
+		}
+		if sum == 0 {
+			continue
+		}
+		closeness[v] = float64(reachable) / float64(sum) * float64(reachable) / float64(n-1)
+	}
+	return g.scores(closeness), nil
+}
+
+// Eigenvector returns the eigenvector centrality, so nodes pointed to by important nodes are important.
+// The iteration adds the former scores, so it converges on acyclic graphs too.
+func Eigenvector(directed walder.GraphDirected) (map[string]float64, error) {
+	g, err := newIndexGraph(directed)
+	if err != nil {
+		return nil, err
+	}
+	n := len(g.nodes)
+	if n == 0 {
+		return map[string]float64{}, nil
+	}
+	score := make([]float64, n)
+	for i := range score {
+		score[i] = 1 / float64(n)
+	}
+	for iteration := 0; iteration < rankIterations; iteration++ {
+		next := make([]float64, n)
+		copy(next, score)
+		for v := range next {
+			for _, u := range g.in[v] {
+				next[v] += score[u]
+			}
+		}
+		var norm float64
+		for _, s := range next {
+			norm += s * s
+		}
+		norm = math.Sqrt(norm)
+		var diff float64
+		for v := range next {
+			next[v] /= norm
+			diff += math.Abs(next[v] - score[v])
+		}
+		score = next
+		if diff < float64(n)*rankTolerance {
+			break
+		}
+	}
+	return g.scores(score), nil
+}
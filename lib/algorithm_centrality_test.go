@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"math"
+	"testing"
+
+	"github.com/treilik/walder"
+)
+
+func TestCentrality(t *testing.T) {
+	pageRank := func(g walder.GraphDirected) (map[string]float64, error) { return PageRank(g, pageRankDamping) }
+	tests := []struct {
+		name    string
+		measure func(walder.GraphDirected) (map[string]float64, error)
+		source  string
+		want    map[string]float64
+	}{
+		{"pagerank cycle", pageRank, `digraph{a->b->c->a}`, map[string]float64{"a": 1.0 / 3, "b": 1.0 / 3, "c": 1.0 / 3}},
+		{"pagerank unconnected", pageRank, `digraph{a; b}`, map[string]float64{"a": 0.5, "b": 0.5}},
+		// b has no outgoing edges, so a gets the jump share of b and b the share of a plus the edge
+		{"pagerank dangling", pageRank, `digraph{a->b}`, map[string]float64{"a": 1 / 2.85, "b": 1.85 / 2.85}},
+		{"betweenness chain", Betweenness, `digraph{a->b->c}`, map[string]float64{"a": 0, "b": 1, "c": 0}},
+		{"betweenness diamond", Betweenness, `digraph{a->b; a->c; b->d; c->d}`, map[string]float64{"a": 0, "b": 0.5, "c": 0.5, "d": 0}},
+		{"betweenness multi edge", Betweenness, `digraph{a->b; a->b; b->c}`, map[string]float64{"b": 1}},
+		{"closeness chain", Closeness, `digraph{a->b->c}`, map[string]float64{"a": 2.0 / 3, "b": 0.5, "c": 0}},
+		{"closeness single", Closeness, `digraph{a}`, map[string]float64{"a": 0}},
+		{"eigenvector cycle", Eigenvector, `digraph{a->b->c->a}`, map[string]float64{"a": 1 / math.Sqrt(3), "b": 1 / math.Sqrt(3), "c": 1 / math.Sqrt(3)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scores, err := test.measure(openDot(t, test.source))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range test.want {
+				got, ok := scores[name]
+				if !ok {
+					t.Fatalf("no score for '%s'", name)
+				}
+				if math.Abs(got-want) > 1e-6 {
+					t.Errorf("want score of %s %g, but got %g", name, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCentralityOrder(t *testing.T) {
+	// the nodes pointed to by more nodes are ranked higher
+	d := openDot(t, `digraph{a->hub; b->hub; c->hub; hub->d; a->d}`)
+	for name, measure := range map[string]func(walder.GraphDirected) (map[string]float64, error){
+		"pagerank":    func(g walder.GraphDirected) (map[string]float64, error) { return PageRank(g, pageRankDamping) },
+		"eigenvector": Eigenvector,
+	} {
+		t.Run(name, func(t *testing.T) {
+			scores, err := measure(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scores["hub"] <= scores["a"] || scores["d"] <= scores["b"] {
+				t.Errorf("want hub and d ranked above the sources, but got %v", scores)
+			}
+		})
+	}
+	if _, err := PageRank(d, 1.5); err == nil {
+		t.Error("want error for a damping above 1, but got none")
+	}
+}
+
+func TestCentralityLabels(t *testing.T) {
+	d := openDot(t, `digraph{a[color=red]; a->b->c}`)
+	c, err := newCentrality(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels, err := c.NodeLabels(dotNode(t, d, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"betweenness": "1", "closeness": "0.5"}
+	for key, value := range want {
+		if got, ok := labelValue(labels, key); !ok || got != value {
+			t.Errorf("want %s '%s', but got '%s'", key, value, got)
+		}
+	}
+	for _, key := range []string{"pagerank", "eigenvector"} {
+		if _, ok := labelValue(labels, key); !ok {
+			t.Errorf("want label '%s', but got %v", key, labels)
+		}
+	}
+	labels, err = c.NodeLabels(dotNode(t, d, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := labelValue(labels, "color"); got != "red" {
+		t.Errorf("want the labels of the origin first, but got %v", labels)
+	}
+	// nodes added later have no scores
+	if _, err := d.NodeCreate(stringer("new")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.NodeLabels(dotNode(t, d, "new")); err == nil {
+		t.Error("want error for a node without scores, but got none")
+	}
+}
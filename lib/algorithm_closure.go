@@ -0,0 +1,67 @@
+package lib
+
+import (
+	"fmt"
+
+	"github.com/treilik/walder"
+)
+
+// transitiveClosure adds a edge to 'into' for every node reachable from an other one in 'from',
+// which is the opposite of the reduction. The nodes are looked up in 'into' by there string,
+// so 'into' has to contain them already, which is the case if both are the same graph.
+func transitiveClosure(from directedAller, into walder.EdgeCreater) error {
+	if from == nil || into == nil {
+		return fmt.Errorf("recieved nil value")
+	}
+	all, err := from.NodeAll()
+	if err != nil {
+		return err
+	}
+
+	// the closure is computed completely before writing, since 'into' might be 'from'
+	c := newClosure(from)
+	var edges [][2]fmt.Stringer
+	for _, a := range all {
+		reached, err := c.Outgoing(a)
+		if err != nil {
+			return err
+		}
+		for _, r := range reached {
+			edges = append(edges, [2]fmt.Stringer{a, r})
+		}
+	}
+
+	// existing edges are not created again, if they can be read
+	existing, _ := into.(walder.GraphOutgoing)
+	for _, e := range edges {
+		newFrom, err := into.NodeUpdate(e[0])
+		if err != nil {
+			return err
+		}
+		newTo, err := into.NodeUpdate(e[1])
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			out, err := existing.Outgoing(newFrom)
+			if err != nil {
+				return err
+			}
+			found := false
+			for _, o := range out {
+				if o.String() == newTo.String() {
+					found = true
+					break
+				}
+			}
+			if found {
+				continue
+			}
+		}
+		err = into.EdgeCreate(newFrom, newTo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"testing"
+)
+
+func TestClosure(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		// the nodes are asked in this order to use the remembered ones
+		order []string
+		out   map[string]string
+		in    map[string]string
+	}{
+		{
+			name:   "chain",
+			source: `digraph{a->b->c}`,
+			order:  []string{"b", "a", "c"},
+			out:    map[string]string{"a": "b c", "b": "c", "c": ""},
+			in:     map[string]string{"a": "", "b": "a", "c": "a b"},
+		},
+		{
+			name:   "cycle",
+			source: `digraph{a->b->c->a; c->d}`,
+			order:  []string{"c", "a", "d"},
+			out:    map[string]string{"a": "a b c d", "c": "a b c d", "d": ""},
+			in:     map[string]string{"a": "a b c", "d": "a b c"},
+		},
+		{
+			name:   "diamond",
+			source: `digraph{a->b; a->c; b->d; c->d}`,
+			order:  []string{"b", "c", "a"},
+			out:    map[string]string{"a": "b c d", "b": "d", "c": "d"},
+			in:     map[string]string{"d": "a b c", "b": "a"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			c := newClosure(d)
+			for _, name := range test.order {
+				n := dotNode(t, d, name)
+				if want, ok := test.out[name]; ok {
+					out, err := c.Outgoing(n)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got := sortedNames(out); got != want {
+						t.Errorf("want outgoing of %s '%s', but got '%s'", name, want, got)
+					}
+				}
+				if want, ok := test.in[name]; ok {
+					in, err := c.Incoming(n)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got := sortedNames(in); got != want {
+						t.Errorf("want incoming of %s '%s', but got '%s'", name, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTransitiveClosure(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		out    map[string]string
+	}{
+		{"chain", `digraph{a->b->c->d}`, map[string]string{"a": "b c d", "b": "c d", "d": ""}},
+		{"existing shortcut", `digraph{a->b->c; a->c}`, map[string]string{"a": "b c", "b": "c"}},
+		{"cycle", `digraph{a->b->a}`, map[string]string{"a": "a b", "b": "a b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			if err := transitiveClosure(d, d); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range test.out {
+				out, err := d.Outgoing(dotNode(t, d, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := sortedNames(out); got != want {
+					t.Errorf("want outgoing of %s '%s', but got '%s'", name, want, got)
+				}
+			}
+		})
+	}
+}
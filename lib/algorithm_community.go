@@ -0,0 +1,259 @@
+package lib
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/treilik/walder"
+)
+
+// The community detection ignores the direction of the edges, every edge weighs 1.
+
+// Louvain returns the communities found by the Louvain method,
+// which moves nodes between communities as long as the modularity grows
+// and then repeats this on the graph of the communities.
+func Louvain(directed walder.GraphOutgoing) ([][]fmt.Stringer, error) {
+	g, err := newIndexGraph(directed)
+	if err != nil {
+		return nil, err
+	}
+	l := newLouvainLevel(g)
+	// membership maps the nodes of the origin to the nodes of the current level
+	membership := make([]int, len(g.nodes))
+	for i := range membership {
+		membership[i] = i
+	}
+	for {
+		community, moved := l.move()
+		if !moved {
+			break
+		}
+		var next *louvainLevel
+		next, community = l.aggregate(community)
+		for i, m := range membership {
+			membership[i] = community[m]
+		}
+		l = next
+	}
+	return g.groups(membership), nil
+}
+
+// LabelPropagation returns the communities found by letting every node take the most common label of its neighbors,
+// until the labels don't change anymore.
+func LabelPropagation(directed walder.GraphOutgoing) ([][]fmt.Stringer, error) {
+	g, err := newIndexGraph(directed)
+	if err != nil {
+		return nil, err
+	}
+	label := make([]int, len(g.nodes))
+	order := make([]int, len(g.nodes))
+	for i := range label {
+		label[i] = i
+		order[i] = i
+	}
+	// the order and the ties are random as usual, but with a fixed seed to get the same communities every time
+	random := rand.New(rand.NewSource(1))
+	for iteration := 0; iteration < rankIterations; iteration++ {
+		changed := false
+		random.Shuffle(len(order), func(a, b int) { order[a], order[b] = order[b], order[a] })
+		for _, v := range order {
+			count := make(map[int]int)
+			var labels []int
+			bestCount := 0
+			for _, neighbors := range [][]int{g.out[v], g.in[v]} {
+				for _, w := range neighbors {
+					if count[label[w]] == 0 {
+						labels = append(labels, label[w])
+					}
+					count[label[w]]++
+					if count[label[w]] > bestCount {
+						bestCount = count[label[w]]
+					}
+				}
+			}
+			// the current label is kept on ties
+			if count[label[v]] == bestCount {
+				continue
+			}
+			var best []int
+			for _, l := range labels {
+				if count[l] == bestCount {
+					best = append(best, l)
+				}
+			}
+			label[v] = best[random.Intn(len(best))]
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+	return g.groups(label), nil
+}
+
+// groups returns the nodes with the same group together, in the order of there first member
+func (g *indexGraph) groups(group []int) [][]fmt.Stringer {
+	index := make(map[int]int)
+	var groups [][]fmt.Stringer
+	for v, c := range group {
+		i, ok := index[c]
+		if !ok {
+			i = len(groups)
+			index[c] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], g.nodes[v])
+	}
+	return groups
+}
+
+type louvainEdge struct {
+	to     int
+	weight float64
+}
+
+// louvainLevel is a undirected weighted graph, for the first level the origin
+// and for the following ones the graph of the communities of the former level
+type louvainLevel struct {
+	edges [][]louvainEdge
+	// loops are the weights of the edges inside a node
+	loops  []float64
+	degree []float64
+	// total is the doubled sum of all weights
+	total float64
+}
+
+func newLouvainLevel(g *indexGraph) *louvainLevel {
+	weights := make([]map[int]float64, len(g.nodes))
+	for v := range weights {
+		weights[v] = make(map[int]float64)
+	}
+	for v, out := range g.out {
+		for _, w := range out {
+			weights[v][w]++
+			weights[w][v]++
+		}
+	}
+	// the order of the neighbors is the order of the edges to stay deterministic
+	order := make([][]int, len(g.nodes))
+	for v, out := range g.out {
+		order[v] = append(order[v], out...)
+		order[v] = append(order[v], g.in[v]...)
+	}
+	return newLouvainFrom(weights, order)
+}
+
+func newLouvainFrom(weights []map[int]float64, order [][]int) *louvainLevel {
+	l := &louvainLevel{
+		edges:  make([][]louvainEdge, len(weights)),
+		loops:  make([]float64, len(weights)),
+		degree: make([]float64, len(weights)),
+	}
+	for v := range weights {
+		seen := make(map[int]bool)
+		for _, w := range order[v] {
+			if seen[w] {
+				continue
+			}
+			seen[w] = true
+			if w == v {
+				// a loop was counted from both sides
+				l.loops[v] = weights[v][w] / 2
+				l.degree[v] += weights[v][w]
+				continue
+			}
+			l.edges[v] = append(l.edges[v], louvainEdge{to: w, weight: weights[v][w]})
+			l.degree[v] += weights[v][w]
+		}
+		l.total += l.degree[v]
+	}
+	return l
+}
+
+// move returns the community of every node after moving the nodes to the neighboring community
+// which increases the modularity the most
+func (l *louvainLevel) move() ([]int, bool) {
+	n := len(l.edges)
+	community := make([]int, n)
+	tot := make([]float64, n)
+	for v := range community {
+		community[v] = v
+		tot[v] = l.degree[v]
+	}
+	if l.total == 0 {
+		return community, false
+	}
+
+	improved := false
+	for moved := true; moved; {
+		moved = false
+		for v := 0; v < n; v++ {
+			former := community[v]
+			tot[former] -= l.degree[v]
+
+			// the weights from v to the neighboring communities in order of appearance
+			weights := map[int]float64{former: 0}
+			candidates := []int{former}
+			for _, e := range l.edges[v] {
+				c := community[e.to]
+				if _, ok := weights[c]; !ok {
+					candidates = append(candidates, c)
+				}
+				weights[c] += e.weight
+			}
+
+			best := former
+			bestGain := weights[former] - tot[former]*l.degree[v]/l.total
+			for _, c := range candidates {
+				gain := weights[c] - tot[c]*l.degree[v]/l.total
+				if gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+			tot[best] += l.degree[v]
+			community[v] = best
+			if best != former {
+				moved = true
+				improved = true
+			}
+		}
+	}
+	return community, improved
+}
+
+// aggregate returns the graph of the communities and the renumbered community of every node
+func (l *louvainLevel) aggregate(community []int) (*louvainLevel, []int) {
+	number := make(map[int]int)
+	renumbered := make([]int, len(community))
+	for v, c := range community {
+		i, ok := number[c]
+		if !ok {
+			i = len(number)
+			number[c] = i
+		}
+		renumbered[v] = i
+	}
+
+	weights := make([]map[int]float64, len(number))
+	order := make([][]int, len(number))
+	for i := range weights {
+		weights[i] = make(map[int]float64)
+	}
+	add := func(from, to int, weight float64) {
+		if _, ok := weights[from][to]; !ok {
+			order[from] = append(order[from], to)
+		}
+		weights[from][to] += weight
+	}
+	for v, edges := range l.edges {
+		c := renumbered[v]
+		if l.loops[v] > 0 {
+			add(c, c, 2*l.loops[v])
+		}
+		for _, e := range edges {
+			// every edge is seen from both sides, which doubles the loops as newLouvainFrom expects
+			add(c, renumbered[e.to], e.weight)
+		}
+	}
+	return newLouvainFrom(weights, order), renumbered
+}
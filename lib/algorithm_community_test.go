@@ -0,0 +1,63 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/treilik/walder"
+)
+
+func TestCommunities(t *testing.T) {
+	algorithms := map[string]func(walder.GraphOutgoing) ([][]fmt.Stringer, error){
+		"louvain":           Louvain,
+		"label propagation": LabelPropagation,
+	}
+	tests := []struct {
+		name   string
+		source string
+		// the communities in order of there first member, the members sorted
+		want string
+	}{
+		{"two triangles", `digraph{a->b->c->a; d->e->f->d; c->d}`, "a b c|d e f"},
+		{"direction is ignored", `digraph{a->b; c->b; a->c; d->e; f->e; f->d; e->c}`, "a b c|d e f"},
+		{"unconnected", `digraph{a; b; c}`, "a|b|c"},
+		{"pairs", `digraph{a->b; c->d}`, "a b|c d"},
+		{"self loops", `digraph{a->a; a->b; c->c; c->d}`, "a b|c d"},
+		{"empty", `digraph{}`, ""},
+	}
+	for name, algorithm := range algorithms {
+		for _, test := range tests {
+			t.Run(name+" "+test.name, func(t *testing.T) {
+				groups, err := algorithm(openDot(t, test.source))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := make([]string, 0, len(groups))
+				for _, g := range groups {
+					got = append(got, sortedNames(g))
+				}
+				if strings.Join(got, "|") != test.want {
+					t.Errorf("want communities '%s', but got '%s'", test.want, strings.Join(got, "|"))
+				}
+			})
+		}
+	}
+}
+
+func TestCommunitiesDeterministic(t *testing.T) {
+	const source = `digraph{a->b->c->a; c->d; d->e->f->d; f->g; g->h->i->g; i->a}`
+	first, err := LabelPropagation(openDot(t, source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		again, err := LabelPropagation(openDot(t, source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(again) != fmt.Sprint(first) {
+			t.Fatalf("want the same communities every time, but got %v and %v", first, again)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/treilik/walder"
+)
+
+// DurationLabel is the node label which holds the duration of a task, nodes without it take no time.
+// Dot only knows the attributes of graphviz, so there an other numeric label has to be used.
+const DurationLabel = "duration"
+
+// the labels are single words, so adapters with attribute names can hold them
+const (
+	earliestLabel = "earliest_start"
+	latestLabel   = "latest_start"
+	slackLabel    = "slack"
+)
+
+// scheduleTolerance is the relative difference below which two times are the same,
+// since summed durations like 0.1 and 0.2 are not exact
+const scheduleTolerance = 1e-9
+
+func sameTime(a, b float64) bool {
+	return math.Abs(a-b) <= scheduleTolerance*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+type times struct {
+	duration float64
+	earliest float64
+	latest   float64
+}
+
+func (t times) finish() float64 {
+	return t.earliest + t.duration
+}
+
+func (t times) slack() float64 {
+	if sameTime(t.latest, t.earliest) {
+		return 0
+	}
+	return t.latest - t.earliest
+}
+
+// schedule holds the earliest and latest start of every task of a acyclic graph,
+// where the edges point from a task to the tasks which have to wait for it
+type schedule struct {
+	origin walder.GraphDirected
+	// key is the label of the durations
+	key   string
+	order []fmt.Stringer
+	times map[string]times
+	// end is the earliest time all tasks are done
+	end float64
+}
+
+func newSchedule(origin walder.GraphDirected, key string) (*schedule, error) {
+	labeler, ok := origin.(walder.NodeLabeler)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement %s", origin, nodeLabelerString)
+	}
+	order, err := topo{origin: origin}.TopoSort()
+	if err != nil {
+		return nil, err
+	}
+	s := &schedule{
+		origin: origin,
+		key:    key,
+		order:  order,
+		times:  make(map[string]times, len(order)),
+	}
+
+	// forward pass for the earliest starts
+	for _, n := range order {
+		duration, err := durationOf(labeler, n, key)
+		if err != nil {
+			return nil, err
+		}
+		t := times{duration: duration}
+		in, err := origin.Incoming(n)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range in {
+			before, ok := s.times[i.String()]
+			if !ok {
+				return nil, fmt.Errorf("'%s' was not sorted before '%s'", i, n)
+			}
+			if finish := before.finish(); finish > t.earliest {
+				t.earliest = finish
+			}
+		}
+		s.times[n.String()] = t
+		if finish := t.finish(); finish > s.end {
+			s.end = finish
+		}
+	}
+
+	// backward pass for the latest starts, which don't delay the end
+	for i := len(order) - 1; i >= 0; i-- {
+		n := order[i]
+		t := s.times[n.String()]
+		finish := s.end
+		out, err := origin.Outgoing(n)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range out {
+			if after := s.times[o.String()]; after.latest < finish {
+				finish = after.latest
+			}
+		}
+		t.latest = finish - t.duration
+		s.times[n.String()] = t
+	}
+	return s, nil
+}
+
+// durationOf parses the duration label
+func durationOf(labeler walder.NodeLabeler, node fmt.Stringer, key string) (float64, error) {
+	labels, err := labeler.NodeLabels(node)
+	if err != nil {
+		return 0, err
+	}
+	for _, kv := range labels {
+		if kv[0] != key {
+			continue
+		}
+		d, err := parseNumber(kv[1])
+		if err != nil {
+			return 0, fmt.Errorf("the duration '%s' of '%s' is not a number", kv[1], node)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("the duration of '%s' is negative", node)
+		}
+		return d, nil
+	}
+	return 0, nil
+}
+
+var _ walder.GraphDirected = &schedule{}
+
+func (s *schedule) String() string {
+	return fmt.Sprintf("schedule of %s taking %s", s.origin.String(), strconv.FormatFloat(s.end, 'g', -1, 64))
+}
+func (s *schedule) HomeNodes() ([]fmt.Stringer, error) {
+	return s.origin.HomeNodes()
+}
+func (s *schedule) Incoming(node fmt.Stringer) ([]fmt.Stringer, error) {
+	return s.origin.Incoming(node)
+}
+func (s *schedule) Outgoing(node fmt.Stringer) ([]fmt.Stringer, error) {
+	return s.origin.Outgoing(node)
+}
+
+var _ walder.NodeAller = &schedule{}
+
+func (s *schedule) NodeAll() ([]fmt.Stringer, error) {
+	return s.order, nil
+}
+
+var _ walder.NodeLabeler = &schedule{}
+
+// NodeLabels returns the labels of the origin followed by the times of the task
+func (s *schedule) NodeLabels(node fmt.Stringer) ([][2]string, error) {
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	t, ok := s.times[node.String()]
+	if !ok {
+		return nil, fmt.Errorf("'%s' was not part of the graph when the schedule was computed", node)
+	}
+	labels, err := s.origin.(walder.NodeLabeler).NodeLabels(node)
+	if err != nil {
+		return nil, err
+	}
+	format := func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+	return append(labels,
+		[2]string{earliestLabel, format(t.earliest)},
+		[2]string{latestLabel, format(t.latest)},
+		[2]string{slackLabel, format(t.slack())},
+	), nil
+}
+
+// critical returns the chain of tasks without slack which ends last
+func (s *schedule) critical() ([]fmt.Stringer, error) {
+	var last fmt.Stringer
+	for _, n := range s.order {
+		t := s.times[n.String()]
+		if t.slack() == 0 && sameTime(t.finish(), s.end) {
+			last = n
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	path := []fmt.Stringer{last}
+	for cur := last; ; {
+		t := s.times[cur.String()]
+		in, err := s.origin.Incoming(cur)
+		if err != nil {
+			return nil, err
+		}
+		var next fmt.Stringer
+		for _, i := range in {
+			before := s.times[i.String()]
+			if before.slack() == 0 && sameTime(before.finish(), t.earliest) {
+				next = i
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		path = append(path, next)
+		cur = next
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// criticalPath shows only the critical path of a schedule, labeled with the times of the tasks
+type criticalPath struct {
+	schedule *schedule
+	path     []fmt.Stringer
+}
+
+func (c criticalPath) index(node fmt.Stringer) (int, error) {
+	if node == nil {
+		return 0, fmt.Errorf("recieved nil value")
+	}
+	for i, n := range c.path {
+		if n.String() == node.String() {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("'%s' is not on the critical path", node)
+}
+
+var _ walder.GraphDirected = criticalPath{}
+
+func (c criticalPath) String() string {
+	return fmt.Sprintf("critical path of %s taking %s", c.schedule.origin.String(), strconv.FormatFloat(c.schedule.end, 'g', -1, 64))
+}
+func (c criticalPath) HomeNodes() ([]fmt.Stringer, error) {
+	if len(c.path) == 0 {
+		return nil, nil
+	}
+	return c.path[:1], nil
+}
+func (c criticalPath) Outgoing(node fmt.Stringer) ([]fmt.Stringer, error) {
+	i, err := c.index(node)
+	if err != nil {
+		return nil, err
+	}
+	if i == len(c.path)-1 {
+		return nil, nil
+	}
+	return c.path[i+1 : i+2], nil
+}
+func (c criticalPath) Incoming(node fmt.Stringer) ([]fmt.Stringer, error) {
+	i, err := c.index(node)
+	if err != nil {
+		return nil, err
+	}
+	if i == 0 {
+		return nil, nil
+	}
+	return c.path[i-1 : i], nil
+}
+
+var _ walder.NodeAller = criticalPath{}
+
+func (c criticalPath) NodeAll() ([]fmt.Stringer, error) {
+	return c.path, nil
+}
+
+var _ walder.NodeLabeler = criticalPath{}
+
+func (c criticalPath) NodeLabels(node fmt.Stringer) ([][2]string, error) {
+	if _, err := c.index(node); err != nil {
+		return nil, err
+	}
+	return c.schedule.NodeLabels(node)
+}
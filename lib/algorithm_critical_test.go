@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"testing"
+)
+
+func TestCriticalPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		path   string
+		end    float64
+		// slack of the nodes by name
+		slack map[string]string
+	}{
+		{
+			name:   "parallel",
+			source: `digraph{a[weight=3]; b[weight=2]; c[weight=4]; d[weight=1]; e; a->b; a->c; b->d; c->d; d->e}`,
+			path:   "a c d e",
+			end:    8,
+			slack:  map[string]string{"a": "0", "b": "2", "c": "0"},
+		},
+		{
+			name:   "inexact sums",
+			source: `digraph{a[weight=0.1]; b[weight=0.2]; c[weight=0.4]; a->b; b->c}`,
+			path:   "a b c",
+			end:    0.7,
+			slack:  map[string]string{"a": "0", "b": "0", "c": "0"},
+		},
+		{
+			name:   "quoted",
+			source: `digraph{a[weight="2"]; b[weight="1"]; c[weight="5"]; a->b; c}`,
+			path:   "c",
+			end:    5,
+			slack:  map[string]string{"a": "2", "b": "2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			s, err := newSchedule(d, "weight")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameTime(s.end, test.end) {
+				t.Errorf("want end %g, but got %g", test.end, s.end)
+			}
+			path, err := s.critical()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(path); got != test.path {
+				t.Errorf("want path '%s', but got '%s'", test.path, got)
+			}
+			for name, want := range test.slack {
+				labels, err := s.NodeLabels(dotNode(t, d, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, _ := labelValue(labels, slackLabel); got != want {
+					t.Errorf("want slack %s for '%s', but got %s", want, name, got)
+				}
+				if _, ok := labelValue(labels, "weight"); !ok {
+					t.Errorf("the labels of the origin are missing for '%s'", name)
+				}
+			}
+		})
+	}
+}
+
+func TestCriticalPathErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"cycle", `digraph{a->b; b->a}`},
+		{"not a number", `digraph{a[weight=x]}`},
+		{"negative", `digraph{a[weight=-1]}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := newSchedule(openDot(t, test.source), "weight"); err == nil {
+				t.Error("want an error, but got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/treilik/walder"
+)
+
+// Dominators returns the dominator tree of all nodes reachable from the entry node,
+// computed with the algorithm of Lengauer and Tarjan.
+// A node dominates an other if every path from the entry to the other passes through it,
+// the parent of a node in the tree is its immediate dominator.
+func Dominators(directed walder.GraphOutgoing, entry fmt.Stringer) (walder.GraphDirected, error) {
+	d, err := dominators(directed, entry, false)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// PostDominators returns the post-dominator tree of all nodes from which the exit node is reachable.
+// A node post-dominates an other if every path from the other to the exit passes through it.
+func PostDominators(directed walder.GraphDirected, exit fmt.Stringer) (walder.GraphDirected, error) {
+	if directed == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	d, err := dominators(inverter{directed}, exit, true)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func dominators(directed walder.GraphOutgoing, entry fmt.Stringer, post bool) (*dominatorTree, error) {
+	if directed == nil || entry == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	l := &lengauerTarjan{
+		origin: directed,
+		number: make(map[string]int),
+	}
+	if err := l.search(entry, -1); err != nil {
+		return nil, err
+	}
+	idom := l.dominate()
+
+	t := &dominatorTree{
+		origin:   directed,
+		post:     post,
+		nodes:    l.vertex,
+		number:   l.number,
+		idom:     idom,
+		children: make([][]int, len(l.vertex)),
+	}
+	for v := 1; v < len(idom); v++ {
+		t.children[idom[v]] = append(t.children[idom[v]], v)
+	}
+	return t, nil
+}
+
+// lengauerTarjan holds the state of the algorithm, the nodes are numbered in depth first order
+type lengauerTarjan struct {
+	origin walder.GraphOutgoing
+
+	number map[string]int
+	vertex []fmt.Stringer
+	parent []int
+	pred   [][]int
+
+	semi     []int
+	ancestor []int
+	label    []int
+}
+
+func (l *lengauerTarjan) search(node fmt.Stringer, parent int) error {
+	v := len(l.vertex)
+	l.number[node.String()] = v
+	l.vertex = append(l.vertex, node)
+	l.parent = append(l.parent, parent)
+	l.pred = append(l.pred, nil)
+
+	out, err := l.origin.Outgoing(node)
+	if err != nil {
+		return err
+	}
+	for _, o := range out {
+		w, ok := l.number[o.String()]
+		if !ok {
+			if err := l.search(o, v); err != nil {
+				return err
+			}
+			w = l.number[o.String()]
+		}
+		l.pred[w] = append(l.pred[w], v)
+	}
+	return nil
+}
+
+// dominate returns the immediate dominator of every node by its number, the entry is its own dominator
+func (l *lengauerTarjan) dominate() []int {
+	n := len(l.vertex)
+	l.semi = make([]int, n)
+	l.ancestor = make([]int, n)
+	l.label = make([]int, n)
+	idom := make([]int, n)
+	bucket := make([][]int, n)
+	for v := range l.semi {
+		l.semi[v] = v
+		l.ancestor[v] = -1
+		l.label[v] = v
+	}
+
+	for w := n - 1; w > 0; w-- {
+		for _, v := range l.pred[w] {
+			if u := l.eval(v); l.semi[u] < l.semi[w] {
+				l.semi[w] = l.semi[u]
+			}
+		}
+		bucket[l.semi[w]] = append(bucket[l.semi[w]], w)
+
+		p := l.parent[w]
+		l.ancestor[w] = p
+		for _, v := range bucket[p] {
+			u := l.eval(v)
+			if l.semi[u] < l.semi[v] {
+				idom[v] = u
+			} else {
+				idom[v] = p
+			}
+		}
+		bucket[p] = nil
+	}
+	for w := 1; w < n; w++ {
+		if idom[w] != l.semi[w] {
+			idom[w] = idom[idom[w]]
+		}
+	}
+	return idom
+}
+
+// eval returns the node with the smallest semidominator on the path to the root of the forest
+func (l *lengauerTarjan) eval(v int) int {
+	if l.ancestor[v] < 0 {
+		return v
+	}
+	l.compress(v)
+	return l.label[v]
+}
+
+func (l *lengauerTarjan) compress(v int) {
+	a := l.ancestor[v]
+	if l.ancestor[a] < 0 {
+		return
+	}
+	l.compress(a)
+	if l.semi[l.label[a]] < l.semi[l.label[v]] {
+		l.label[v] = l.label[a]
+	}
+	l.ancestor[v] = l.ancestor[a]
+}
+
+type dominatorTree struct {
+	origin walder.GraphOutgoing
+	post   bool
+
+	nodes    []fmt.Stringer
+	number   map[string]int
+	idom     []int
+	children [][]int
+}
+
+func (d *dominatorTree) lookup(str fmt.Stringer) (int, error) {
+	if str == nil {
+		return 0, fmt.Errorf("recieved nil value")
+	}
+	v, ok := d.number[str.String()]
+	if !ok {
+		if d.post {
+			return 0, fmt.Errorf("the exit is not reachable from '%s'", str)
+		}
+		return 0, fmt.Errorf("'%s' is not reachable from the entry", str)
+	}
+	return v, nil
+}
+
+var _ walder.Graph = &dominatorTree{}
+
+func (d *dominatorTree) String() string {
+	if len(d.nodes) == 0 {
+		return "empty dominator tree"
+	}
+	origin := d.origin.String()
+	if i, ok := d.origin.(inverter); ok && i.invert != nil {
+		origin = i.invert.String()
+	}
+	if d.post {
+		return fmt.Sprintf("post-dominators of %s to '%s'", origin, d.nodes[0])
+	}
+	return fmt.Sprintf("dominators of %s from '%s'", origin, d.nodes[0])
+}
+
+func (d *dominatorTree) HomeNodes() ([]fmt.Stringer, error) {
+	return d.nodes[:1], nil
+}
+
+var _ walder.NodeAller = &dominatorTree{}
+
+// NodeAll returns the nodes in depth first order of the origin
+func (d *dominatorTree) NodeAll() ([]fmt.Stringer, error) {
+	return d.nodes, nil
+}
+
+var _ walder.GraphDirectedTree = &dominatorTree{}
+
+func (d *dominatorTree) Parent(str fmt.Stringer) (fmt.Stringer, error) {
+	v, err := d.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if v == 0 {
+		return nil, fmt.Errorf("'%s' is the root of the tree", str)
+	}
+	return d.nodes[d.idom[v]], nil
+}
+
+func (d *dominatorTree) Children(str fmt.Stringer) ([]fmt.Stringer, error) {
+	v, err := d.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	children := make([]fmt.Stringer, 0, len(d.children[v]))
+	for _, c := range d.children[v] {
+		children = append(children, d.nodes[c])
+	}
+	return children, nil
+}
+
+var _ walder.GraphDirected = &dominatorTree{}
+
+func (d *dominatorTree) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	return d.Children(str)
+}
+
+func (d *dominatorTree) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	v, err := d.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	if v == 0 {
+		return nil, nil
+	}
+	return []fmt.Stringer{d.nodes[d.idom[v]]}, nil
+}
+
+var _ walder.NodeLabeler = &dominatorTree{}
+
+// NodeLabels returns the depth in the tree and the amount of dominated nodes
+func (d *dominatorTree) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	v, err := d.lookup(str)
+	if err != nil {
+		return nil, err
+	}
+	depth := 0
+	for u := v; u != 0; u = d.idom[u] {
+		depth++
+	}
+	dominated := 0
+	stack := []int{v}
+	for len(stack) > 0 {
+		u := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		dominated++
+		stack = append(stack, d.children[u]...)
+	}
+	return [][2]string{
+		{"depth", strconv.Itoa(depth)},
+		{"dominated", strconv.Itoa(dominated - 1)},
+	}, nil
+}
@@ -0,0 +1,157 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/treilik/walder"
+)
+
+func TestDominators(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		entry  string
+		// immediate dominator of every reachable node besides the entry
+		idom map[string]string
+	}{
+		{
+			name:   "chain",
+			source: `digraph{a->b->c}`,
+			entry:  "a",
+			idom:   map[string]string{"b": "a", "c": "b"},
+		},
+		{
+			name:   "diamond",
+			source: `digraph{a->b; a->c; b->d; c->d; d->e}`,
+			entry:  "a",
+			idom:   map[string]string{"b": "a", "c": "a", "d": "a", "e": "d"},
+		},
+		{
+			name:   "loop",
+			source: `digraph{a->b->c->b; c->d}`,
+			entry:  "a",
+			idom:   map[string]string{"b": "a", "c": "b", "d": "c"},
+		},
+		{
+			name:   "unreachable",
+			source: `digraph{x->b; a->b}`,
+			entry:  "a",
+			idom:   map[string]string{"b": "a"},
+		},
+		{
+			// the example of Lengauer and Tarjan
+			name: "paper",
+			source: `digraph{r->a; r->b; r->c; a->d; b->a; b->d; b->e; c->f; c->g; d->l; e->h;
+				f->i; g->i; g->j; h->e; h->k; i->k; j->i; k->i; k->r; l->h}`,
+			entry: "r",
+			idom: map[string]string{
+				"a": "r", "b": "r", "c": "r", "d": "r", "e": "r", "f": "c",
+				"g": "c", "h": "r", "i": "r", "j": "g", "k": "r", "l": "d",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			tree, err := Dominators(d, dotNode(t, d, test.entry))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDominators(t, tree, test.entry, test.idom)
+		})
+	}
+}
+
+func TestPostDominators(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		exit   string
+		idom   map[string]string
+	}{
+		{
+			name:   "branch",
+			source: `digraph{s->a; s->b; a->e; b->e}`,
+			exit:   "e",
+			idom:   map[string]string{"a": "e", "b": "e", "s": "e"},
+		},
+		{
+			name:   "join",
+			source: `digraph{s->a; s->b; a->j; b->j; j->e; s->x}`,
+			exit:   "e",
+			idom:   map[string]string{"a": "j", "b": "j", "j": "e", "s": "j"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			tree, err := PostDominators(d, dotNode(t, d, test.exit))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkDominators(t, tree, test.exit, test.idom)
+		})
+	}
+}
+
+// checkDominators compares the parents of the tree with the immediate dominators
+func checkDominators(t *testing.T, tree walder.GraphDirected, root string, idom map[string]string) {
+	t.Helper()
+	d := tree.(*dominatorTree)
+	all, err := d.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(idom)+1 {
+		t.Errorf("want %d nodes, but got '%s'", len(idom)+1, sortedNames(all))
+	}
+	for _, n := range all {
+		if n.String() == root {
+			if _, err := d.Parent(n); err == nil {
+				t.Errorf("want the root '%s' to have no parent", root)
+			}
+			continue
+		}
+		parent, err := d.Parent(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := idom[n.String()]; parent.String() != want {
+			t.Errorf("want immediate dominator of %s '%s', but got '%s'", n, want, parent)
+		}
+	}
+}
+
+func TestDominatorLabels(t *testing.T) {
+	d := openDot(t, `digraph{a->b; a->c; b->d; c->d; d->e; x}`)
+	tree, err := Dominators(d, dotNode(t, d, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		node      string
+		depth     string
+		dominated string
+	}{
+		{"a", "0", "4"},
+		{"d", "1", "1"},
+		{"e", "2", "0"},
+	}
+	for _, test := range tests {
+		t.Run(test.node, func(t *testing.T) {
+			labels, err := tree.(walder.NodeLabeler).NodeLabels(dotNode(t, d, test.node))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := labelValue(labels, "depth"); got != test.depth {
+				t.Errorf("want depth '%s', but got '%s'", test.depth, got)
+			}
+			if got, _ := labelValue(labels, "dominated"); got != test.dominated {
+				t.Errorf("want dominated '%s', but got '%s'", test.dominated, got)
+			}
+		})
+	}
+	if _, err := tree.Outgoing(dotNode(t, d, "x")); err == nil {
+		t.Error("want error for a unreachable node, but got none")
+	}
+}
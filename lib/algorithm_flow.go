@@ -0,0 +1,361 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/treilik/walder"
+)
+
+// FlowLabel is the edge label which holds the flow through the edge
+const FlowLabel = "flow"
+
+// flowArc is a edge of the residual network, rev is the index of the arc in the other direction
+type flowArc struct {
+	from, to int
+	capacity float64
+	flow     float64
+	rev      int
+	// original is false for the arcs which only exist to take back flow
+	original bool
+}
+
+func (a flowArc) residual() float64 {
+	return a.capacity - a.flow
+}
+
+// flowNetwork holds the flow between two nodes of a graph.
+// The edges of a walder.GraphDirected are used as they are,
+// the neighbors of a walder.GraphNeighbors and the edges of a undirected dot graph in both directions.
+type flowNetwork struct {
+	origin walder.Graph
+	key    string
+	nodes  []fmt.Stringer
+	index  map[string]int
+	arcs   []flowArc
+	// adjacent holds the indices of the arcs leaving every node
+	adjacent [][]int
+
+	source, sink int
+	value        float64
+	// sourceSide holds the nodes still reachable from the source when the flow is maximal
+	sourceSide []bool
+}
+
+// newFlowNetwork reads the capacities from the edge labels with the key, edges without it have a capacity of 1
+func newFlowNetwork(g walder.Graph, key string) (*flowNetwork, error) {
+	x, err := newEdgeIndex(g, false)
+	if err != nil {
+		return nil, err
+	}
+	labeler, _ := g.(walder.EdgeLabeler)
+	f := &flowNetwork{
+		origin:   g,
+		key:      key,
+		nodes:    x.nodes,
+		index:    x.index,
+		adjacent: make([][]int, len(x.nodes)),
+	}
+	// the edges of undirected graphs are read from both ends, but are only added once in each direction
+	seen := make(map[[2]int]bool)
+	err = x.walk(func(i, j int) error {
+		if i == j || (x.undirected && seen[[2]int{j, i}]) {
+			return nil
+		}
+		seen[[2]int{i, j}] = true
+		capacity, err := edgeWeight(labeler, f.nodes[i], f.nodes[j], key)
+		if err != nil {
+			return err
+		}
+		f.addArc(i, j, capacity)
+		if x.undirected {
+			f.addArc(j, i, capacity)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *flowNetwork) addArc(from, to int, capacity float64) {
+	forward, backward := len(f.arcs), len(f.arcs)+1
+	f.arcs = append(f.arcs,
+		flowArc{from: from, to: to, capacity: capacity, rev: backward, original: true},
+		flowArc{from: to, to: from, rev: forward},
+	)
+	f.adjacent[from] = append(f.adjacent[from], forward)
+	f.adjacent[to] = append(f.adjacent[to], backward)
+}
+
+func (f *flowNetwork) lookup(node fmt.Stringer) (int, error) {
+	if node == nil {
+		return 0, fmt.Errorf("recieved nil value")
+	}
+	i, ok := f.index[node.String()]
+	if !ok {
+		return 0, fmt.Errorf("'%s' was not returned by NodeAll", node)
+	}
+	return i, nil
+}
+
+// maximize sends as much flow as possible from the source to the sink,
+// always along the augmenting path with the least edges like Edmonds and Karp
+func (f *flowNetwork) maximize(source, sink fmt.Stringer) error {
+	s, err := f.lookup(source)
+	if err != nil {
+		return err
+	}
+	t, err := f.lookup(sink)
+	if err != nil {
+		return err
+	}
+	if s == t {
+		return fmt.Errorf("the source and the sink are both '%s'", source)
+	}
+	f.source, f.sink = s, t
+	for {
+		via := f.augmenting()
+		if via[t] < 0 {
+			break
+		}
+		bottleneck := -1.0
+		for v := t; v != s; v = f.arcs[via[v]].from {
+			if r := f.arcs[via[v]].residual(); bottleneck < 0 || r < bottleneck {
+				bottleneck = r
+			}
+		}
+		for v := t; v != s; v = f.arcs[via[v]].from {
+			a := via[v]
+			f.arcs[a].flow += bottleneck
+			f.arcs[f.arcs[a].rev].flow -= bottleneck
+		}
+		f.value += bottleneck
+	}
+	// the last search found no path, so the nodes it reached are the source side of a minimum cut
+	via := f.augmenting()
+	f.sourceSide = make([]bool, len(f.nodes))
+	for v := range f.nodes {
+		f.sourceSide[v] = v == s || via[v] >= 0
+	}
+	return nil
+}
+
+// augmenting searches breadth first through the arcs with residual capacity
+// and returns the arc over which every node was reached, or -1
+func (f *flowNetwork) augmenting() []int {
+	via := make([]int, len(f.nodes))
+	for v := range via {
+		via[v] = -1
+	}
+	queue := []int{f.source}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, a := range f.adjacent[v] {
+			arc := f.arcs[a]
+			if arc.residual() <= 0 || arc.to == f.source || via[arc.to] >= 0 {
+				continue
+			}
+			via[arc.to] = a
+			if arc.to == f.sink {
+				return via
+			}
+			queue = append(queue, arc.to)
+		}
+	}
+	return via
+}
+
+// cut returns the original arcs from the source side to the sink side
+func (f *flowNetwork) cut() []int {
+	var arcs []int
+	for a, arc := range f.arcs {
+		if arc.original && f.sourceSide[arc.from] && !f.sourceSide[arc.to] {
+			arcs = append(arcs, a)
+		}
+	}
+	return arcs
+}
+
+// MaxFlow returns the value of the maximum flow from the source to the sink.
+// The capacities are taken from the edge labels with the key, edges without it have a capacity of 1.
+func MaxFlow(g walder.Graph, key string, source, sink fmt.Stringer) (float64, error) {
+	f, err := newFlowNetwork(g, key)
+	if err != nil {
+		return 0, err
+	}
+	err = f.maximize(source, sink)
+	if err != nil {
+		return 0, err
+	}
+	return f.value, nil
+}
+
+// MinimumCut returns the edges with the least summed capacity, whose removal separates the sink from the source.
+func MinimumCut(g walder.Graph, key string, source, sink fmt.Stringer) ([][2]fmt.Stringer, error) {
+	f, err := newFlowNetwork(g, key)
+	if err != nil {
+		return nil, err
+	}
+	err = f.maximize(source, sink)
+	if err != nil {
+		return nil, err
+	}
+	var edges [][2]fmt.Stringer
+	for _, a := range f.cut() {
+		edges = append(edges, [2]fmt.Stringer{f.nodes[f.arcs[a].from], f.nodes[f.arcs[a].to]})
+	}
+	return edges, nil
+}
+
+// flowing returns the original arcs with flow
+func (f *flowNetwork) flowing() []int {
+	var arcs []int
+	for a, arc := range f.arcs {
+		if arc.original && arc.flow > 0 {
+			arcs = append(arcs, a)
+		}
+	}
+	return arcs
+}
+
+// flowGraph shows some arcs of a flow network, labeled with there capacity and flow,
+// which are the same for the edges of a minimum cut
+type flowGraph struct {
+	flow *flowNetwork
+	// name tells which arcs are shown and measure what the value of the flow is to them
+	name, measure string
+	nodes         []fmt.Stringer
+	// out and in map the nodes to the shown arcs leaving and entering them
+	out map[string][]int
+	in  map[string][]int
+}
+
+// newMinimumCut shows the edges of the minimum cut
+func newMinimumCut(f *flowNetwork) flowGraph {
+	return newFlowGraph(f, "minimum cut", "capacity", f.cut())
+}
+
+// newFlows shows the edges with flow, so the flow is shown without writing labels into the origin,
+// which would be dropped by graphs like dot, which only know there own attributes
+func newFlows(f *flowNetwork) flowGraph {
+	return newFlowGraph(f, "maximum flow", "value", f.flowing())
+}
+
+func newFlowGraph(f *flowNetwork, name, measure string, arcs []int) flowGraph {
+	m := flowGraph{
+		flow:    f,
+		name:    name,
+		measure: measure,
+		out:     make(map[string][]int),
+		in:      make(map[string][]int),
+	}
+	seen := make(map[int]bool)
+	for _, a := range arcs {
+		from, to := f.arcs[a].from, f.arcs[a].to
+		for _, v := range []int{from, to} {
+			if !seen[v] {
+				seen[v] = true
+				m.nodes = append(m.nodes, f.nodes[v])
+			}
+		}
+		m.out[f.nodes[from].String()] = append(m.out[f.nodes[from].String()], a)
+		m.in[f.nodes[to].String()] = append(m.in[f.nodes[to].String()], a)
+	}
+	return m
+}
+
+func (m flowGraph) check(node fmt.Stringer) error {
+	if node == nil {
+		return fmt.Errorf("recieved nil value")
+	}
+	_, out := m.out[node.String()]
+	_, in := m.in[node.String()]
+	if !out && !in {
+		return fmt.Errorf("'%s' is not part of the %s", node, m.name)
+	}
+	return nil
+}
+
+var _ walder.GraphDirected = flowGraph{}
+
+func (m flowGraph) String() string {
+	f := m.flow
+	return fmt.Sprintf("%s of %s between %s and %s with %s %s",
+		m.name, f.origin.String(), f.nodes[f.source], f.nodes[f.sink], m.measure, strconv.FormatFloat(f.value, 'g', -1, 64))
+}
+
+// HomeNodes returns the nodes without incoming arcs, which are the source side of a cut or the source of the flow
+func (m flowGraph) HomeNodes() ([]fmt.Stringer, error) {
+	var home []fmt.Stringer
+	for _, n := range m.nodes {
+		if _, ok := m.in[n.String()]; !ok {
+			home = append(home, n)
+		}
+	}
+	return home, nil
+}
+func (m flowGraph) Outgoing(node fmt.Stringer) ([]fmt.Stringer, error) {
+	if err := m.check(node); err != nil {
+		return nil, err
+	}
+	var out []fmt.Stringer
+	for _, a := range m.out[node.String()] {
+		out = append(out, m.flow.nodes[m.flow.arcs[a].to])
+	}
+	return out, nil
+}
+func (m flowGraph) Incoming(node fmt.Stringer) ([]fmt.Stringer, error) {
+	if err := m.check(node); err != nil {
+		return nil, err
+	}
+	var in []fmt.Stringer
+	for _, a := range m.in[node.String()] {
+		in = append(in, m.flow.nodes[m.flow.arcs[a].from])
+	}
+	return in, nil
+}
+
+var _ walder.NodeAller = flowGraph{}
+
+func (m flowGraph) NodeAll() ([]fmt.Stringer, error) {
+	return m.nodes, nil
+}
+
+var _ walder.NodeLabeler = flowGraph{}
+
+// NodeLabels tells on which side of the minimum cut the node is
+func (m flowGraph) NodeLabels(node fmt.Stringer) ([][2]string, error) {
+	if err := m.check(node); err != nil {
+		return nil, err
+	}
+	side := "sink"
+	if m.flow.sourceSide[m.flow.index[node.String()]] {
+		side = "source"
+	}
+	return [][2]string{{"side", side}}, nil
+}
+
+var _ walder.EdgeLabeler = flowGraph{}
+
+func (m flowGraph) EdgeLabels(from, to fmt.Stringer) ([][2]string, error) {
+	if err := m.check(from); err != nil {
+		return nil, err
+	}
+	if to == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	for _, a := range m.out[from.String()] {
+		arc := m.flow.arcs[a]
+		if m.flow.nodes[arc.to].String() != to.String() {
+			continue
+		}
+		return [][2]string{
+			{m.flow.key, strconv.FormatFloat(arc.capacity, 'g', -1, 64)},
+			{FlowLabel, strconv.FormatFloat(arc.flow, 'g', -1, 64)},
+		}, nil
+	}
+	return nil, fmt.Errorf("no edge of the %s from '%s' to '%s'", m.name, from, to)
+}
@@ -0,0 +1,129 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+const flowSample = `digraph { s -> a [weight=3]; s -> b [weight="2"]; a -> b [weight=1]; a -> t [weight=2]; b -> t [weight=3] }`
+
+func TestMaxFlow(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		from, to string
+		want     float64
+		cut      string
+	}{
+		{"weighted", flowSample, "s", "t", 5, "s->a s->b"},
+		{"unweighted", `digraph { s -> a; s -> b; a -> t; b -> t; a -> b }`, "s", "t", 2, "s->a s->b"},
+		{"bottleneck", `digraph { s -> a [weight=5]; a -> t [weight=1] }`, "s", "t", 1, "a->t"},
+		{"unconnected", `digraph { s -> a; t }`, "s", "t", 0, ""},
+		{"undirected", `graph { s -- a; t -- a; s -- b; b -- t }`, "s", "t", 2, "s->a s->b"},
+		{"undirected weighted", `graph { t -- a [weight=4]; a -- s [weight=3]; s -- t [weight=1] }`, "s", "t", 4, "s->a s->t"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			from, to := dotNode(t, d, test.from), dotNode(t, d, test.to)
+			value, err := MaxFlow(d, WeightLabel, from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.want {
+				t.Errorf("want flow %v, but got %v", test.want, value)
+			}
+			edges, err := MinimumCut(d, WeightLabel, from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var cut []string
+			for _, e := range edges {
+				cut = append(cut, e[0].String()+"->"+e[1].String())
+			}
+			if got := strings.Join(cut, " "); got != test.cut {
+				t.Errorf("want cut '%s', but got '%s'", test.cut, got)
+			}
+		})
+	}
+}
+
+func TestFlowGraphs(t *testing.T) {
+	d := openDot(t, flowSample)
+	f, err := newFlowNetwork(d, WeightLabel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = f.maximize(dotNode(t, d, "s"), dotNode(t, d, "t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flows := newFlows(f)
+	if got := sortedNames(flows.nodes); got != "a b s t" {
+		t.Errorf("want nodes 'a b s t', but got '%s'", got)
+	}
+	home, err := flows.HomeNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(home); got != "s" {
+		t.Errorf("want home 's', but got '%s'", got)
+	}
+	tests := []struct {
+		from, to string
+		capacity string
+		flow     string
+	}{
+		{"s", "a", "3", "3"},
+		{"s", "b", "2", "2"},
+		{"a", "b", "1", "1"},
+		{"a", "t", "2", "2"},
+		{"b", "t", "3", "3"},
+	}
+	for _, test := range tests {
+		t.Run(test.from+"->"+test.to, func(t *testing.T) {
+			labels, err := flows.EdgeLabels(dotNode(t, d, test.from), dotNode(t, d, test.to))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v, _ := labelValue(labels, WeightLabel); v != test.capacity {
+				t.Errorf("want capacity '%s', but got '%s'", test.capacity, v)
+			}
+			if v, _ := labelValue(labels, FlowLabel); v != test.flow {
+				t.Errorf("want flow '%s', but got '%s'", test.flow, v)
+			}
+		})
+	}
+
+	cut := newMinimumCut(f)
+	labels, err := cut.NodeLabels(dotNode(t, d, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := labelValue(labels, "side"); v != "sink" {
+		t.Errorf("want 'a' on the sink side, but got '%s'", v)
+	}
+	if _, err := cut.EdgeLabels(dotNode(t, d, "a"), dotNode(t, d, "t")); err == nil {
+		t.Error("want error for a edge not in the cut, but got nil")
+	}
+}
+
+func TestMaxFlowErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		from, to string
+	}{
+		{"same node", `digraph { s -> t }`, "s", "s"},
+		{"negative capacity", `digraph { s -> t [weight=-1] }`, "s", "t"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			_, err := MaxFlow(d, WeightLabel, dotNode(t, d, test.from), dotNode(t, d, test.to))
+			if err == nil {
+				t.Error("want error, but got nil")
+			}
+		})
+	}
+}
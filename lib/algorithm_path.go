@@ -0,0 +1,284 @@
+package lib
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/treilik/walder"
+)
+
+// WeightLabel is the edge label which is used as weight, if the graph has edge labels
+const WeightLabel = "weight"
+
+// ShortestPath returns the path from 'from' to 'to' with the least edges,
+// or the least summed weights if the graph implements walder.EdgeLabeler.
+// Edges without a weight label weigh 1.
+// As elsewhere the nodes are identified by there string.
+func ShortestPath(directed walder.GraphOutgoing, from, to fmt.Stringer) ([]fmt.Stringer, error) {
+	if directed == nil || from == nil || to == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	p := newPathFinder(directed)
+	path, _, err := p.shortest(from, to)
+	return path, err
+}
+
+// KShortestPaths returns up to k loopless paths from 'from' to 'to' in ascending length, using Yen's algorithm.
+func KShortestPaths(directed walder.GraphOutgoing, from, to fmt.Stringer, k int) ([][]fmt.Stringer, error) {
+	if directed == nil || from == nil || to == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	if k < 1 {
+		return nil, fmt.Errorf("want at least one path, but got %d", k)
+	}
+	p := newPathFinder(directed)
+	first, _, err := p.shortest(from, to)
+	if err != nil {
+		return nil, err
+	}
+	found := [][]fmt.Stringer{first}
+
+	type candidate struct {
+		path []fmt.Stringer
+		cost float64
+	}
+	var candidates []candidate
+	known := map[string]bool{pathKey(first): true}
+
+	for len(found) < k {
+		last := found[len(found)-1]
+		for i := 0; i < len(last)-1; i++ {
+			root := last[:i+1]
+			rootKey := pathKey(root)
+
+			// the edges leaving the root which are used by paths already found are removed,
+			// so the spur path has to deviate from them
+			p.removedEdges = make(map[[2]string]bool)
+			for _, f := range found {
+				if len(f) > i+1 && pathKey(f[:i+1]) == rootKey {
+					p.removedEdges[[2]string{f[i].String(), f[i+1].String()}] = true
+				}
+			}
+			// the root nodes are removed to keep the paths loopless
+			p.removedNodes = make(map[string]bool)
+			for _, n := range root[:i] {
+				p.removedNodes[n.String()] = true
+			}
+
+			spur, spurCost, err := p.shortest(root[i], to)
+			if err != nil {
+				if _, ok := err.(noPath); ok {
+					continue
+				}
+				return found, err
+			}
+			rootCost, err := p.cost(root)
+			if err != nil {
+				return found, err
+			}
+			path := make([]fmt.Stringer, 0, i+len(spur))
+			path = append(path, root[:i]...)
+			path = append(path, spur...)
+			key := pathKey(path)
+			if known[key] {
+				continue
+			}
+			known[key] = true
+			candidates = append(candidates, candidate{path: path, cost: rootCost + spurCost})
+		}
+		p.removedEdges = nil
+		p.removedNodes = nil
+
+		if len(candidates) == 0 {
+			break
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			if candidates[a].cost == candidates[b].cost {
+				return len(candidates[a].path) < len(candidates[b].path)
+			}
+			return candidates[a].cost < candidates[b].cost
+		})
+		found = append(found, candidates[0].path)
+		candidates = candidates[1:]
+	}
+	return found, nil
+}
+
+// noPath is returned if the target node can't be reached
+type noPath struct {
+	from string
+	to   string
+}
+
+func (n noPath) Error() string {
+	return fmt.Sprintf("no path from '%s' to '%s'", n.from, n.to)
+}
+
+type pathFinder struct {
+	origin  walder.GraphOutgoing
+	labeler walder.EdgeLabeler
+
+	// removed nodes and edges are skipped while searching
+	removedNodes map[string]bool
+	removedEdges map[[2]string]bool
+}
+
+func newPathFinder(directed walder.GraphOutgoing) *pathFinder {
+	p := &pathFinder{origin: directed}
+	if l, ok := directed.(walder.EdgeLabeler); ok {
+		p.labeler = l
+	}
+	return p
+}
+
+// weight returns the smallest weight label of the edge
+func (p *pathFinder) weight(from, to fmt.Stringer) (float64, error) {
+	return edgeWeight(p.labeler, from, to, WeightLabel)
+}
+
+func (p *pathFinder) cost(path []fmt.Stringer) (float64, error) {
+	var sum float64
+	for i := 1; i < len(path); i++ {
+		w, err := p.weight(path[i-1], path[i])
+		if err != nil {
+			return 0, err
+		}
+		sum += w
+	}
+	return sum, nil
+}
+
+// shortest searches breadth first for unweighted graphs and else uses Dijkstra's algorithm
+func (p *pathFinder) shortest(from, to fmt.Stringer) ([]fmt.Stringer, float64, error) {
+	start, target := from.String(), to.String()
+	if p.removedNodes[start] {
+		return nil, 0, noPath{from: start, to: target}
+	}
+
+	nodes := map[string]fmt.Stringer{start: from}
+	previous := make(map[string]string)
+	distance := map[string]float64{start: 0}
+	done := make(map[string]bool)
+
+	queue := &pathQueue{{key: start}}
+	pop := func() pathItem { return heap.Pop(queue).(pathItem) }
+	push := func(item pathItem) { heap.Push(queue, item) }
+	if p.labeler == nil {
+		// without weights the first visit is the shortest, so a fifo queue makes this a breadth first search
+		pop = func() pathItem {
+			item := (*queue)[0]
+			*queue = (*queue)[1:]
+			return item
+		}
+		push = func(item pathItem) { *queue = append(*queue, item) }
+	}
+	for queue.Len() > 0 {
+		cur := pop()
+		if done[cur.key] {
+			continue
+		}
+		done[cur.key] = true
+		if cur.key == target {
+			break
+		}
+		out, err := p.origin.Outgoing(nodes[cur.key])
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, o := range out {
+			key := o.String()
+			if done[key] || p.removedNodes[key] || p.removedEdges[[2]string{cur.key, key}] {
+				continue
+			}
+			w, err := p.weight(nodes[cur.key], o)
+			if err != nil {
+				return nil, 0, err
+			}
+			d := cur.distance + w
+			if former, ok := distance[key]; ok && former <= d {
+				continue
+			}
+			distance[key] = d
+			previous[key] = cur.key
+			nodes[key] = o
+			push(pathItem{key: key, distance: d})
+		}
+	}
+	if !done[target] {
+		return nil, 0, noPath{from: start, to: target}
+	}
+
+	var path []fmt.Stringer
+	for key := target; ; key = previous[key] {
+		path = append(path, nodes[key])
+		if key == start {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, distance[target], nil
+}
+
+func pathKey(path []fmt.Stringer) string {
+	key := make([]byte, 0, len(path)*8)
+	for _, n := range path {
+		key = strconv.AppendQuote(key, n.String())
+	}
+	return string(key)
+}
+
+// writePaths writes the paths into the graph, nodes with the same string are only created once
+func writePaths(gw graphWriter, paths ...[]fmt.Stringer) error {
+	lookup := make(map[string]fmt.Stringer)
+	edges := make(map[[2]string]bool)
+	for _, path := range paths {
+		for i, oldNode := range path {
+			key := oldNode.String()
+			newNode, ok := lookup[key]
+			if !ok {
+				var err error
+				newNode, err = gw.NodeCreate(oldNode)
+				if err != nil {
+					return err
+				}
+				lookup[key] = newNode
+			}
+			if i == 0 {
+				continue
+			}
+			formerKey := path[i-1].String()
+			if edges[[2]string{formerKey, key}] {
+				continue
+			}
+			edges[[2]string{formerKey, key}] = true
+			err := gw.EdgeCreate(lookup[formerKey], newNode)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type pathItem struct {
+	key      string
+	distance float64
+}
+
+// pathQueue is a min heap ordered by distance
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(a, b int) bool  { return q[a].distance < q[b].distance }
+func (q pathQueue) Swap(a, b int)       { q[a], q[b] = q[b], q[a] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
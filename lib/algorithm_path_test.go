@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShortestPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		from, to string
+		k        int
+		want     []string
+	}{
+		{"unweighted", `digraph { a -> b; b -> c; a -> d; d -> e; e -> c }`, "a", "c", 2, []string{"a b c", "a d e c"}},
+		{"weighted", `digraph { a -> b [weight=5]; a -> c [weight=1]; c -> b [weight=1] }`, "a", "b", 2, []string{"a c b", "a b"}},
+		{"quoted weights", `digraph { a -> b [weight="5"]; a -> c [weight="1"]; c -> b [weight="1"] }`, "a", "b", 2, []string{"a c b", "a b"}},
+		{"fewer paths than k", `digraph { a -> b }`, "a", "b", 3, []string{"a b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			from, to := dotNode(t, d, test.from), dotNode(t, d, test.to)
+			path, err := ShortestPath(d, from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(path); got != test.want[0] {
+				t.Errorf("want shortest path '%s', but got '%s'", test.want[0], got)
+			}
+			paths, err := KShortestPaths(d, from, to, test.k)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(paths))
+			for _, p := range paths {
+				got = append(got, names(p))
+			}
+			if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+				t.Errorf("want paths '%s', but got '%s'", strings.Join(test.want, ", "), strings.Join(got, ", "))
+			}
+		})
+	}
+}
+
+func TestShortestPathErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		from, to string
+		k        int
+	}{
+		{"no path", `digraph { a -> b; c }`, "a", "c", 1},
+		{"negative weight", `digraph { a -> b [weight=-1] }`, "a", "b", 1},
+		{"not a number", `digraph { a -> b [weight=x] }`, "a", "b", 1},
+		{"no paths wanted", `digraph { a -> b }`, "a", "b", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			_, err := KShortestPaths(d, dotNode(t, d, test.from), dotNode(t, d, test.to), test.k)
+			if err == nil {
+				t.Error("want error, but got nil")
+			}
+		})
+	}
+}
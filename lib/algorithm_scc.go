@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"fmt"
+
+	"github.com/treilik/walder"
+)
+
+// StronglyConnected returns the strongly connected components of the graph using Tarjan's algorithm.
+// The components are returned in reverse topological order, so no component has edges to a later one.
+// As elsewhere the nodes are identified by there string.
+func StronglyConnected(directed walder.GraphDirected) ([][]fmt.Stringer, error) {
+	if directed == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	aller, ok := directed.(walder.NodeAller)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement %s", directed, nodeAllerString)
+	}
+	all, err := aller.NodeAll()
+	if err != nil {
+		return nil, err
+	}
+	t := tarjan{
+		origin:  directed,
+		index:   make(map[string]int),
+		low:     make(map[string]int),
+		onStack: make(map[string]bool),
+	}
+	for _, n := range all {
+		if _, ok := t.index[n.String()]; ok {
+			continue
+		}
+		if err := t.connect(n); err != nil {
+			return nil, err
+		}
+	}
+	return t.components, nil
+}
+
+type tarjan struct {
+	origin walder.GraphOutgoing
+
+	counter int
+	index   map[string]int
+	low     map[string]int
+	stack   []fmt.Stringer
+	onStack map[string]bool
+
+	components [][]fmt.Stringer
+}
+
+func (t *tarjan) connect(node fmt.Stringer) error {
+	key := node.String()
+	t.index[key] = t.counter
+	t.low[key] = t.counter
+	t.counter++
+	t.stack = append(t.stack, node)
+	t.onStack[key] = true
+
+	out, err := t.origin.Outgoing(node)
+	if err != nil {
+		return err
+	}
+	for _, o := range out {
+		oKey := o.String()
+		if _, ok := t.index[oKey]; !ok {
+			if err := t.connect(o); err != nil {
+				return err
+			}
+			if t.low[oKey] < t.low[key] {
+				t.low[key] = t.low[oKey]
+			}
+			continue
+		}
+		if t.onStack[oKey] && t.index[oKey] < t.low[key] {
+			t.low[key] = t.index[oKey]
+		}
+	}
+
+	// node is the root of a component, which are all nodes above it on the stack
+	if t.low[key] != t.index[key] {
+		return nil
+	}
+	var component []fmt.Stringer
+	for {
+		last := t.stack[len(t.stack)-1]
+		t.stack = t.stack[:len(t.stack)-1]
+		t.onStack[last.String()] = false
+		component = append(component, last)
+		if last.String() == key {
+			break
+		}
+	}
+	// keep the order in which the nodes were found
+	for i, j := 0, len(component)-1; i < j; i, j = i+1, j-1 {
+		component[i], component[j] = component[j], component[i]
+	}
+	t.components = append(t.components, component)
+	return nil
+}
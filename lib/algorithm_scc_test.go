@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStronglyConnected(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		// components in the order they are returned, the nodes of each sorted
+		want string
+	}{
+		{"chain", `digraph{a->b->c}`, "c|b|a"},
+		{"cycle", `digraph{a->b->c->a}`, "a b c"},
+		{"self loop", `digraph{a->a; a->b}`, "b|a"},
+		{"two cycles", `digraph{a->b->a; b->c; c->d->c}`, "c d|a b"},
+		{"unconnected", `digraph{a; b}`, "a|b"},
+		{"shared sink", `digraph{a->c; b->c; c->d->c}`, "c d|a|b"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			components, err := StronglyConnected(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(components))
+			for _, c := range components {
+				got = append(got, sortedNames(c))
+			}
+			if strings.Join(got, "|") != test.want {
+				t.Errorf("want components '%s', but got '%s'", test.want, strings.Join(got, "|"))
+			}
+		})
+	}
+}
+
+func TestStronglyConnectedOrder(t *testing.T) {
+	// no component has edges to a later one
+	d := openDot(t, `digraph{e->a; a->b->a; b->c; c->d; d->c; a->d}`)
+	components, err := StronglyConnected(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	position := make(map[string]int)
+	for i, c := range components {
+		for _, n := range c {
+			position[n.String()] = i
+		}
+	}
+	all, err := d.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range all {
+		out, err := d.Outgoing(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range out {
+			if position[o.String()] > position[n.String()] {
+				t.Errorf("edge %s->%s goes to a later component", n, o)
+			}
+		}
+	}
+}
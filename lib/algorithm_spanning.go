@@ -0,0 +1,351 @@
+package lib
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+
+	"github.com/treilik/walder"
+)
+
+// The spanning trees ignore the direction of the edges,
+// but the returned edges keep the direction they have in the graph.
+
+type weightedEdge struct {
+	from, to int
+	weight   float64
+}
+
+// weightedGraph is the undirected simple graph of the origin, where parallel edges keep the lightest weight
+type weightedGraph struct {
+	nodes []fmt.Stringer
+	edges []weightedEdge
+	// adjacent holds the indices of the edges of every node
+	adjacent [][]int
+}
+
+// newWeightedGraph reads the weights from the edge labels with the key, edges without it weigh 1
+func newWeightedGraph(g walder.Graph, key string) (*weightedGraph, error) {
+	x, err := newEdgeIndex(g, false)
+	if err != nil {
+		return nil, err
+	}
+	labeler, _ := g.(walder.EdgeLabeler)
+	w := &weightedGraph{
+		nodes:    x.nodes,
+		adjacent: make([][]int, len(x.nodes)),
+	}
+	// known maps both directions of a edge to its index
+	known := make(map[[2]int]int)
+	err = x.walk(func(i, j int) error {
+		if i == j {
+			return nil
+		}
+		weight, err := edgeWeight(labeler, w.nodes[i], w.nodes[j], key)
+		if err != nil {
+			return err
+		}
+		if e, ok := known[[2]int{i, j}]; ok {
+			if weight < w.edges[e].weight {
+				w.edges[e].weight = weight
+			}
+			return nil
+		}
+		e := len(w.edges)
+		known[[2]int{i, j}] = e
+		known[[2]int{j, i}] = e
+		w.edges = append(w.edges, weightedEdge{from: i, to: j, weight: weight})
+		w.adjacent[i] = append(w.adjacent[i], e)
+		w.adjacent[j] = append(w.adjacent[j], e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// edgeWeight returns the smallest value of the label
+func edgeWeight(labeler walder.EdgeLabeler, from, to fmt.Stringer, key string) (float64, error) {
+	if labeler == nil {
+		return 1, nil
+	}
+	labels, err := labeler.EdgeLabels(from, to)
+	if err != nil {
+		return 0, err
+	}
+	weight, found := 1.0, false
+	for _, kv := range labels {
+		if kv[0] != key {
+			continue
+		}
+		w, err := parseNumber(kv[1])
+		if err != nil {
+			return 0, fmt.Errorf("the weight '%s' of the edge from '%s' to '%s' is not a number", kv[1], from, to)
+		}
+		if w < 0 {
+			return 0, fmt.Errorf("the weight of the edge from '%s' to '%s' is negative", from, to)
+		}
+		if !found || w < weight {
+			weight, found = w, true
+		}
+	}
+	return weight, nil
+}
+
+func (w *weightedGraph) other(e weightedEdge, v int) int {
+	if e.from == v {
+		return e.to
+	}
+	return e.from
+}
+
+func (w *weightedGraph) stringers(edges []int) [][2]fmt.Stringer {
+	pairs := make([][2]fmt.Stringer, 0, len(edges))
+	for _, e := range edges {
+		pairs = append(pairs, [2]fmt.Stringer{w.nodes[w.edges[e].from], w.nodes[w.edges[e].to]})
+	}
+	return pairs
+}
+
+// unionFind holds disjoint sets of indices
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	u := make(unionFind, n)
+	for i := range u {
+		u[i] = i
+	}
+	return u
+}
+
+func (u unionFind) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+// union returns false if both were in the same set already
+func (u unionFind) union(a, b int) bool {
+	a, b = u.find(a), u.find(b)
+	if a == b {
+		return false
+	}
+	u[b] = a
+	return true
+}
+
+// MinimumSpanningForest returns the edges of a minimum spanning tree of every component, using Kruskal's algorithm.
+// The weights are taken from the edge labels with the key, edges without it weigh 1.
+func MinimumSpanningForest(g walder.Graph, key string) ([][2]fmt.Stringer, error) {
+	w, err := newWeightedGraph(g, key)
+	if err != nil {
+		return nil, err
+	}
+	return w.stringers(w.kruskal(nil)), nil
+}
+
+// kruskal returns the edges of the minimum spanning forest, only using the allowed edges if they are given
+func (w *weightedGraph) kruskal(allowed map[int]bool) []int {
+	var order []int
+	for e := range w.edges {
+		if allowed == nil || allowed[e] {
+			order = append(order, e)
+		}
+	}
+	// stable to keep the order of the graph for equal weights
+	sort.SliceStable(order, func(a, b int) bool {
+		return w.edges[order[a]].weight < w.edges[order[b]].weight
+	})
+	sets := newUnionFind(len(w.nodes))
+	var tree []int
+	for _, e := range order {
+		if sets.union(w.edges[e].from, w.edges[e].to) {
+			tree = append(tree, e)
+		}
+	}
+	return tree
+}
+
+// SteinerTree returns the edges of a tree connecting the terminals, which is at most twice as heavy as the lightest one.
+// Like Takahashi and Matsuyama it starts with the first terminal and adds the shortest path to the nearest terminal,
+// until all are connected. At the end the tree is replaced by the minimum spanning tree of all edges between its nodes,
+// from which the leafs are removed, that are no terminals.
+func SteinerTree(g walder.Graph, key string, terminals []fmt.Stringer) ([][2]fmt.Stringer, error) {
+	if len(terminals) == 0 {
+		return nil, fmt.Errorf("want at least one terminal, but got none")
+	}
+	w, err := newWeightedGraph(g, key)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(w.nodes))
+	for i, n := range w.nodes {
+		index[n.String()] = i
+	}
+	missing := make(map[int]bool)
+	terminal := make(map[int]bool)
+	for _, t := range terminals {
+		if t == nil {
+			return nil, fmt.Errorf("recieved nil value")
+		}
+		i, ok := index[t.String()]
+		if !ok {
+			return nil, fmt.Errorf("'%s' was not returned by NodeAll", t)
+		}
+		missing[i] = true
+		terminal[i] = true
+	}
+	first := index[terminals[0].String()]
+	delete(missing, first)
+	inTree := map[int]bool{first: true}
+
+	for len(missing) > 0 {
+		reached, via := w.nearest(inTree, missing)
+		if reached < 0 {
+			return nil, fmt.Errorf("the terminals are not connected, %d can't be reached from '%s'", len(missing), terminals[0])
+		}
+		for v := reached; !inTree[v]; {
+			e := via[v]
+			inTree[v] = true
+			delete(missing, v)
+			v = w.other(w.edges[e], v)
+		}
+	}
+	between := make(map[int]bool)
+	for e, edge := range w.edges {
+		if inTree[edge.from] && inTree[edge.to] {
+			between[e] = true
+		}
+	}
+	return w.stringers(w.prune(w.kruskal(between), terminal)), nil
+}
+
+// prune removes the edges to leafs which are no terminals, until every leaf of the tree is a terminal
+func (w *weightedGraph) prune(tree []int, terminal map[int]bool) []int {
+	degree := make(map[int]int)
+	for _, e := range tree {
+		degree[w.edges[e].from]++
+		degree[w.edges[e].to]++
+	}
+	removed := make(map[int]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, e := range tree {
+			from, to := w.edges[e].from, w.edges[e].to
+			if removed[e] {
+				continue
+			}
+			if (degree[from] == 1 && !terminal[from]) || (degree[to] == 1 && !terminal[to]) {
+				removed[e] = true
+				degree[from]--
+				degree[to]--
+				changed = true
+			}
+		}
+	}
+	kept := make([]int, 0, len(tree))
+	for _, e := range tree {
+		if !removed[e] {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// nearest searches from all nodes of the tree at once and returns the first reached target,
+// with the edge over which every visited node was reached, or -1 if no target can be reached
+func (w *weightedGraph) nearest(tree, targets map[int]bool) (int, map[int]int) {
+	distance := make(map[int]float64)
+	via := make(map[int]int)
+	done := make(map[int]bool)
+	queue := &weightQueue{}
+	for v := range w.nodes {
+		if tree[v] {
+			distance[v] = 0
+			heap.Push(queue, weightItem{node: v})
+		}
+	}
+	for queue.Len() > 0 {
+		cur := heap.Pop(queue).(weightItem)
+		v := cur.node
+		if done[v] {
+			continue
+		}
+		done[v] = true
+		if targets[v] {
+			return v, via
+		}
+		for _, e := range w.adjacent[v] {
+			o := w.other(w.edges[e], v)
+			if done[o] {
+				continue
+			}
+			d := cur.distance + w.edges[e].weight
+			if former, ok := distance[o]; ok && former <= d {
+				continue
+			}
+			distance[o] = d
+			via[o] = e
+			heap.Push(queue, weightItem{node: o, distance: d})
+		}
+	}
+	return -1, nil
+}
+
+type weightItem struct {
+	node     int
+	distance float64
+}
+
+// weightQueue is a min heap ordered by distance
+type weightQueue []weightItem
+
+func (q weightQueue) Len() int            { return len(q) }
+func (q weightQueue) Less(a, b int) bool  { return q[a].distance < q[b].distance }
+func (q weightQueue) Swap(a, b int)       { q[a], q[b] = q[b], q[a] }
+func (q *weightQueue) Push(x interface{}) { *q = append(*q, x.(weightItem)) }
+func (q *weightQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// writeTree writes the nodes and the edges into the graph, nodes with the same string are only created once
+func writeTree(gw graphWriter, nodes []fmt.Stringer, edges [][2]fmt.Stringer) error {
+	lookup := make(map[string]fmt.Stringer)
+	create := func(oldNode fmt.Stringer) (fmt.Stringer, error) {
+		if newNode, ok := lookup[oldNode.String()]; ok {
+			return newNode, nil
+		}
+		newNode, err := gw.NodeCreate(oldNode)
+		if err != nil {
+			return nil, err
+		}
+		lookup[oldNode.String()] = newNode
+		return newNode, nil
+	}
+	for _, n := range nodes {
+		if _, err := create(n); err != nil {
+			return err
+		}
+	}
+	for _, e := range edges {
+		from, err := create(e[0])
+		if err != nil {
+			return err
+		}
+		to, err := create(e[1])
+		if err != nil {
+			return err
+		}
+		err = gw.EdgeCreate(from, to)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMinimumSpanningForest(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"unweighted chain", `digraph{a->b->c}`, "a->b b->c"},
+		{"lightest edges", `digraph{a->b[weight=1]; b->c[weight=2]; a->c[weight=3]}`, "a->b b->c"},
+		{"direction is ignored", `digraph{b->a[weight=1]; c->b[weight=1]; a->c[weight=5]}`, "b->a c->b"},
+		{"quoted weights", `digraph{a->b[weight="4"]; b->c[weight="1"]; a->c[weight="2"]}`, "a->c b->c"},
+		{"lighter parallel edge", `digraph{a->b[weight=9]; b->a[weight=1]; b->c[weight=2]; a->c[weight=3]}`, "a->b b->c"},
+		{"forest", `digraph{a->b; c->d; e}`, "a->b c->d"},
+		{"self loop", `digraph{a->a; a->b}`, "a->b"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			edges, err := MinimumSpanningForest(openDot(t, test.source), "weight")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := edgeNames(edges); got != test.want {
+				t.Errorf("want edges '%s', but got '%s'", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSteinerTree(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		terminals []string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "single terminal",
+			source:    `digraph{a->b}`,
+			terminals: []string{"a"},
+			want:      "",
+		},
+		{
+			name:      "path through a steiner node",
+			source:    `digraph{a->c[weight=5]; a->s[weight=1]; s->c[weight=1]; s->x[weight=1]}`,
+			terminals: []string{"a", "c"},
+			want:      "a->s s->c",
+		},
+		{
+			name:      "star",
+			source:    `digraph{a->s; b->s; c->s; a->b[weight=3]; b->c[weight=3]}`,
+			terminals: []string{"a", "b", "c"},
+			want:      "a->s b->s c->s",
+		},
+		{
+			name:      "edge between the paths",
+			source:    `digraph{a->x[weight=2]; x->b[weight=2]; x->y[weight=1]; y->c[weight=1.2]; b->y[weight=1.5]; a->b[weight=5]}`,
+			terminals: []string{"a", "b", "c"},
+			want:      "a->x b->y x->y y->c",
+		},
+		{
+			name:      "unconnected",
+			source:    `digraph{a->b; c}`,
+			terminals: []string{"a", "c"},
+			wantErr:   true,
+		},
+		{
+			name:      "negative weight",
+			source:    `digraph{a->b[weight=-1]}`,
+			terminals: []string{"a", "b"},
+			wantErr:   true,
+		},
+		{
+			name:      "no number",
+			source:    `digraph{a->b[weight=heavy]}`,
+			terminals: []string{"a", "b"},
+			wantErr:   true,
+		},
+		{
+			name:    "no terminals",
+			source:  `digraph{a}`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			var terminals []fmt.Stringer
+			for _, name := range test.terminals {
+				terminals = append(terminals, dotNode(t, d, name))
+			}
+			edges, err := SteinerTree(d, "weight", terminals)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("want error, but got '%s'", edgeNames(edges))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := edgeNames(edges); got != test.want {
+				t.Errorf("want edges '%s', but got '%s'", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSteinerPrune(t *testing.T) {
+	w, err := newWeightedGraph(openDot(t, `digraph{a->s; s->b; b->t; t->u}`), "weight")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terminal := make(map[int]bool)
+	for i, n := range w.nodes {
+		if n.String() == "a" || n.String() == "b" {
+			terminal[i] = true
+		}
+	}
+	got := edgeNames(w.stringers(w.prune(w.kruskal(nil), terminal)))
+	if got != "a->s s->b" {
+		t.Errorf("want edges 'a->s s->b', but got '%s'", got)
+	}
+}
@@ -0,0 +1,195 @@
+package lib
+
+import (
+	"fmt"
+
+	"github.com/treilik/walder"
+)
+
+// The structural algorithms treat the graph as undirected and simple,
+// so edges in both directions and parallel edges count as one.
+
+// newUndirected returns the index graph with the neighbors of every node in out,
+// which are taken from walder.GraphNeighbors or else from both directions of walder.GraphDirected
+func newUndirected(g walder.Graph) (*indexGraph, error) {
+	x, err := newEdgeIndex(g, true)
+	if err != nil {
+		return nil, err
+	}
+	u := &indexGraph{
+		nodes: x.nodes,
+		out:   make([][]int, len(x.nodes)),
+	}
+	seen := make(map[[2]int]bool)
+	err = x.walk(func(i, j int) error {
+		if i == j || seen[[2]int{i, j}] {
+			return nil
+		}
+		seen[[2]int{i, j}] = true
+		seen[[2]int{j, i}] = true
+		u.out[i] = append(u.out[i], j)
+		u.out[j] = append(u.out[j], i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.in = u.out
+	return u, nil
+}
+
+// lowpoint holds the depth first search of Hopcroft and Tarjan,
+// which finds articulation points and bridges by the lowest discovery time reachable through a back edge
+type lowpoint struct {
+	g           *indexGraph
+	counter     int
+	discovered  []int
+	low         []int
+	articulated []bool
+	bridges     [][2]int
+}
+
+func newLowpoint(g *indexGraph) *lowpoint {
+	l := &lowpoint{
+		g:           g,
+		discovered:  make([]int, len(g.nodes)),
+		low:         make([]int, len(g.nodes)),
+		articulated: make([]bool, len(g.nodes)),
+	}
+	for v := range l.discovered {
+		l.discovered[v] = -1
+	}
+	for v := range g.nodes {
+		if l.discovered[v] < 0 {
+			l.search(v, -1)
+		}
+	}
+	return l
+}
+
+func (l *lowpoint) search(v, parent int) {
+	l.discovered[v] = l.counter
+	l.low[v] = l.counter
+	l.counter++
+	children := 0
+	for _, w := range l.g.out[v] {
+		if w == parent {
+			continue
+		}
+		if l.discovered[w] >= 0 {
+			if l.discovered[w] < l.low[v] {
+				l.low[v] = l.discovered[w]
+			}
+			continue
+		}
+		children++
+		l.search(w, v)
+		if l.low[w] < l.low[v] {
+			l.low[v] = l.low[w]
+		}
+		// nothing below w reaches above v
+		if parent >= 0 && l.low[w] >= l.discovered[v] {
+			l.articulated[v] = true
+		}
+		if l.low[w] > l.discovered[v] {
+			l.bridges = append(l.bridges, [2]int{v, w})
+		}
+	}
+	// the root separates its subtrees
+	if parent < 0 && children > 1 {
+		l.articulated[v] = true
+	}
+}
+
+// ArticulationPoints returns the nodes whose removal disconnects there component
+func ArticulationPoints(g walder.Graph) ([]fmt.Stringer, error) {
+	u, err := newUndirected(g)
+	if err != nil {
+		return nil, err
+	}
+	l := newLowpoint(u)
+	var points []fmt.Stringer
+	for v, ok := range l.articulated {
+		if ok {
+			points = append(points, u.nodes[v])
+		}
+	}
+	return points, nil
+}
+
+// Bridges returns the edges whose removal disconnects there component
+func Bridges(g walder.Graph) ([][2]fmt.Stringer, error) {
+	u, err := newUndirected(g)
+	if err != nil {
+		return nil, err
+	}
+	l := newLowpoint(u)
+	bridges := make([][2]fmt.Stringer, 0, len(l.bridges))
+	for _, b := range l.bridges {
+		bridges = append(bridges, [2]fmt.Stringer{u.nodes[b[0]], u.nodes[b[1]]})
+	}
+	return bridges, nil
+}
+
+// CoreNumbers returns for every node the biggest k, for which the node is part of the k-core.
+// The k-core is what is left after removing all nodes with less then k neighbors until there are none.
+func CoreNumbers(g walder.Graph) (map[string]int, error) {
+	u, err := newUndirected(g)
+	if err != nil {
+		return nil, err
+	}
+	core := coreNumbers(u)
+	numbers := make(map[string]int, len(core))
+	for v, k := range core {
+		numbers[u.nodes[v].String()] = k
+	}
+	return numbers, nil
+}
+
+// coreNumbers peels the nodes in order of there remaining degree, like Batagelj and Zaversnik
+func coreNumbers(u *indexGraph) []int {
+	n := len(u.nodes)
+	degree := make([]int, n)
+	maxDegree := 0
+	for v := range u.nodes {
+		degree[v] = len(u.out[v])
+		if degree[v] > maxDegree {
+			maxDegree = degree[v]
+		}
+	}
+	buckets := make([][]int, maxDegree+1)
+	for v, d := range degree {
+		buckets[d] = append(buckets[d], v)
+	}
+	removed := make([]bool, n)
+	core := make([]int, n)
+	k := 0
+	for d := 0; d <= maxDegree; {
+		if len(buckets[d]) == 0 {
+			d++
+			continue
+		}
+		v := buckets[d][len(buckets[d])-1]
+		buckets[d] = buckets[d][:len(buckets[d])-1]
+		// the buckets keep stale entries of nodes whose degree was lowered
+		if removed[v] || degree[v] != d {
+			continue
+		}
+		if d > k {
+			k = d
+		}
+		core[v] = k
+		removed[v] = true
+		for _, w := range u.out[v] {
+			if removed[w] || degree[w] == 0 {
+				continue
+			}
+			degree[w]--
+			buckets[degree[w]] = append(buckets[degree[w]], w)
+			if degree[w] < d {
+				d = degree[w]
+			}
+		}
+	}
+	return core
+}
@@ -0,0 +1,184 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStructure(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		articulation string
+		bridges      string
+		core         map[string]int
+	}{
+		{
+			name:         "chain",
+			source:       `digraph{a->b->c}`,
+			articulation: "b",
+			bridges:      "a->b b->c",
+			core:         map[string]int{"a": 1, "b": 1, "c": 1},
+		},
+		{
+			name:         "cycle",
+			source:       `digraph{a->b->c->a}`,
+			articulation: "",
+			bridges:      "",
+			core:         map[string]int{"a": 2, "b": 2, "c": 2},
+		},
+		{
+			name:         "two triangles with a bridge",
+			source:       `digraph{a->b->c->a; d->e->f->d; c->d}`,
+			articulation: "c d",
+			bridges:      "c->d",
+			core:         map[string]int{"a": 2, "d": 2},
+		},
+		{
+			name:         "both directions count once",
+			source:       `digraph{a->b; b->a; b->c}`,
+			articulation: "b",
+			bridges:      "a->b b->c",
+			core:         map[string]int{"a": 1, "b": 1},
+		},
+		{
+			name:         "root with two subtrees",
+			source:       `digraph{r->a; r->b; c}`,
+			articulation: "r",
+			bridges:      "r->a r->b",
+			core:         map[string]int{"r": 1, "c": 0},
+		},
+		{
+			name:         "clique with tail",
+			source:       `digraph{a->b; a->c; a->d; b->c; b->d; c->d; d->e; e->f}`,
+			articulation: "d e",
+			bridges:      "d->e e->f",
+			core:         map[string]int{"a": 3, "d": 3, "e": 1, "f": 1},
+		},
+		{
+			name:         "self loop",
+			source:       `digraph{a->a}`,
+			articulation: "",
+			bridges:      "",
+			core:         map[string]int{"a": 0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := openDot(t, test.source)
+			points, err := ArticulationPoints(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(points); got != test.articulation {
+				t.Errorf("want articulation points '%s', but got '%s'", test.articulation, got)
+			}
+			bridges, err := Bridges(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := edgeNames(bridges); got != test.bridges {
+				t.Errorf("want bridges '%s', but got '%s'", test.bridges, got)
+			}
+			core, err := CoreNumbers(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range test.core {
+				if core[name] != want {
+					t.Errorf("want core number of %s %d, but got %d", name, want, core[name])
+				}
+			}
+		})
+	}
+}
+
+func TestStructureDimensions(t *testing.T) {
+	d := openDot(t, `digraph{a->b; a->c; a->d; b->c; b->d; c->d; d->e; e->f}`)
+	s, err := newStructure(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		dim   dimension
+		nodes string
+		// the neighbors of d
+		neighbors string
+	}{
+		{dimArticulation, "d e", "e"},
+		{dimBridges, "d e f", "e"},
+		{dimCore, "a b c d", "a b c"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.dim), func(t *testing.T) {
+			if err := s.DimensionSet(stringer(test.dim)); err != nil {
+				t.Fatal(err)
+			}
+			all, err := s.NodeAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(all); got != test.nodes {
+				t.Errorf("want nodes '%s', but got '%s'", test.nodes, got)
+			}
+			neighbors, err := s.Neighbors(dotNode(t, d, "d"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedNames(neighbors); got != test.neighbors {
+				t.Errorf("want neighbors '%s', but got '%s'", test.neighbors, got)
+			}
+		})
+	}
+	labels, err := s.NodeLabels(dotNode(t, d, "e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := labelValue(labels, "core"); got != "1" {
+		t.Errorf("want core '1', but got '%s'", got)
+	}
+	if err := s.DimensionSet(stringer("unknown")); err == nil {
+		t.Error("want error for a unknown dimension, but got none")
+	}
+}
+
+func TestEdgeIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		undirected bool
+		want       string
+		marked     bool
+	}{
+		{"directed", `digraph{a->b; a->b; b->c}`, false, "a->b b->c", false},
+		{"both directions", `digraph{a->b; a->b; b->c}`, true, "a->b b->a b->c c->b", true},
+		{"undirected dot", `graph{a--b; b--c}`, false, "a->b b->c", true},
+		{"undirected dot both directions", `graph{a--b}`, true, "a->b b->a", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			x, err := newEdgeIndex(openDot(t, test.source), test.undirected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if x.undirected != test.marked {
+				t.Errorf("want undirected %v, but got %v", test.marked, x.undirected)
+			}
+			var edges [][2]fmt.Stringer
+			err = x.walk(func(i, j int) error {
+				edges = append(edges, [2]fmt.Stringer{x.nodes[i], x.nodes[j]})
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := edgeNames(edges); got != test.want {
+				t.Errorf("want edges '%s', but got '%s'", test.want, got)
+			}
+		})
+	}
+
+	if _, err := newEdgeIndex(stringerGraph{}, false); err == nil {
+		t.Error("want error for a graph without NodeAll, but got nil")
+	}
+}
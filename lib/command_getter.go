@@ -353,6 +353,42 @@ func (c *command) returnGraph(g walder.Graph) {
 	c.walder.addError(c.walder.Push(g))
 	return
 }
+
+// returnPaths highlights the nodes of the paths in the current graph and pushes a graph made of the paths
+func (c *command) returnPaths(paths ...[]fmt.Stringer) error {
+	var nodes []fmt.Stringer
+	for _, path := range paths {
+		nodes = append(nodes, path...)
+	}
+	c.walder.addError(c.walder.peek().highlight(nodes...))
+
+	g, err := DotDim{}.New()
+	if err != nil {
+		return err
+	}
+	gw, ok := g.(graphWriter)
+	if !ok {
+		return fmt.Errorf("want %T, but got %T", gw, g)
+	}
+	err = writePaths(gw, paths...)
+	if err != nil {
+		return err
+	}
+	c.returnGraph(gw)
+	return nil
+}
+
+// communities lets the user choose the community detection and returns the found communities
+func (c *command) communities(gd walder.GraphDirected) ([][]fmt.Stringer, error) {
+	algorithm, err := c.choose(stringer("louvain"), stringer("label propagation"))
+	if err != nil {
+		return nil, err
+	}
+	if algorithm.String() == "louvain" {
+		return Louvain(gd)
+	}
+	return LabelPropagation(gd)
+}
 func editGraph[T walder.Graph](c *command, editFunc func(*T) error) error {
 	g, err := c.graph()
 	if err != nil {
@@ -364,3 +400,86 @@ func editGraph[T walder.Graph](c *command, editFunc func(*T) error) error {
 	}
 	return editFunc(&t)
 }
+
+// durationLabel returns the DurationLabel if a node has it and else lets the user choose one of the numeric labels
+func (c *command) durationLabel(gd walder.GraphDirected) (string, error) {
+	labeler, ok := gd.(walder.NodeLabeler)
+	if !ok {
+		return "", fmt.Errorf("%T does not implement %s", gd, nodeLabelerString)
+	}
+	aller, ok := gd.(walder.NodeAller)
+	if !ok {
+		return "", fmt.Errorf("%T does not implement %s", gd, nodeAllerString)
+	}
+	all, err := aller.NodeAll()
+	if err != nil {
+		return "", err
+	}
+	var keys []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, n := range all {
+		labels, err := labeler.NodeLabels(n)
+		if err != nil {
+			return "", err
+		}
+		for _, kv := range labels {
+			if _, err := parseNumber(kv[1]); err != nil {
+				continue
+			}
+			if kv[0] == DurationLabel {
+				return DurationLabel, nil
+			}
+			if !seen[kv[0]] {
+				seen[kv[0]] = true
+				keys = append(keys, stringer(kv[0]))
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no numeric labels found")
+	}
+	key, err := c.choose(keys...)
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
+// edgeWeightLabel lets the user choose one of the numeric edge labels,
+// without any every edge weighs 1 and the WeightLabel is returned
+func (c *command) edgeWeightLabel(g walder.Graph) (string, error) {
+	labeler, ok := g.(walder.EdgeLabeler)
+	if !ok {
+		return WeightLabel, nil
+	}
+	// without a key no label is parsed, only the edges are needed
+	w, err := newWeightedGraph(g, "")
+	if err != nil {
+		return "", err
+	}
+	var keys []fmt.Stringer
+	seen := make(map[string]bool)
+	for _, e := range w.edges {
+		labels, err := labeler.EdgeLabels(w.nodes[e.from], w.nodes[e.to])
+		if err != nil {
+			return "", err
+		}
+		for _, kv := range labels {
+			if _, err := parseNumber(kv[1]); err != nil {
+				continue
+			}
+			if !seen[kv[0]] {
+				seen[kv[0]] = true
+				keys = append(keys, stringer(kv[0]))
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return WeightLabel, nil
+	}
+	key, err := c.choose(keys...)
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
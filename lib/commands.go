@@ -89,6 +89,69 @@ var (
 				return err
 			},
 		},
+		{
+			Name:        "shortest path",
+			Description: "",
+			run: func(c *command) error {
+				from, err := c.node("from")
+				if err != nil {
+					return err
+				}
+				c.pause("get 'to' node")
+				to, err := c.node("to")
+				if err != nil {
+					return err
+				}
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				path, err := ShortestPath(*gd, from, to)
+				if err != nil {
+					return err
+				}
+				return c.returnPaths(path)
+			},
+		},
+		{
+			Name:        "k shortest paths",
+			Description: "",
+			run: func(c *command) error {
+				from, err := c.node("from")
+				if err != nil {
+					return err
+				}
+				c.pause("get 'to' node")
+				to, err := c.node("to")
+				if err != nil {
+					return err
+				}
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				k, err := c.repeat("amount of paths")
+				if err != nil {
+					return err
+				}
+				paths, err := KShortestPaths(*gd, from, to, k)
+				if err != nil {
+					return err
+				}
+				return c.returnPaths(paths...)
+			},
+		},
+		{
+			Name:        "clear highlight",
+			Description: "",
+			run: func(c *command) error {
+				b, err := c.graphHolder()
+				if err != nil {
+					return err
+				}
+				return b.highlight()
+			},
+		},
 		{
 			Name:        "rename node",
 			Description: "",
@@ -136,6 +199,218 @@ var (
 				return BreakCycles(node, *gd, *gc)
 			},
 		},
+		{
+			Name:        "condensation",
+			Description: "",
+			run: func(c *command) error {
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				cond, err := newCondensation(*gd)
+				if err != nil {
+					return err
+				}
+				c.returnGraph(cond)
+				return nil
+			},
+		},
+		{
+			Name:        "component size sort",
+			Description: "",
+			run: func(c *command) error {
+				d, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				cond, ok := (*d).(*condensation)
+				if !ok {
+					cond, err = newCondensation(*d)
+					if err != nil {
+						return err
+					}
+				}
+				return c.holderList(func(l *holderList) error {
+					former := l.lessFunc
+					defer func(former func(a, b int) bool) {
+						l.lessFunc = former
+					}(former)
+
+					// the biggest components first
+					l.lessFunc = func(a, b int) bool {
+						aItem, _ := l.GetItem(a)
+						aSize, _ := cond.size(aItem)
+
+						bItem, _ := l.GetItem(b)
+						bSize, _ := cond.size(bItem)
+
+						return aSize > bSize
+					}
+					sort.Stable(l)
+					return nil
+				})
+			},
+		},
+		{
+			Name:        "communities",
+			Description: "",
+			run: func(c *command) error {
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				communities, err := c.communities(*gd)
+				if err != nil {
+					return err
+				}
+				c.returnGraph(newGrouping(*gd, "communities", "community", communities))
+				return nil
+			},
+		},
+		{
+			Name:        "write clusters to dot",
+			Description: "",
+			run: func(c *command) error {
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				// a grouped graph is written as it is, any other graph is grouped into communities
+				grouped, ok := (*gd).(*condensation)
+				if !ok {
+					communities, err := c.communities(*gd)
+					if err != nil {
+						return err
+					}
+					grouped = newGrouping(*gd, "communities", "community", communities)
+				}
+				// a copy of a dot graph gets the clusters added, so its attributes are kept
+				if source, ok := grouped.origin.(*DotGraph); ok {
+					d, err := grouped.addClusters(source)
+					if err != nil {
+						return err
+					}
+					c.returnGraph(d)
+					return nil
+				}
+				g, err := DotDim{}.New()
+				if err != nil {
+					return err
+				}
+				d, ok := g.(*DotGraph)
+				if !ok {
+					return fmt.Errorf("want %T, but got %T", d, g)
+				}
+				err = grouped.writeClusters(d)
+				if err != nil {
+					return err
+				}
+				c.returnGraph(d)
+				return nil
+			},
+		},
+		{
+			Name:        "dominator tree",
+			Description: "",
+			run: func(c *command) error {
+				entry, err := c.node("entry")
+				if err != nil {
+					return err
+				}
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				tree, err := Dominators(*gd, entry)
+				if err != nil {
+					return err
+				}
+				c.returnGraph(tree)
+				return nil
+			},
+		},
+		{
+			Name:        "post dominator tree",
+			Description: "",
+			run: func(c *command) error {
+				exit, err := c.node("exit")
+				if err != nil {
+					return err
+				}
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				tree, err := PostDominators(*gd, exit)
+				if err != nil {
+					return err
+				}
+				c.returnGraph(tree)
+				return nil
+			},
+		},
+		{
+			Name:        "maximum flow",
+			Description: "",
+			run: func(c *command) error {
+				source, err := c.node("source")
+				if err != nil {
+					return err
+				}
+				c.pause("get 'sink' node")
+				sink, err := c.node("sink")
+				if err != nil {
+					return err
+				}
+				g, err := c.graph()
+				if err != nil {
+					return err
+				}
+				key, err := c.edgeWeightLabel(*g)
+				if err != nil {
+					return err
+				}
+				f, err := newFlowNetwork(*g, key)
+				if err != nil {
+					return err
+				}
+				err = f.maximize(source, sink)
+				if err != nil {
+					return err
+				}
+				// the flow is shown with the minimum cut on top of it
+				c.returnGraph(newFlows(f))
+				c.returnGraph(newMinimumCut(f))
+				return nil
+			},
+		},
+		{
+			Name:        "critical path",
+			Description: "",
+			run: func(c *command) error {
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				key, err := c.durationLabel(*gd)
+				if err != nil {
+					return err
+				}
+				s, err := newSchedule(*gd, key)
+				if err != nil {
+					return err
+				}
+				path, err := s.critical()
+				if err != nil {
+					return err
+				}
+				c.walder.addError(c.walder.peek().highlight(path...))
+				// the schedule labels all nodes, the critical path is put on top of it
+				c.returnGraph(s)
+				c.returnGraph(criticalPath{schedule: s, path: path})
+				return nil
+			},
+		},
 		{
 			Name:        "edge move",
 			Description: "",
@@ -226,6 +501,38 @@ var (
 				return reduction(fd)
 			},
 		},
+		{
+			Name:        "transitive closure",
+			Description: "",
+			run: func(c *command) error {
+				g, err := c.graph()
+				if err != nil {
+					return err
+				}
+				da, ok := (*g).(directedAller)
+				if !ok {
+					return fmt.Errorf("want %T, but got %T", da, *g)
+				}
+				c.pause("get graph where the closure should be written to")
+				ec, err := c.edgeCreater()
+				if err != nil {
+					return err
+				}
+				return transitiveClosure(da, *ec)
+			},
+		},
+		{
+			Name:        "closure",
+			Description: "",
+			run: func(c *command) error {
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				c.returnGraph(newClosure(*gd))
+				return nil
+			},
+		},
 		{
 			Name:        "delete edge",
 			Description: "",
@@ -865,6 +1172,97 @@ var (
 				})
 			},
 		},
+		{
+			Name:        "centrality",
+			Description: "",
+			run: func(c *command) error {
+				gd, err := c.graphDirected()
+				if err != nil {
+					return err
+				}
+				ranked, err := newCentrality(*gd)
+				if err != nil {
+					return err
+				}
+				c.returnGraph(ranked)
+				return nil
+			},
+		},
+		{
+			Name:        "sort by label",
+			Description: "",
+			run: func(c *command) error {
+				g, err := c.graph()
+				if err != nil {
+					return err
+				}
+				labeler, ok := (*g).(walder.NodeLabeler)
+				if !ok {
+					return fmt.Errorf("want %T, but got %T", labeler, *g)
+				}
+				var all []fmt.Stringer
+				err = c.holderList(func(l *holderList) error {
+					all, err = l.GetAllItems()
+					return err
+				})
+				if err != nil {
+					return err
+				}
+
+				// collect the numeric labels of every item and offer there keys in order of appearance
+				values := make(map[string]map[string]float64)
+				var keys []fmt.Stringer
+				seen := make(map[string]bool)
+				for _, item := range all {
+					labels, err := labeler.NodeLabels(item)
+					if err != nil {
+						continue
+					}
+					values[item.String()] = make(map[string]float64)
+					for _, kv := range labels {
+						v, err := parseNumber(kv[1])
+						if err != nil {
+							continue
+						}
+						values[item.String()][kv[0]] = v
+						if !seen[kv[0]] {
+							seen[kv[0]] = true
+							keys = append(keys, stringer(kv[0]))
+						}
+					}
+				}
+				if len(keys) == 0 {
+					return fmt.Errorf("no numeric labels found")
+				}
+				key, err := c.choose(keys...)
+				if err != nil {
+					return err
+				}
+
+				return c.holderList(func(l *holderList) error {
+					former := l.lessFunc
+					defer func(former func(a, b int) bool) {
+						l.lessFunc = former
+					}(former)
+
+					// the highest values first and the items without the label last
+					l.lessFunc = func(a, b int) bool {
+						aItem, _ := l.GetItem(a)
+						aValue, aOK := values[aItem.String()][key.String()]
+
+						bItem, _ := l.GetItem(b)
+						bValue, bOK := values[bItem.String()][key.String()]
+
+						if aOK != bOK {
+							return aOK
+						}
+						return aValue > bValue
+					}
+					sort.Stable(l)
+					return nil
+				})
+			},
+		},
 		{
 			Name:        "delete Nodes",
 			Description: "",
@@ -1235,6 +1633,74 @@ var (
 				return Reachable(cur, (*from), (*to))
 
 			}},
+		{
+			Name:        "minimum spanning tree",
+			Description: "",
+			run: func(c *command) error {
+				g, err := c.graph()
+				if err != nil {
+					return err
+				}
+				key, err := c.edgeWeightLabel(*g)
+				if err != nil {
+					return err
+				}
+				edges, err := MinimumSpanningForest(*g, key)
+				if err != nil {
+					return err
+				}
+				// all nodes are written, since the forest spans them even if they have no edges
+				aller, ok := (*g).(walder.NodeAller)
+				if !ok {
+					return fmt.Errorf("want %T, but got %T", aller, *g)
+				}
+				all, err := aller.NodeAll()
+				if err != nil {
+					return err
+				}
+				c.pause("get graphCreator")
+				to, err := c.graphCreater()
+				if err != nil {
+					return err
+				}
+				return writeTree(*to, all, edges)
+			},
+		},
+		{
+			Name:        "steiner tree",
+			Description: "",
+			run: func(c *command) error {
+				var terminals []fmt.Stringer
+				err := c.holderList(func(l *holderList) error {
+					terminals = l.GetSelected()
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if len(terminals) == 0 {
+					return fmt.Errorf("select the nodes which should be connected")
+				}
+				g, err := c.graph()
+				if err != nil {
+					return err
+				}
+				key, err := c.edgeWeightLabel(*g)
+				if err != nil {
+					return err
+				}
+				edges, err := SteinerTree(*g, key, terminals)
+				if err != nil {
+					return err
+				}
+				c.pause("get graphCreator")
+				to, err := c.graphCreater()
+				if err != nil {
+					return err
+				}
+				return writeTree(*to, terminals, edges)
+			},
+		},
 		{
 			Name:        "set dimension",
 			Description: "",
@@ -1273,6 +1739,29 @@ var (
 				return nil
 			},
 		},
+		{
+			Name:        "push structure filter",
+			Description: "",
+			run: func(c *command) error {
+				g, err := c.graph()
+				if err != nil {
+					return err
+				}
+				s, err := newStructure(*g)
+				if err != nil {
+					return err
+				}
+				// without a repeat amount the densest core is shown
+				if c.repeatAmount != "" {
+					s.k, err = c.repeat("k of the k-core")
+					if err != nil {
+						return err
+					}
+				}
+				c.returnGraph(s)
+				return nil
+			},
+		},
 		{
 			Name:        "transfer",
 			Description: "",
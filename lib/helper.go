@@ -7,6 +7,7 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -35,6 +36,11 @@ func (s stringer) View() string {
 	return s.String()
 }
 
+// parseNumber parses the value of a label, dot attributes might be quoted
+func parseNumber(value string) (float64, error) {
+	return strconv.ParseFloat(strings.Trim(value, "\""), 64)
+}
+
 type stringSorter []fmt.Stringer
 
 func (s *stringSorter) Len() int           { return len(*s) }
@@ -67,6 +73,27 @@ func (b *buffer) Close() error {
 	return f.Close()
 }
 
+// applyBuffer hands its whole content to apply after every write,
+// so in memory graphs are up to date even if the writer is never closed.
+type applyBuffer struct {
+	content bytes.Buffer
+	apply   func(content []byte) error
+}
+
+var _ io.WriteCloser = &applyBuffer{}
+
+func (b *applyBuffer) Write(p []byte) (int, error) {
+	n, err := b.content.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, b.apply(b.content.Bytes())
+}
+
+func (b *applyBuffer) Close() error {
+	return nil
+}
+
 func getNewWriter() func() (io.WriteCloser, error) {
 	path := "/home/kili/.walder/" + strconv.FormatInt(time.Now().UnixMicro(), 10)
 	os.Create(path)
@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/treilik/walder"
+)
+
+// pageRankDamping is the usual probability to follow an edge instead of jumping
+const pageRankDamping = 0.85
+
+// centrality passes the origin through and adds the centrality scores of every node as labels.
+// The scores are computed once, so they don't follow later changes of the origin.
+type centrality struct {
+	origin walder.GraphDirected
+	keys   []string
+	scores []map[string]float64
+}
+
+func newCentrality(origin walder.GraphDirected) (*centrality, error) {
+	c := &centrality{origin: origin}
+	measures := []struct {
+		key     string
+		measure func(walder.GraphDirected) (map[string]float64, error)
+	}{
+		{"pagerank", func(g walder.GraphDirected) (map[string]float64, error) { return PageRank(g, pageRankDamping) }},
+		{"betweenness", Betweenness},
+		{"closeness", Closeness},
+		{"eigenvector", Eigenvector},
+	}
+	for _, m := range measures {
+		scores, err := m.measure(origin)
+		if err != nil {
+			return nil, err
+		}
+		c.keys = append(c.keys, m.key)
+		c.scores = append(c.scores, scores)
+	}
+	return c, nil
+}
+
+var _ walder.GraphDirected = &centrality{}
+
+func (c *centrality) String() string {
+	if c.origin == nil {
+		return "nothing to rank"
+	}
+	return fmt.Sprintf("centrality of %s", c.origin.String())
+}
+func (c *centrality) HomeNodes() ([]fmt.Stringer, error) {
+	return c.origin.HomeNodes()
+}
+func (c *centrality) Incoming(node fmt.Stringer) ([]fmt.Stringer, error) {
+	return c.origin.Incoming(node)
+}
+func (c *centrality) Outgoing(node fmt.Stringer) ([]fmt.Stringer, error) {
+	return c.origin.Outgoing(node)
+}
+
+var _ walder.NodeAller = &centrality{}
+
+func (c *centrality) NodeAll() ([]fmt.Stringer, error) {
+	aller, ok := c.origin.(walder.NodeAller)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement %s", c.origin, nodeAllerString)
+	}
+	return aller.NodeAll()
+}
+
+var _ walder.NodeLabeler = &centrality{}
+
+// NodeLabels returns the labels of the origin followed by the scores
+func (c *centrality) NodeLabels(node fmt.Stringer) ([][2]string, error) {
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	var labels [][2]string
+	if l, ok := c.origin.(walder.NodeLabeler); ok {
+		var err error
+		labels, err = l.NodeLabels(node)
+		if err != nil {
+			return nil, err
+		}
+	}
+	key := node.String()
+	for i, scores := range c.scores {
+		score, ok := scores[key]
+		if !ok {
+			return nil, fmt.Errorf("'%s' was not part of the graph when the scores were computed", key)
+		}
+		labels = append(labels, [2]string{c.keys[i], strconv.FormatFloat(score, 'g', 4, 64)})
+	}
+	return labels, nil
+}
+
+var _ walder.Typer = &centrality{}
+
+func (c *centrality) GetType(node fmt.Stringer) (string, error) {
+	t, ok := c.origin.(walder.Typer)
+	if !ok {
+		return "", fmt.Errorf("want %T, but got %T", t, c.origin)
+	}
+	return t.GetType(node)
+}
@@ -0,0 +1,112 @@
+package lib
+
+import (
+	"fmt"
+
+	"github.com/treilik/walder"
+)
+
+// closure is the transitive closure of the origin, so every node reachable in the origin is a direct neighbor.
+// The reachable nodes are computed on request and remembered, so the origin should not change meanwhile.
+type closure struct {
+	origin walder.GraphDirected
+
+	// out and in hold the nodes reachable from and reaching every node computed so far
+	out map[string][]fmt.Stringer
+	in  map[string][]fmt.Stringer
+}
+
+func newClosure(origin walder.GraphDirected) *closure {
+	return &closure{
+		origin: origin,
+		out:    make(map[string][]fmt.Stringer),
+		in:     make(map[string][]fmt.Stringer),
+	}
+}
+
+var _ walder.GraphDirected = &closure{}
+
+func (c *closure) String() string {
+	if c.origin == nil {
+		return "empty closure"
+	}
+	return fmt.Sprintf("closure of %s", c.origin.String())
+}
+func (c *closure) HomeNodes() ([]fmt.Stringer, error) {
+	if c.origin == nil {
+		return nil, fmt.Errorf("underling graph is nil")
+	}
+	return c.origin.HomeNodes()
+}
+func (c *closure) Outgoing(node fmt.Stringer) ([]fmt.Stringer, error) {
+	if c.origin == nil {
+		return nil, fmt.Errorf("underling graph is nil")
+	}
+	return c.reach(node, c.out, c.origin.Outgoing)
+}
+func (c *closure) Incoming(node fmt.Stringer) ([]fmt.Stringer, error) {
+	if c.origin == nil {
+		return nil, fmt.Errorf("underling graph is nil")
+	}
+	return c.reach(node, c.in, c.origin.Incoming)
+}
+
+// reach searches breadth first along the edges and stops at nodes which were searched before,
+// since everything reachable from them is known already.
+// The node itself is only reachable if it is part of a cycle.
+func (c *closure) reach(node fmt.Stringer, memo map[string][]fmt.Stringer, edges func(fmt.Stringer) ([]fmt.Stringer, error)) ([]fmt.Stringer, error) {
+	if node == nil {
+		return nil, fmt.Errorf("recieved nil value")
+	}
+	key := node.String()
+	if reached, ok := memo[key]; ok {
+		return reached, nil
+	}
+	seen := make(map[string]bool)
+	var reached []fmt.Stringer
+	add := func(n fmt.Stringer) bool {
+		if seen[n.String()] {
+			return false
+		}
+		seen[n.String()] = true
+		reached = append(reached, n)
+		return true
+	}
+	queue := []fmt.Stringer{node}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		next, err := edges(cur)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range next {
+			if n == nil {
+				return nil, fmt.Errorf("recieved nil value")
+			}
+			if !add(n) {
+				continue
+			}
+			known, ok := memo[n.String()]
+			if !ok {
+				queue = append(queue, n)
+				continue
+			}
+			for _, k := range known {
+				add(k)
+			}
+		}
+	}
+	memo[key] = reached
+	return reached, nil
+}
+
+var _ walder.NodeAller = &closure{}
+
+func (c *closure) NodeAll() ([]fmt.Stringer, error) {
+	aller, ok := c.origin.(walder.NodeAller)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement %s", c.origin, nodeAllerString)
+	}
+	return aller.NodeAll()
+}
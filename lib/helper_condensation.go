@@ -0,0 +1,381 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/treilik/walder"
+)
+
+// condensation shows every group of nodes of a graph as one node.
+// For the strongly connected components this makes the graph acyclic.
+type condensation struct {
+	origin walder.GraphDirected
+	// name describes the grouping and group a single group
+	name       string
+	group      string
+	components [][]fmt.Stringer
+	// lookup maps the string of every node to the index of its component
+	lookup map[string]int
+}
+
+func newCondensation(origin walder.GraphDirected) (*condensation, error) {
+	components, err := StronglyConnected(origin)
+	if err != nil {
+		return nil, err
+	}
+	return newGrouping(origin, "condensation", "component", components), nil
+}
+
+// newGrouping returns the graph of the given groups, which have to contain every node once
+func newGrouping(origin walder.GraphDirected, name, group string, components [][]fmt.Stringer) *condensation {
+	c := &condensation{
+		origin:     origin,
+		name:       name,
+		group:      group,
+		components: components,
+		lookup:     make(map[string]int),
+	}
+	for i, members := range components {
+		for _, m := range members {
+			c.lookup[m.String()] = i
+		}
+	}
+	return c
+}
+
+type sccNode struct {
+	index   int
+	members []fmt.Stringer
+}
+
+// String shows single nodes as themselfs and for bigger components the size and the first members
+func (s sccNode) String() string {
+	if len(s.members) == 1 {
+		return s.members[0].String()
+	}
+	names := make([]string, 0, 4)
+	for _, m := range s.members {
+		if len(names) == 3 {
+			names = append(names, "...")
+			break
+		}
+		names = append(names, m.String())
+	}
+	return fmt.Sprintf("%d nodes: %s", len(s.members), strings.Join(names, ", "))
+}
+
+func (c *condensation) node(index int) sccNode {
+	return sccNode{index: index, members: c.components[index]}
+}
+
+func (c *condensation) lookupNode(str fmt.Stringer) (sccNode, error) {
+	if str == nil {
+		return sccNode{}, fmt.Errorf("recieved nil value")
+	}
+	n, ok := str.(sccNode)
+	if !ok {
+		return n, fmt.Errorf("want %T, but got %T", n, str)
+	}
+	if n.index < 0 || n.index >= len(c.components) {
+		return n, fmt.Errorf("'%s' is not part of this graph", n)
+	}
+	return n, nil
+}
+
+// size returns the amount of nodes in the component of the node, which can be a node of the origin too
+func (c *condensation) size(str fmt.Stringer) (int, error) {
+	if n, ok := str.(sccNode); ok {
+		return len(n.members), nil
+	}
+	if str == nil {
+		return 0, fmt.Errorf("recieved nil value")
+	}
+	i, ok := c.lookup[str.String()]
+	if !ok {
+		return 0, fmt.Errorf("'%s' is not part of the graph", str)
+	}
+	return len(c.components[i]), nil
+}
+
+// neighbors returns the components connected to the members, without the component itself
+func (c *condensation) neighbors(n sccNode, edges func(fmt.Stringer) ([]fmt.Stringer, error)) ([]fmt.Stringer, error) {
+	seen := map[int]bool{n.index: true}
+	var neighbors []fmt.Stringer
+	for _, m := range n.members {
+		nodes, err := edges(m)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range nodes {
+			i, ok := c.lookup[o.String()]
+			if !ok {
+				return nil, fmt.Errorf("'%s' was not returned by NodeAll", o)
+			}
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			neighbors = append(neighbors, c.node(i))
+		}
+	}
+	return neighbors, nil
+}
+
+var _ walder.GraphDirected = &condensation{}
+
+func (c *condensation) String() string {
+	if c.origin == nil {
+		return fmt.Sprintf("empty %s", c.name)
+	}
+	return fmt.Sprintf("%s of %s", c.name, c.origin.String())
+}
+
+// HomeNodes returns the components without incoming edges, or all if every component has incoming edges
+func (c *condensation) HomeNodes() ([]fmt.Stringer, error) {
+	var home []fmt.Stringer
+	for i := range c.components {
+		in, err := c.Incoming(c.node(i))
+		if err != nil {
+			return nil, err
+		}
+		if len(in) == 0 {
+			home = append(home, c.node(i))
+		}
+	}
+	if len(home) == 0 {
+		return c.NodeAll()
+	}
+	return home, nil
+}
+
+func (c *condensation) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, err := c.lookupNode(str)
+	if err != nil {
+		return nil, err
+	}
+	return c.neighbors(n, c.origin.Outgoing)
+}
+func (c *condensation) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	n, err := c.lookupNode(str)
+	if err != nil {
+		return nil, err
+	}
+	return c.neighbors(n, c.origin.Incoming)
+}
+
+var _ walder.NodeAller = &condensation{}
+
+func (c *condensation) NodeAll() ([]fmt.Stringer, error) {
+	all := make([]fmt.Stringer, 0, len(c.components))
+	for i := range c.components {
+		all = append(all, c.node(i))
+	}
+	return all, nil
+}
+
+var _ walder.NodeLabeler = &condensation{}
+
+func (c *condensation) NodeLabels(str fmt.Stringer) ([][2]string, error) {
+	n, err := c.lookupNode(str)
+	if err != nil {
+		return nil, err
+	}
+	return [][2]string{{"size", strconv.Itoa(len(n.members))}}, nil
+}
+
+var _ walder.NodeOpener = &condensation{}
+
+// NodeOpen returns the members of the component with the edges between them
+func (c *condensation) NodeOpen(nodes ...fmt.Stringer) (walder.Graph, error) {
+	if len(nodes) != 1 {
+		return nil, fmt.Errorf("want exactly one node, but got %d", len(nodes))
+	}
+	n, err := c.lookupNode(nodes[0])
+	if err != nil {
+		return nil, err
+	}
+	return newInduced(c.origin, fmt.Sprintf("%s of %s", c.group, n.members[0]), n.members), nil
+}
+
+var _ walder.Dimensions = &condensation{}
+
+func (c *condensation) Dimensions(str fmt.Stringer) ([]walder.Graph, error) {
+	g, err := c.NodeOpen(str)
+	if err != nil {
+		return nil, err
+	}
+	return []walder.Graph{g}, nil
+}
+
+// writeClusters writes every component with more than one member as a cluster into the dot graph,
+// followed by the edges of the origin
+func (c *condensation) writeClusters(into *DotGraph) error {
+	lookup := make(map[string]fmt.Stringer)
+	for i, members := range c.components {
+		var nc walder.NodeCreater = into
+		if len(members) > 1 {
+			// graphviz draws subgraphs as boxes if there name starts with cluster
+			cluster, err := into.NodeTypedCreate(stringer("subgraph"), stringer(fmt.Sprintf("cluster_%s_%d", c.group, i)))
+			if err != nil {
+				return err
+			}
+			inner, err := into.NodeOpen(cluster)
+			if err != nil {
+				return err
+			}
+			var ok bool
+			nc, ok = inner.(walder.NodeCreater)
+			if !ok {
+				return fmt.Errorf("want %T, but got %T", nc, inner)
+			}
+		}
+		for _, m := range members {
+			n, err := nc.NodeCreate(m)
+			if err != nil {
+				return err
+			}
+			lookup[m.String()] = n
+		}
+		if len(members) > 1 {
+			if err := into.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, members := range c.components {
+		for _, m := range members {
+			out, err := c.origin.Outgoing(m)
+			if err != nil {
+				return err
+			}
+			for _, o := range out {
+				to, ok := lookup[o.String()]
+				if !ok {
+					return fmt.Errorf("'%s' was not returned by NodeAll", o)
+				}
+				if err := into.EdgeCreate(lookup[m.String()], to); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// addClusters copies the dot graph the nodes are from and puts every component with more than one member
+// into a cluster of the copy, so the attributes of the graph, its nodes and its edges are kept
+func (c *condensation) addClusters(from *DotGraph) (*DotGraph, error) {
+	r, err := from.GetReader()
+	if err != nil {
+		return nil, err
+	}
+	g, err := DotDim{}.Open(r)
+	if err != nil {
+		return nil, err
+	}
+	into, ok := g.(*DotGraph)
+	if !ok {
+		return nil, fmt.Errorf("want %T, but got %T", into, g)
+	}
+	for i, members := range c.components {
+		if len(members) < 2 {
+			continue
+		}
+		name := fmt.Sprintf("cluster_%s_%d", c.group, i)
+		cluster, err := into.NodeTypedCreate(stringer("subgraph"), stringer(name))
+		if err != nil {
+			return nil, err
+		}
+		inner, err := into.NodeOpen(cluster)
+		if err != nil {
+			return nil, err
+		}
+		nc, ok := inner.(walder.NodeTypedCreator)
+		if !ok {
+			return nil, fmt.Errorf("want %T, but got %T", nc, inner)
+		}
+		for _, m := range members {
+			n, ok := m.(node)
+			if !ok {
+				return nil, fmt.Errorf("want %T, but got %T", n, m)
+			}
+			// the node is only declared again inside the cluster, the declaration keeps its attributes
+			_, err := nc.NodeTypedCreate(stringer("node"), stringer(n.Name))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := into.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return into, nil
+}
+
+// induced is the subgraph of the given nodes with all edges between them
+type induced struct {
+	origin  walder.GraphDirected
+	name    string
+	members []fmt.Stringer
+	keys    map[string]bool
+}
+
+func newInduced(origin walder.GraphDirected, name string, members []fmt.Stringer) induced {
+	keys := make(map[string]bool, len(members))
+	for _, m := range members {
+		keys[m.String()] = true
+	}
+	return induced{origin: origin, name: name, members: members, keys: keys}
+}
+
+func (i induced) filter(nodes []fmt.Stringer, err error) ([]fmt.Stringer, error) {
+	if err != nil {
+		return nil, err
+	}
+	var kept []fmt.Stringer
+	for _, n := range nodes {
+		if i.keys[n.String()] {
+			kept = append(kept, n)
+		}
+	}
+	return kept, nil
+}
+
+func (i induced) check(str fmt.Stringer) error {
+	if str == nil {
+		return fmt.Errorf("recieved nil value")
+	}
+	if !i.keys[str.String()] {
+		return fmt.Errorf("'%s' is not part of %s", str, i.name)
+	}
+	return nil
+}
+
+var _ walder.GraphDirected = induced{}
+
+func (i induced) String() string {
+	return i.name
+}
+func (i induced) HomeNodes() ([]fmt.Stringer, error) {
+	return i.members, nil
+}
+func (i induced) Outgoing(str fmt.Stringer) ([]fmt.Stringer, error) {
+	if err := i.check(str); err != nil {
+		return nil, err
+	}
+	return i.filter(i.origin.Outgoing(str))
+}
+func (i induced) Incoming(str fmt.Stringer) ([]fmt.Stringer, error) {
+	if err := i.check(str); err != nil {
+		return nil, err
+	}
+	return i.filter(i.origin.Incoming(str))
+}
+
+var _ walder.NodeAller = induced{}
+
+func (i induced) NodeAll() ([]fmt.Stringer, error) {
+	return i.members, nil
+}
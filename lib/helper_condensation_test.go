@@ -0,0 +1,49 @@
+package lib
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestAddClusters(t *testing.T) {
+	const source = `digraph G { rankdir=LR; a [color=red]; a -> b; b -> a; b -> c [weight=2]; c -> d; d -> c }`
+	d := openDot(t, source)
+	before := readAll(t, d)
+	c, err := newCondensation(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clustered, err := c.addClusters(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clustered == d {
+		t.Fatal("want a copy, but got the same graph")
+	}
+	if after := readAll(t, d); after != before {
+		t.Errorf("want the origin unchanged, but got:\n%s", after)
+	}
+	got := readAll(t, clustered)
+	for _, want := range []string{"rankdir=LR", "color=red", "weight=2", "subgraph cluster_component_"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %s in:\n%s", want, got)
+		}
+	}
+
+	again := openDot(t, got)
+	var clusters []string
+	for name := range again.graph.SubGraphs.SubGraphs {
+		children := again.graph.Relations.SortedChildren(name)
+		clusters = append(clusters, strings.Join(children, " "))
+	}
+	sort.Strings(clusters)
+	if joined := strings.Join(clusters, ", "); joined != "a b, c d" {
+		t.Errorf("want clusters 'a b, c d', but got '%s'", joined)
+	}
+
+	// the origin is unchanged, so adding the clusters again works
+	if _, err := c.addClusters(d); err != nil {
+		t.Fatal(err)
+	}
+}
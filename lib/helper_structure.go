@@ -0,0 +1,237 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/treilik/walder"
+)
+
+const (
+	dimArticulation dimension = "articulation points"
+	dimBridges      dimension = "bridges"
+	dimCore         dimension = "core"
+)
+
+// structure keeps only the nodes which are single points of failure or part of the k-core, depending on the dimension.
+// Like the remover it filters the origin, but the structure is computed once, so it doesn't follow later changes.
+type structure struct {
+	origin walder.Graph
+	dim    dimension
+	// k is the minimal core number of the nodes in the core dimension
+	k int
+
+	nodes        []fmt.Stringer
+	articulation map[string]bool
+	// bridges holds both directions of every bridge
+	bridges    map[[2]string]bool
+	bridgeEnds map[string]bool
+	core       map[string]int
+}
+
+func newStructure(origin walder.Graph) (*structure, error) {
+	u, err := newUndirected(origin)
+	if err != nil {
+		return nil, err
+	}
+	l := newLowpoint(u)
+	s := &structure{
+		origin:       origin,
+		dim:          dimArticulation,
+		nodes:        u.nodes,
+		articulation: make(map[string]bool),
+		bridges:      make(map[[2]string]bool),
+		bridgeEnds:   make(map[string]bool),
+		core:         make(map[string]int),
+	}
+	for v, ok := range l.articulated {
+		if ok {
+			s.articulation[u.nodes[v].String()] = true
+		}
+	}
+	for _, b := range l.bridges {
+		from, to := u.nodes[b[0]].String(), u.nodes[b[1]].String()
+		s.bridges[[2]string{from, to}] = true
+		s.bridges[[2]string{to, from}] = true
+		s.bridgeEnds[from] = true
+		s.bridgeEnds[to] = true
+	}
+	for v, k := range coreNumbers(u) {
+		s.core[u.nodes[v].String()] = k
+		if k > s.k {
+			s.k = k
+		}
+	}
+	return s, nil
+}
+
+// kept reports if the node is shown in the current dimension
+func (s *structure) kept(node fmt.Stringer) bool {
+	key := node.String()
+	switch s.dim {
+	case dimArticulation:
+		return s.articulation[key]
+	case dimBridges:
+		return s.bridgeEnds[key]
+	case dimCore:
+		return s.core[key] >= s.k
+	}
+	return false
+}
+
+// filter keeps the nodes of the dimension, in the bridges dimension only the bridges are kept as edges
+func (s *structure) filter(from fmt.Stringer, nodes []fmt.Stringer, err error) ([]fmt.Stringer, error) {
+	if err != nil {
+		return nil, err
+	}
+	var kept []fmt.Stringer
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		if s.dim == dimBridges && !s.bridges[[2]string{from.String(), n.String()}] {
+			continue
+		}
+		if s.kept(n) {
+			kept = append(kept, n)
+		}
+	}
+	return kept, nil
+}
+
+func (s *structure) check(node fmt.Stringer) error {
+	if node == nil {
+		return fmt.Errorf("recieved nil value")
+	}
+	if _, ok := s.core[node.String()]; !ok {
+		return fmt.Errorf("'%s' was not part of the graph when the structure was computed", node)
+	}
+	return nil
+}
+
+var _ walder.DimensionChanger = &structure{}
+
+func (s *structure) DimensionGetAll() ([]fmt.Stringer, error) {
+	return []fmt.Stringer{
+		stringer(dimArticulation),
+		stringer(dimBridges),
+		stringer(dimCore),
+	}, nil
+}
+func (s *structure) DimensionSet(dim fmt.Stringer) error {
+	switch dim := dimension(dim.String()); dim {
+	case dimArticulation, dimBridges, dimCore:
+		s.dim = dim
+		return nil
+	}
+	return fmt.Errorf("dimension '%s' not known to this graph", dim)
+}
+
+var _ walder.Graph = &structure{}
+
+func (s *structure) String() string {
+	if s.origin == nil {
+		return "empty structure"
+	}
+	if s.dim == dimCore {
+		return fmt.Sprintf("%d-core of %s", s.k, s.origin.String())
+	}
+	return fmt.Sprintf("%s of %s", s.dim, s.origin.String())
+}
+
+// HomeNodes returns all kept nodes, since the filtered graph is mostly disconnected
+func (s *structure) HomeNodes() ([]fmt.Stringer, error) {
+	return s.NodeAll()
+}
+
+var _ walder.NodeAller = &structure{}
+
+func (s *structure) NodeAll() ([]fmt.Stringer, error) {
+	var all []fmt.Stringer
+	for _, n := range s.nodes {
+		if s.kept(n) {
+			all = append(all, n)
+		}
+	}
+	return all, nil
+}
+
+var _ walder.GraphDirected = &structure{}
+
+// Outgoing uses the edges of the origin, which are the neighbors for undirected graphs
+func (s *structure) Outgoing(node fmt.Stringer) ([]fmt.Stringer, error) {
+	if err := s.check(node); err != nil {
+		return nil, err
+	}
+	switch origin := s.origin.(type) {
+	case walder.GraphDirected:
+		out, err := origin.Outgoing(node)
+		return s.filter(node, out, err)
+	case walder.GraphNeighbors:
+		return s.Neighbors(node)
+	}
+	return nil, fmt.Errorf("want %s or %s, but got %T", graphNeighborsString, graphDirectedString, s.origin)
+}
+func (s *structure) Incoming(node fmt.Stringer) ([]fmt.Stringer, error) {
+	if err := s.check(node); err != nil {
+		return nil, err
+	}
+	switch origin := s.origin.(type) {
+	case walder.GraphDirected:
+		in, err := origin.Incoming(node)
+		return s.filter(node, in, err)
+	case walder.GraphNeighbors:
+		return s.Neighbors(node)
+	}
+	return nil, fmt.Errorf("want %s or %s, but got %T", graphNeighborsString, graphDirectedString, s.origin)
+}
+
+var _ walder.GraphNeighbors = &structure{}
+
+func (s *structure) Neighbors(node fmt.Stringer) ([]fmt.Stringer, error) {
+	if err := s.check(node); err != nil {
+		return nil, err
+	}
+	switch origin := s.origin.(type) {
+	case walder.GraphNeighbors:
+		neighbors, err := origin.Neighbors(node)
+		return s.filter(node, neighbors, err)
+	case walder.GraphDirected:
+		out, err := s.Outgoing(node)
+		if err != nil {
+			return nil, err
+		}
+		in, err := s.Incoming(node)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		var neighbors []fmt.Stringer
+		for _, n := range append(out, in...) {
+			if !seen[n.String()] {
+				seen[n.String()] = true
+				neighbors = append(neighbors, n)
+			}
+		}
+		return neighbors, nil
+	}
+	return nil, fmt.Errorf("want %s or %s, but got %T", graphNeighborsString, graphDirectedString, s.origin)
+}
+
+var _ walder.NodeLabeler = &structure{}
+
+// NodeLabels returns the labels of the origin followed by the core number
+func (s *structure) NodeLabels(node fmt.Stringer) ([][2]string, error) {
+	if err := s.check(node); err != nil {
+		return nil, err
+	}
+	var labels [][2]string
+	if l, ok := s.origin.(walder.NodeLabeler); ok {
+		var err error
+		labels, err = l.NodeLabels(node)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(labels, [2]string{"core", strconv.Itoa(s.core[node.String()])}), nil
+}
@@ -0,0 +1,145 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/treilik/walder"
+)
+
+// openDot returns the dot graph of the source or fails the test
+func openDot(t *testing.T, source string) *DotGraph {
+	t.Helper()
+	g, err := DotDim{}.Open(strings.NewReader(source))
+	if err != nil {
+		t.Fatalf("open %q: %v", source, err)
+	}
+	d, ok := g.(*DotGraph)
+	if !ok {
+		t.Fatalf("want %T, but got %T", d, g)
+	}
+	return d
+}
+
+// dotNode returns the node of the dot graph with the name
+func dotNode(t *testing.T, d *DotGraph, name string) fmt.Stringer {
+	t.Helper()
+	all, err := d.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range all {
+		if n.String() == name {
+			return n
+		}
+	}
+	t.Fatalf("no node '%s' in %s", name, d)
+	return nil
+}
+
+// names returns the strings of the nodes joined by spaces
+func names(nodes []fmt.Stringer) string {
+	strs := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		strs = append(strs, n.String())
+	}
+	return strings.Join(strs, " ")
+}
+
+// sortedNames returns the strings of the nodes sorted and joined by spaces
+func sortedNames(nodes []fmt.Stringer) string {
+	strs := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		strs = append(strs, n.String())
+	}
+	sort.Strings(strs)
+	return strings.Join(strs, " ")
+}
+
+// edgeNames returns the edges as from->to sorted and joined by spaces
+func edgeNames(edges [][2]fmt.Stringer) string {
+	strs := make([]string, 0, len(edges))
+	for _, e := range edges {
+		strs = append(strs, e[0].String()+"->"+e[1].String())
+	}
+	sort.Strings(strs)
+	return strings.Join(strs, " ")
+}
+
+// labelValue returns the value of the first label with the key
+func labelValue(labels [][2]string, key string) (string, bool) {
+	for _, kv := range labels {
+		if kv[0] == key {
+			return kv[1], true
+		}
+	}
+	return "", false
+}
+
+// readAll writes the graph and fails the test on errors
+func readAll(t *testing.T, g walder.Graph) string {
+	t.Helper()
+	getter, ok := g.(walder.GetReader)
+	if !ok {
+		t.Fatalf("%T does not implement %s", g, getReaderString)
+	}
+	r, err := getter.GetReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// findNode returns the first node of the graph with the string
+func findNode(t *testing.T, g walder.NodeAller, name string) fmt.Stringer {
+	t.Helper()
+	all, err := g.NodeAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range all {
+		if n.String() == name {
+			return n
+		}
+	}
+	t.Fatalf("no node '%s' in %s", name, g)
+	return nil
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"2", 2, false},
+		{`"2.5"`, 2.5, false},
+		{"-1", -1, false},
+		{"heavy", 0, true},
+		{`""`, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := parseNumber(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("want error, but got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("want %v, but got %v", test.want, got)
+			}
+		})
+	}
+}
@@ -12,7 +12,8 @@ import (
 )
 
 var (
-	style = termenv.Style{}.Foreground(termenv.ANSIRed)
+	style          = termenv.Style{}.Foreground(termenv.ANSIRed)
+	highlightStyle = termenv.Style{}.Underline()
 )
 
 type holder struct {
@@ -52,6 +53,9 @@ func (h holder) String() string {
 
 			}
 		}
+		if _, ok := h.g.highlighted[h.content.String()]; ok {
+			content = highlightStyle.Styled(content)
+		}
 	}
 	// overwrite type // TODO change
 	if h.selected {
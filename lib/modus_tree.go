@@ -18,9 +18,10 @@ func newTreeModus(g walder.Graph) graphHolder {
 
 	b := boxer.Boxer{}
 	h := graphHolder{
-		focus: mainAddr,
-		graph: g,
-		boxer: &b,
+		focus:       mainAddr,
+		graph:       g,
+		boxer:       &b,
+		highlighted: make(map[string]struct{}),
 		updateFunc: func(g *graphHolder, w walder.Graph) {
 			defer g.setFocus()
 			in(g)
@@ -303,6 +304,23 @@ func (b *graphHolder) resetOutgoing(stringerList ...fmt.Stringer) {
 	})
 }
 
+// highlight replaces the highlighted nodes, which are marked where ever they are listed
+func (b *graphHolder) highlight(nodes ...fmt.Stringer) error {
+	if b.highlighted == nil {
+		return fmt.Errorf("highlighting is only possible in tree modus")
+	}
+	for key := range b.highlighted {
+		delete(b.highlighted, key)
+	}
+	for _, n := range nodes {
+		if n == nil {
+			return fmt.Errorf("recieved nil value")
+		}
+		b.highlighted[n.String()] = struct{}{}
+	}
+	return nil
+}
+
 func (b *graphHolder) moveIncoming() error {
 	var empty bool
 	_ = b.editList(inAddr, func(l *holderList) error {
@@ -28,6 +28,10 @@ type graphHolder struct {
 
 	lastInPosition  map[string]int
 	lastOutPosition map[string]int
+
+	// highlighted holds the strings of the nodes which are highlighted in all lists,
+	// it is shared by the copies of the holder and thus only changed in place
+	highlighted map[string]struct{}
 }
 
 func (g *graphHolder) update() {
@@ -26,8 +26,11 @@ const (
 	inputAddr = "input"
 )
 
+// syncer is send through the Syncer into Update, since only there the walder may be changed
 type syncer struct {
 	cmd *command
+	// do is run within Update, after work done in the background
+	do func(w *Walder) error
 }
 
 type internal interface {
@@ -493,6 +496,11 @@ func (w *Walder) Update(msg tea.Msg) (m tea.Model, _ tea.Cmd) {
 		w.peek().boxer = &b
 		w.addError(err)
 		return w, nil
+	case syncer:
+		if msg.do != nil {
+			w.addError(msg.do(w))
+		}
+		return w, nil
 	default:
 		w.addError(fmt.Errorf("unknown message: '%#v'", msg))
 	}